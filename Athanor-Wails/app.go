@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -27,22 +28,49 @@ type App struct {
 
 	currentJobID atomic.Value
 	isProcessing atomic.Bool
+
+	queueMu          sync.Mutex
+	queue            []*QueueJob
+	queueRunning     int
+	queueFastRunning int
+	queueConcurrency int
+
+	jobsMu  sync.Mutex
+	jobs    map[string]jobRecord
+	cancels map[string]context.CancelFunc
+
+	configMu   sync.RWMutex
+	config     Config
+	configPath string
+	// saveMu serializes SaveConfig and SetSettings, which both rewrite the
+	// config file.
+	saveMu sync.Mutex
 }
 
 type ConversionProgress struct {
-	JobID        string  `json:"jobId"`
-	Stage        string  `json:"stage"`
-	Progress     float64 `json:"progress"`
-	Message      string  `json:"message"`
-	IsComplete   bool    `json:"isComplete"`
-	IsError      bool    `json:"isError"`
-	OutputPath   string  `json:"outputPath,omitempty"`
-	MarkdownPath string  `json:"markdownPath,omitempty"`
+	JobID        string        `json:"jobId"`
+	Stage        string        `json:"stage"`
+	Progress     float64       `json:"progress"`
+	Message      string        `json:"message"`
+	IsComplete   bool          `json:"isComplete"`
+	IsError      bool          `json:"isError"`
+	OutputPath   string        `json:"outputPath,omitempty"`
+	MarkdownPath string        `json:"markdownPath,omitempty"`
+	Warnings     []rag.Warning `json:"warnings,omitempty"`
+}
+
+// ChapterProgress is emitted as "conversion:chapter" whenever a chapter file
+// has been written, so the frontend can open it before the job finishes.
+type ChapterProgress struct {
+	JobID string `json:"jobId"`
+	rag.ChapterOutput
 }
 
 func NewApp() *App {
 	return &App{
-		logBuffer: make([]string, 0, 2000),
+		logBuffer:        make([]string, 0, 2000),
+		queueConcurrency: defaultQueueConcurrency,
+		config:           defaultConfig(),
 	}
 }
 
@@ -53,6 +81,10 @@ func (a *App) startup(ctx context.Context) {
 
 	a.log("Athanor RAG Edition")
 	a.log("Target: EPUB -> RAG Markdown")
+
+	if _, err := a.LoadConfig(); err != nil {
+		a.log("WARN: " + err.Error())
+	}
 }
 
 func (a *App) Shutdown(ctx context.Context) {
@@ -115,6 +147,33 @@ func (a *App) GetLogsSince(since int) map[string]interface{} {
 	}
 }
 
+// logLines returns the buffered log lines with sequence numbers in
+// [from, to). Lines that were already rotated out are skipped.
+func (a *App) logLines(from, to int) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	earliest := a.logSeq - len(a.logBuffer)
+	if from < earliest {
+		from = earliest
+	}
+	if to > a.logSeq {
+		to = a.logSeq
+	}
+	if from >= to {
+		return nil
+	}
+	out := make([]string, to-from)
+	copy(out, a.logBuffer[from-earliest:to-earliest])
+	return out
+}
+
+func (a *App) currentLogSeq() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.logSeq
+}
+
 func (a *App) SelectEpub() (string, error) {
 	if a.ctx == nil {
 		return "", fmt.Errorf("context not ready")
@@ -146,14 +205,119 @@ func (a *App) SelectEpub() (string, error) {
 	return path, nil
 }
 
+// SelectOutputDir lets the user write outputs somewhere other than next to
+// the input, e.g. when the EPUB lives on a read-only network share. The
+// choice is saved to the config and used by every later job.
+func (a *App) SelectOutputDir() (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("context not ready")
+	}
+
+	dir, err := wailsRuntime.OpenDirectoryDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title:                "选择输出目录",
+		DefaultDirectory:     a.currentConfig().OutputDir,
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	if dir == "" {
+		a.log("User cancelled output directory selection")
+		return "", nil
+	}
+	if err := checkOutputDir(dir); err != nil {
+		return "", err
+	}
+
+	cfg := a.currentConfig()
+	cfg.OutputDir = dir
+	if _, err := a.SaveConfig(cfg); err != nil {
+		return "", err
+	}
+	a.log(fmt.Sprintf("Output directory: %s", dir))
+	return dir, nil
+}
+
 func (a *App) ConvertBook(inputPath string, outputFormat string) ConversionProgress {
 	if !a.isProcessing.CompareAndSwap(false, true) {
 		return a.fail("", "系统忙，请等待当前任务完成")
 	}
 	defer a.isProcessing.Store(false)
 
-	jobID := fmt.Sprintf("job_%d", time.Now().UnixNano())
+	jobID := newJobID()
 	a.currentJobID.Store(jobID)
+	return a.runConversion(jobID, inputPath, outputFormat)
+}
+
+// PreviewConversion renders the first chapter of a book with the current
+// config and returns it without writing anything, so settings can be
+// checked before a full run.
+func (a *App) PreviewConversion(inputPath string) (rag.Preview, error) {
+	if !strings.HasSuffix(strings.ToLower(inputPath), ".epub") {
+		return rag.Preview{}, fmt.Errorf("仅支持 EPUB 文件")
+	}
+	preview, err := rag.PreviewEPUB(a.ctx, inputPath, a.conversionOptions(inputPath))
+	if err != nil {
+		return rag.Preview{}, err
+	}
+	a.log(fmt.Sprintf("Preview: %s (%s)", filepath.Base(inputPath), preview.Chapter))
+	return preview, nil
+}
+
+// CancelJob aborts a running conversion, or drops a job that is still
+// waiting in the queue. Chapter files are written as they are rendered, so a
+// job cancelled part way may leave some of them behind; the main Markdown and
+// the other outputs are only written at the end of a run.
+func (a *App) CancelJob(jobID string) error {
+	a.jobsMu.Lock()
+	cancel, running := a.cancels[jobID]
+	a.jobsMu.Unlock()
+	if running {
+		a.log(fmt.Sprintf("Cancelling: %s", jobID))
+		cancel()
+		return nil
+	}
+	if a.cancelQueued(jobID) {
+		a.cancelled(jobID)
+		a.emitQueue()
+		return nil
+	}
+	return fmt.Errorf("任务不存在或已结束: %s", jobID)
+}
+
+func (a *App) runConversion(jobID, inputPath, outputFormat string) (outcome ConversionProgress) {
+	logStart := a.currentLogSeq()
+	baseName := ""
+	defer func() {
+		if outcome.IsError {
+			a.recordJob(jobID, jobRecord{
+				InputPath:    inputPath,
+				OutputFormat: outputFormat,
+				BaseName:     baseName,
+				Error:        outcome.Message,
+				logStart:     logStart,
+				logEnd:       a.currentLogSeq(),
+			})
+		}
+	}()
+
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	a.jobsMu.Lock()
+	if a.cancels == nil {
+		a.cancels = map[string]context.CancelFunc{}
+	}
+	a.cancels[jobID] = cancel
+	a.jobsMu.Unlock()
+	defer func() {
+		a.jobsMu.Lock()
+		delete(a.cancels, jobID)
+		a.jobsMu.Unlock()
+		cancel()
+	}()
 
 	inputInfo, err := os.Stat(inputPath)
 	if err != nil {
@@ -166,16 +330,40 @@ func (a *App) ConvertBook(inputPath string, outputFormat string) ConversionProgr
 	a.progress(jobID, "init", 0, "初始化转换")
 	a.log(fmt.Sprintf("Input: %s (%.2f MB)", filepath.Base(inputPath), float64(inputInfo.Size())/1024/1024))
 
-	options := rag.Options{
-		OutputRootDir: filepath.Dir(inputPath),
-		BaseName:      outputPathBase(inputPath),
-		Logger:        a.log,
-		Progress: func(stage string, pct float64, message string) {
-			a.progress(jobID, stage, pct, message)
-		},
+	options := a.conversionOptions(inputPath)
+	if err := checkOutputDir(options.OutputRootDir); err != nil {
+		return a.fail(jobID, err.Error())
+	}
+	if outputFormat == "" {
+		outputFormat = a.currentConfig().OutputFormat
+	}
+	if err := rag.ApplyOutputFormat(&options, outputFormat); err != nil {
+		return a.fail(jobID, err.Error())
+	}
+	var series rag.Metadata
+	if options.SeriesNumbering {
+		if series, err = rag.ReadSeries(inputPath); err != nil {
+			return a.fail(jobID, err.Error())
+		}
+	}
+	if !a.resolveConflict(&options, series) {
+		return a.skipped(jobID, filepath.Join(options.OutputRootDir, finalBaseName(options, series)+".md"))
+	}
+	baseName = finalBaseName(options, series)
+	options.Logger = a.log
+	options.Progress = func(stage string, pct float64, message string) {
+		a.progress(jobID, stage, pct, message)
+	}
+	options.ChapterWritten = func(chapter rag.ChapterOutput) {
+		if a.ctx != nil {
+			wailsRuntime.EventsEmit(a.ctx, "conversion:chapter", ChapterProgress{JobID: jobID, ChapterOutput: chapter})
+		}
 	}
 
-	result, err := rag.ConvertEPUB(a.ctx, inputPath, options)
+	result, err := rag.ConvertEPUB(ctx, inputPath, options)
+	if errors.Is(err, context.Canceled) {
+		return a.cancelled(jobID)
+	}
 	if err != nil {
 		return a.fail(jobID, err.Error())
 	}
@@ -189,6 +377,36 @@ func (a *App) ConvertBook(inputPath string, outputFormat string) ConversionProgr
 	a.log(fmt.Sprintf("TOC: %s", result.TOCPath))
 	a.log(fmt.Sprintf("Chunks: %s", result.ChunksPath))
 	a.log(fmt.Sprintf("Diagnostics: %s", result.DiagnosticsPath))
+	if result.TextPath != "" {
+		a.log(fmt.Sprintf("Text: %s", result.TextPath))
+	}
+	if result.AsciiDocPath != "" {
+		a.log(fmt.Sprintf("AsciiDoc: %s", result.AsciiDocPath))
+	}
+	if result.DocBookPath != "" {
+		a.log(fmt.Sprintf("DocBook: %s", result.DocBookPath))
+	}
+	if result.SQLScriptPath != "" {
+		a.log(fmt.Sprintf("SQL: %s", result.SQLScriptPath))
+	}
+	if result.DOCXPath != "" {
+		a.log(fmt.Sprintf("DOCX: %s", result.DOCXPath))
+	}
+	if result.ODTPath != "" {
+		a.log(fmt.Sprintf("ODT: %s", result.ODTPath))
+	}
+	if result.RTFPath != "" {
+		a.log(fmt.Sprintf("RTF: %s", result.RTFPath))
+	}
+	if result.HTMLPath != "" {
+		a.log(fmt.Sprintf("HTML: %s", result.HTMLPath))
+	}
+	if result.CoverPath != "" {
+		a.log(fmt.Sprintf("Cover: %s", result.CoverPath))
+	}
+	if result.ReadabilityPath != "" {
+		a.log(fmt.Sprintf("Readability: %s", result.ReadabilityPath))
+	}
 
 	if summary, err := json.MarshalIndent(result.Stats, "", "  "); err == nil {
 		a.log("Stats:")
@@ -197,37 +415,31 @@ func (a *App) ConvertBook(inputPath string, outputFormat string) ConversionProgr
 		}
 	}
 
+	message := "转换成功"
+	if len(result.Warnings) > 0 {
+		a.log(fmt.Sprintf("Warnings: %d", len(result.Warnings)))
+		message = fmt.Sprintf("转换完成，有 %d 个警告", len(result.Warnings))
+	}
+
 	a.progress(jobID, "complete", 100, "转换完成")
+	a.recordJob(jobID, jobRecord{
+		InputPath: inputPath,
+		Result:    result,
+		logStart:  logStart,
+		logEnd:    a.currentLogSeq(),
+	})
 	return ConversionProgress{
 		JobID:        jobID,
 		Stage:        "complete",
 		Progress:     100,
 		IsComplete:   true,
-		Message:      "转换成功",
+		Message:      message,
 		OutputPath:   result.MainMarkdownPath,
 		MarkdownPath: result.MainMarkdownPath,
+		Warnings:     result.Warnings,
 	}
 }
 
-func outputPathBase(input string) string {
-	name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
-	name = strings.TrimSpace(strings.NewReplacer(
-		"/", "_",
-		"\\", "_",
-		":", "_",
-		"*", "_",
-		"?", "_",
-		"\"", "_",
-		"<", "_",
-		">", "_",
-		"|", "_",
-	).Replace(name))
-	if name == "" {
-		name = "book"
-	}
-	return name + "_athanor"
-}
-
 func (a *App) fail(jobID, msg string) ConversionProgress {
 	a.log("ERROR: " + msg)
 
@@ -251,8 +463,24 @@ func (a *App) fail(jobID, msg string) ConversionProgress {
 	}
 }
 
+func (a *App) cancelled(jobID string) ConversionProgress {
+	a.log("Cancelled: " + jobID)
+
+	result := ConversionProgress{
+		JobID:      jobID,
+		Stage:      "cancelled",
+		IsComplete: true,
+		Message:    "任务已取消",
+	}
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "conversion:progress", result)
+	}
+	return result
+}
+
 func (a *App) progress(jobID, stage string, pct float64, msg string) {
 	a.log(msg)
+	a.updateQueueProgress(jobID, pct, msg)
 	if a.ctx != nil {
 		wailsRuntime.EventsEmit(a.ctx, "conversion:progress", ConversionProgress{
 			JobID:    jobID,
@@ -13,7 +13,7 @@ import (
 )
 
 func TestOutputPathBase(t *testing.T) {
-	got := outputPathBase(`D:\books\测试.epub`)
+	got := rag.DefaultBaseName(`D:\books\测试.epub`)
 	if got != "测试_athanor" {
 		t.Fatalf("unexpected output base: %s", got)
 	}
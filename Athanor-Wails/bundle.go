@@ -0,0 +1,151 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"Athanor-Wails/internal/rag"
+)
+
+// jobRecord keeps what a finished job needs for a bundle or, when Error is
+// set, a failure report.
+type jobRecord struct {
+	InputPath    string
+	OutputFormat string
+	Result       rag.ConvertResult
+	Error        string
+	// BaseName is the output name the job ended up with, after series
+	// numbering and conflict renaming.
+	BaseName string
+	logStart int
+	logEnd   int
+}
+
+func (a *App) recordJob(jobID string, record jobRecord) {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+
+	if a.jobs == nil {
+		a.jobs = map[string]jobRecord{}
+	}
+	a.jobs[jobID] = record
+}
+
+// ExportJobBundle zips everything a finished job produced, together with the
+// log lines written while it ran, so a bad conversion can be shared in one
+// file when asking for help.
+func (a *App) ExportJobBundle(jobID string) (string, error) {
+	a.jobsMu.Lock()
+	record, ok := a.jobs[jobID]
+	a.jobsMu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("任务不存在或尚未完成: %s", jobID)
+	}
+	if record.Error != "" {
+		return "", fmt.Errorf("任务失败，没有可打包的输出: %s", jobID)
+	}
+
+	artifactDir := record.Result.ArtifactDir
+	bundlePath := artifactDir + "_bundle.zip"
+	if err := writeJobBundle(bundlePath, jobID, record, a.logLines(record.logStart, record.logEnd)); err != nil {
+		return "", err
+	}
+	a.log(fmt.Sprintf("Bundle: %s", bundlePath))
+	return bundlePath, nil
+}
+
+func writeJobBundle(bundlePath, jobID string, record jobRecord, logs []string) error {
+	file, err := os.Create(bundlePath)
+	if err != nil {
+		return fmt.Errorf("创建任务包失败: %w", err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	artifactDir := record.Result.ArtifactDir
+	base := filepath.Base(artifactDir)
+
+	// The main Markdown and the single-file exports sit next to the
+	// artifact directory; everything else is inside it.
+	for _, path := range []string{
+		record.Result.MainMarkdownPath,
+		record.Result.TextPath,
+		record.Result.AsciiDocPath,
+		record.Result.DocBookPath,
+		record.Result.SQLScriptPath,
+		record.Result.DOCXPath,
+		record.Result.ODTPath,
+		record.Result.RTFPath,
+	} {
+		if path == "" {
+			continue
+		}
+		if err := addFileToZip(writer, path, filepath.Base(path)); err != nil {
+			return err
+		}
+	}
+	err = filepath.Walk(artifactDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(artifactDir, path)
+		if err != nil {
+			return err
+		}
+		return addFileToZip(writer, path, filepath.ToSlash(filepath.Join(base, rel)))
+	})
+	if err != nil {
+		return fmt.Errorf("打包输出目录失败: %w", err)
+	}
+
+	entry, err := writer.Create("logs.txt")
+	if err != nil {
+		return fmt.Errorf("写入任务包失败: %w", err)
+	}
+	if _, err := io.WriteString(entry, strings.Join(logs, "\n")+"\n"); err != nil {
+		return fmt.Errorf("写入任务包失败: %w", err)
+	}
+
+	job, err := json.MarshalIndent(map[string]any{
+		"jobId":     jobID,
+		"inputPath": record.InputPath,
+		"result":    record.Result,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化任务信息失败: %w", err)
+	}
+	entry, err = writer.Create("job.json")
+	if err != nil {
+		return fmt.Errorf("写入任务包失败: %w", err)
+	}
+	if _, err := entry.Write(job); err != nil {
+		return fmt.Errorf("写入任务包失败: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("写入任务包失败: %w", err)
+	}
+	return nil
+}
+
+func addFileToZip(writer *zip.Writer, path, name string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %w", filepath.Base(path), err)
+	}
+	defer source.Close()
+
+	entry, err := writer.Create(name)
+	if err != nil {
+		return fmt.Errorf("写入任务包失败: %w", err)
+	}
+	if _, err := io.Copy(entry, source); err != nil {
+		return fmt.Errorf("写入任务包失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportJobBundle(t *testing.T) {
+	workDir := filepath.Join(".", ".tmp", "test-bundle")
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatalf("mkdir work dir: %v", err)
+	}
+	input := filepath.Join(workDir, "bundle.epub")
+	createSampleEPUB(t, input)
+
+	app := NewApp()
+	if _, err := app.ExportJobBundle("job_missing"); err == nil {
+		t.Fatal("expected unknown job to fail")
+	}
+	result := app.ConvertBook(input, "md,txt,adoc,docbook,sql,docx,odt,rtf")
+	if result.IsError {
+		t.Fatalf("conversion failed: %s", result.Message)
+	}
+
+	bundlePath, err := app.ExportJobBundle(result.JobID)
+	if err != nil {
+		t.Fatalf("export bundle: %v", err)
+	}
+	reader, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		t.Fatalf("open bundle: %v", err)
+	}
+	defer reader.Close()
+
+	names := map[string]bool{}
+	for _, file := range reader.File {
+		names[file.Name] = true
+	}
+	for _, name := range []string{
+		"bundle_athanor.md",
+		"bundle_athanor.txt",
+		"bundle_athanor.adoc",
+		"bundle_athanor.docbook.xml",
+		"bundle_athanor.sql",
+		"bundle_athanor.docx",
+		"bundle_athanor.odt",
+		"bundle_athanor.rtf",
+		"bundle_athanor/diagnostics.json",
+		"bundle_athanor/chapters/chapter-001.md",
+		"logs.txt",
+		"job.json",
+	} {
+		if !names[name] {
+			t.Fatalf("bundle missing %s, got %v", name, names)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"Athanor-Wails/pkg/athanor"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `usage: athanor convert [flags] book.epub [more.epub ...]
+       athanor convert [flags] --manifest=books.json
+
+flags:
+  --format=md   output formats, comma separated: md, txt, adoc, docbook, sql, docx,
+                odt, rtf, html; txt:N wraps the plain text at N columns,
+                html:vertical opens the reader in vertical (tategaki) writing,
+                html:readable in its dyslexia-friendly font and spacing,
+                html:dark in its dark theme with dimmed images; the html modes
+                combine, e.g. html:readable:dark
+  --reference-doc=F
+                take the DOCX output's styles from the .docx F, e.g. a pandoc
+                reference.docx restyled in Word
+  --wrap=MODE   line wrapping for Markdown and plain text: none (default) or auto;
+                a number N is short for --wrap=auto --columns=N
+  --columns=N   wrap width for --wrap=auto (default: 72)
+  --out=DIR     output directory (default: next to each input)
+  --series-numbering
+                put the series name and volume number in front of the output
+                names of books with calibre or EPUB 3 series metadata
+  --on-conflict=P
+                when a book's outputs already exist: overwrite (default), rename
+                (adds _2, _3, ...) or skip
+  --readability write per-chapter readability scores to readability.json
+  --cover=MODE  cover image: auto (default; shown in the HTML reader), extract
+                (also copied to cover.<ext>) or skip (left out everywhere)
+  --include-orphans
+                also convert documents the manifest lists but the spine leaves out
+  --drop-duplicates
+                remove chapters whose text repeats an earlier chapter instead of
+                only reporting them
+  --math=STYLE  Markdown math delimiters: dollar ($, $$), latex (\( \), \[ \]) or fenced
+  --images=MODE image references: strip (default), relative, absolute or embed
+  --emphasis    turn italic and bold CSS classes and tags into *...* and **...**
+  --blockquotes turn bordered or deeply indented paragraphs into blockquotes
+  --alignment   keep centred and right-aligned paragraphs and centred images in
+                the HTML reader and the DOCX, ODT and RTF exports
+  --callouts    turn asides and sidebar, tip and warning boxes into GitHub-style
+                alerts (> [!NOTE], > [!TIP], > [!WARNING])
+  --headings=R  heading levels: keep (default; as in the source), compact (top
+                level becomes 1 and gaps close) or shift (top level becomes 1)
+  --dir=DIR     HTML reader text direction: auto (from the book language), ltr or rtl
+  --chinese=C   convert Chinese text: s2t (Simplified to Traditional) or t2s
+  --toc-depth=N list chapters down to navigation level N (1-4) in the HTML reader's
+                contents (default: every level); --toc=false leaves the list out
+  --on-warning=P what non-fatal problems (spine errors, DRM content, missing
+                images, ...) do: report (default; listed after the outputs),
+                fail (the book fails before anything is written) or ignore
+  --notes=P     where the main Markdown puts footnotes: chapter (default; after
+                each chapter) or book (one notes section at the end)
+  --labels=L    language of the text Athanor adds itself (footnote headings, the
+                HTML pager, the untitled-book title, ...): zh, en or auto (from
+                the book language); default keeps Chinese document labels and
+                English chunk labels
+  --provenance  end the Markdown with a comment recording the source SHA-256,
+                pipeline version and effective options
+  --timeout=D   give up on a book after duration D, e.g. 90s or 5m (default: none)
+  --dry-run     convert nothing; print a JSON plan per book with the outputs that
+                would be written (and whether they exist), chapter, chunk and
+                image counts, predicted issues and the estimated time
+  --manifest=F  also convert the books listed in F, a JSON array or a CSV file
+                with the columns input, name, out, format, wrap, columns, math,
+                images, dir and chinese; set fields override the flags for that book
+  --quiet       only print errors
+
+usage: athanor scan [--csv] library/ [more/ ...]
+
+Inspects every EPUB under the folders without converting it and prints a
+JSON (or CSV) report with size, language, chapter, character and image
+counts, DRM flags and parse time.
+
+Every flag can also be set through an ATHANOR_<FLAG> environment variable,
+with dashes in the flag name written as underscores, e.g. ATHANOR_OUT=/data/out,
+ATHANOR_QUIET=true or ATHANOR_TOC_DEPTH=2. Flags on the command line take
+precedence over the environment.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) > 0 && args[0] == "scan" {
+		return scan(args[1:])
+	}
+	if len(args) == 0 || args[0] != "convert" {
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	var base settings
+	fs.StringVar(&base.Format, "format", "md", "")
+	fs.StringVar(&base.ReferenceDoc, "reference-doc", "", "")
+	fs.StringVar(&base.Wrap, "wrap", "none", "")
+	fs.IntVar(&base.Columns, "columns", 0, "")
+	fs.StringVar(&base.Out, "out", "", "")
+	fs.BoolVar(&base.SeriesNumbering, "series-numbering", false, "")
+	onConflict := fs.String("on-conflict", conflictOverwrite, "")
+	fs.BoolVar(&base.Readability, "readability", false, "")
+	fs.StringVar(&base.Cover, "cover", "auto", "")
+	fs.BoolVar(&base.IncludeOrphans, "include-orphans", false, "")
+	fs.BoolVar(&base.DropDuplicates, "drop-duplicates", false, "")
+	quiet := fs.Bool("quiet", false, "")
+	fs.BoolVar(&base.Provenance, "provenance", false, "")
+	timeout := fs.Duration("timeout", 0, "")
+	fs.StringVar(&base.Math, "math", "dollar", "")
+	fs.StringVar(&base.Images, "images", "strip", "")
+	fs.BoolVar(&base.Emphasis, "emphasis", false, "")
+	fs.BoolVar(&base.Blockquotes, "blockquotes", false, "")
+	fs.BoolVar(&base.Alignment, "alignment", false, "")
+	fs.BoolVar(&base.Callouts, "callouts", false, "")
+	fs.StringVar(&base.Headings, "headings", "keep", "")
+	fs.StringVar(&base.Dir, "dir", "auto", "")
+	fs.StringVar(&base.Chinese, "chinese", "", "")
+	fs.BoolVar(&base.TOC, "toc", true, "")
+	fs.IntVar(&base.TOCDepth, "toc-depth", 0, "")
+	fs.StringVar(&base.OnWarning, "on-warning", "report", "")
+	fs.StringVar(&base.Notes, "notes", "chapter", "")
+	fs.StringVar(&base.Labels, "labels", "", "")
+	manifest := fs.String("manifest", "", "")
+	dryRun := fs.Bool("dry-run", false, "")
+	if err := applyEnv(fs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	inputs, err := parseInterleaved(fs, args[1:])
+	if err != nil {
+		return exitUsage
+	}
+	switch *onConflict {
+	case conflictOverwrite, conflictRename, conflictSkip:
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported conflict policy %q: use overwrite, rename or skip\n", *onConflict)
+		return exitUsage
+	}
+	var entries []manifestEntry
+	if *manifest != "" {
+		if entries, err = readManifest(*manifest); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+	for _, input := range inputs {
+		entries = append(entries, manifestEntry{Input: input})
+	}
+	if len(entries) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	}
+	jobs := make([]athanor.Options, len(entries))
+	for i, entry := range entries {
+		if jobs[i], err = entry.apply(base).options(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filepath.Base(entry.Input), err)
+			return exitUsage
+		}
+		jobs[i].BaseName = entry.Name
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *dryRun {
+		return plan(ctx, entries, jobs)
+	}
+	status := exitOK
+	for i, entry := range entries {
+		if err := convert(ctx, entry.Input, jobs[i], *quiet, *timeout, *onConflict); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filepath.Base(entry.Input), err)
+			status = exitError
+		}
+		if ctx.Err() != nil {
+			return exitError
+		}
+	}
+	return status
+}
+
+// settings holds the per-book conversion flags. A manifest entry overrides
+// the ones it sets.
+type settings struct {
+	Format          string
+	Wrap            string
+	Columns         int
+	Out             string
+	Math            string
+	Images          string
+	Emphasis        bool
+	Blockquotes     bool
+	Alignment       bool
+	Callouts        bool
+	Headings        string
+	Dir             string
+	Chinese         string
+	TOC             bool
+	TOCDepth        int
+	OnWarning       string
+	Notes           string
+	Labels          string
+	Provenance      bool
+	SeriesNumbering bool
+	Readability     bool
+	Cover           string
+	IncludeOrphans  bool
+	DropDuplicates  bool
+	ReferenceDoc    string
+}
+
+func (s settings) options() (athanor.Options, error) {
+	options := athanor.Options{OutputRootDir: s.Out, Columns: s.Columns, Provenance: s.Provenance, TOCDepth: s.TOCDepth, NoTOC: !s.TOC, SeriesNumbering: s.SeriesNumbering,
+		Readability: s.Readability, IncludeOrphans: s.IncludeOrphans, DropDuplicates: s.DropDuplicates, DOCXReference: s.ReferenceDoc}
+	switch mode := strings.ToLower(s.Wrap); mode {
+	case "none", "":
+		options.Wrap = athanor.WrapNone
+	case "auto":
+		options.Wrap = athanor.WrapAuto
+	default:
+		n, err := strconv.Atoi(mode)
+		if err != nil || n <= 0 {
+			return options, fmt.Errorf("unsupported wrap mode %q: use none, auto or a column count", s.Wrap)
+		}
+		options.Wrap = athanor.WrapAuto
+		options.Columns = n
+	}
+	if options.Columns < 0 {
+		return options, fmt.Errorf("invalid --columns=%d: must be positive", options.Columns)
+	}
+	if options.Wrap == athanor.WrapAuto {
+		options.TextWrap = options.Columns
+		if options.TextWrap == 0 {
+			options.TextWrap = athanor.DefaultWrapColumns
+		}
+	}
+	if err := athanor.ApplyOutputFormat(&options, s.Format); err != nil {
+		return options, fmt.Errorf("invalid --format=%s: %w", s.Format, err)
+	}
+	switch cover := strings.ToLower(s.Cover); cover {
+	case "auto", "":
+		options.Cover = athanor.CoverModeAuto
+	case "extract", "skip":
+		options.Cover = athanor.CoverMode(cover)
+	default:
+		return options, fmt.Errorf("unsupported cover mode %q: use auto, extract or skip", s.Cover)
+	}
+	switch style := strings.ToLower(s.Math); style {
+	case "dollar", "":
+		options.Styles.Math = athanor.MathStyleDollar
+	case "latex", "fenced":
+		options.Styles.Math = athanor.MathStyle(style)
+	default:
+		return options, fmt.Errorf("unsupported math style %q: use dollar, latex or fenced", s.Math)
+	}
+	switch mode := strings.ToLower(s.Images); mode {
+	case "strip", "":
+		options.Styles.Images = athanor.ImageModeStrip
+	case "relative", "absolute", "embed":
+		options.Styles.Images = athanor.ImageMode(mode)
+	default:
+		return options, fmt.Errorf("unsupported image mode %q: use strip, relative, absolute or embed", s.Images)
+	}
+	options.Styles.Emphasis = s.Emphasis
+	options.Styles.Blockquotes = s.Blockquotes
+	options.Styles.Alignment = s.Alignment
+	options.Styles.Callouts = s.Callouts
+	switch rule := strings.ToLower(s.Headings); rule {
+	case "keep", "":
+		options.Headings.Rule = athanor.HeadingRuleKeep
+	case "compact", "shift":
+		options.Headings.Rule = athanor.HeadingRule(rule)
+	default:
+		return options, fmt.Errorf("unsupported heading rule %q: use keep, compact or shift", s.Headings)
+	}
+	switch dir := strings.ToLower(s.Dir); dir {
+	case "auto", "":
+		options.Direction = athanor.DirectionAuto
+	case "ltr", "rtl":
+		options.Direction = athanor.TextDirection(dir)
+	default:
+		return options, fmt.Errorf("unsupported text direction %q: use auto, ltr or rtl", s.Dir)
+	}
+	switch conversion := strings.ToLower(s.Chinese); conversion {
+	case "", "none":
+		options.Chinese = athanor.ChineseConversionNone
+	case "s2t", "t2s":
+		options.Chinese = athanor.ChineseConversion(conversion)
+	default:
+		return options, fmt.Errorf("unsupported Chinese conversion %q: use s2t or t2s", s.Chinese)
+	}
+	switch policy := strings.ToLower(s.OnWarning); policy {
+	case "report", "":
+		options.OnWarning = athanor.WarningsReport
+	case "fail", "ignore":
+		options.OnWarning = athanor.WarningPolicy(policy)
+	default:
+		return options, fmt.Errorf("unsupported warning policy %q: use report, fail or ignore", s.OnWarning)
+	}
+	switch notes := strings.ToLower(s.Notes); notes {
+	case "chapter", "":
+		options.Notes = athanor.NotesChapter
+	case "book":
+		options.Notes = athanor.NotesBook
+	default:
+		return options, fmt.Errorf("unsupported note placement %q: use chapter or book", s.Notes)
+	}
+	switch labels := strings.ToLower(s.Labels); labels {
+	case "", "default":
+		options.Labels = athanor.LabelsDefault
+	case "zh", "en", "auto":
+		options.Labels = athanor.LabelLanguage(labels)
+	default:
+		return options, fmt.Errorf("unsupported label language %q: use zh, en or auto", s.Labels)
+	}
+	if s.TOCDepth < 0 || s.TOCDepth > athanor.MaxTOCDepth {
+		return options, fmt.Errorf("invalid --toc-depth=%d: use 1 to %d, or 0 for every level", s.TOCDepth, athanor.MaxTOCDepth)
+	}
+	return options, nil
+}
+
+// applyEnv sets each flag from its ATHANOR_<FLAG> environment variable, so a
+// container can be configured without a command line. Dashes become
+// underscores, since shells do not accept them in variable names. It runs
+// before the arguments are parsed, which lets explicit flags win.
+func applyEnv(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := "ATHANOR_" + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid %s=%q: %v", name, value, setErr)
+		}
+	})
+	return err
+}
+
+// parseInterleaved lets flags appear before or after the input files, so
+// both "convert --out=x a.epub" and "convert a.epub --out=x" work.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// planEntry is one book of a dry-run report. Books that cannot be planned
+// keep their input path and carry the error instead.
+type planEntry struct {
+	athanor.Plan
+	Error string `json:"error,omitempty"`
+}
+
+// plan prints what converting the entries would do, as a JSON array.
+func plan(ctx context.Context, entries []manifestEntry, jobs []athanor.Options) int {
+	status := exitOK
+	report := make([]planEntry, 0, len(entries))
+	for i, entry := range entries {
+		result, err := athanor.NewConverter(jobs[i]).Plan(ctx, entry.Input)
+		if ctx.Err() != nil {
+			return exitError
+		}
+		if err != nil {
+			report = append(report, planEntry{Plan: athanor.Plan{Input: entry.Input}, Error: err.Error()})
+			status = exitError
+			continue
+		}
+		report = append(report, planEntry{Plan: result})
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return status
+}
+
+func convert(ctx context.Context, input string, options athanor.Options, quiet bool, timeout time.Duration, onConflict string) error {
+	info, err := os.Stat(input)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("not an EPUB file")
+	}
+
+	logLine := func(string) {}
+	if !quiet {
+		logLine = func(line string) { fmt.Println(line) }
+	}
+	proceed, err := resolveConflict(input, &options, onConflict)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		logLine("Skipped, output exists: " + input)
+		return nil
+	}
+	options.Logger = logLine
+	options.Progress = func(stage string, pct float64, message string) {
+		logLine(fmt.Sprintf("[%3.0f%%] %s", pct, message))
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	result, err := athanor.NewConverter(options).Convert(ctx, input)
+	if err != nil {
+		return err
+	}
+	logLine(fmt.Sprintf("Markdown: %s", result.MainMarkdownPath))
+	for _, extra := range []struct{ label, path string }{
+		{"Text", result.TextPath},
+		{"AsciiDoc", result.AsciiDocPath},
+		{"DocBook", result.DocBookPath},
+		{"SQL", result.SQLScriptPath},
+		{"DOCX", result.DOCXPath},
+		{"ODT", result.ODTPath},
+		{"RTF", result.RTFPath},
+		{"HTML", result.HTMLPath},
+	} {
+		if extra.path != "" {
+			logLine(fmt.Sprintf("%s: %s", extra.label, extra.path))
+		}
+	}
+	logLine(fmt.Sprintf("Chunks: %s", result.ChunksPath))
+	for _, warning := range result.Warnings {
+		logLine(fmt.Sprintf("Warning: %s (%s)", warning.Message, warning))
+	}
+	return nil
+}
+
+const (
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+	conflictSkip      = "skip"
+)
+
+// resolveConflict applies --on-conflict before a book is converted, the way
+// the desktop app applies its conflictPolicy setting. It reports false when
+// the book should be skipped.
+func resolveConflict(input string, options *athanor.Options, policy string) (bool, error) {
+	if policy == conflictOverwrite {
+		return true, nil
+	}
+	exists, err := outputsExist(input, *options)
+	if err != nil || !exists {
+		return true, err
+	}
+	if policy == conflictSkip {
+		return false, nil
+	}
+	base := options.BaseName
+	if base == "" {
+		base = athanor.DefaultBaseName(input)
+	}
+	for n := 2; ; n++ {
+		options.BaseName = fmt.Sprintf("%s_%d", base, n)
+		if exists, err := outputsExist(input, *options); err != nil || !exists {
+			return true, err
+		}
+	}
+}
+
+func outputsExist(input string, options athanor.Options) (bool, error) {
+	outputs, err := athanor.NewConverter(options).Outputs(input)
+	if err != nil {
+		return false, err
+	}
+	for _, output := range outputs {
+		if _, err := os.Stat(output); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
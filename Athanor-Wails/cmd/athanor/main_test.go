@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"Athanor-Wails/pkg/athanor"
+)
+
+func TestApplyEnvMapsDashesToUnderscores(t *testing.T) {
+	t.Setenv("ATHANOR_TOC_DEPTH", "2")
+	t.Setenv("ATHANOR_ON_WARNING", "fail")
+
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	depth := fs.Int("toc-depth", 0, "")
+	policy := fs.String("on-warning", "report", "")
+	if err := applyEnv(fs); err != nil {
+		t.Fatalf("applyEnv: %v", err)
+	}
+	if *depth != 2 || *policy != "fail" {
+		t.Fatalf("expected env to set hyphenated flags, got toc-depth=%d on-warning=%q", *depth, *policy)
+	}
+
+	t.Setenv("ATHANOR_TOC_DEPTH", "deep")
+	if err := applyEnv(fs); err == nil {
+		t.Fatal("expected an invalid value to fail")
+	}
+}
+
+func TestSettingsAcceptTheAppFormats(t *testing.T) {
+	options, err := settings{Format: "md,txt:60,html:readable:dark", Wrap: "auto", TOC: true}.options()
+	if err != nil {
+		t.Fatalf("options: %v", err)
+	}
+	if !options.PlainText || options.TextWrap != 60 || !options.ReadableHTML || !options.DarkHTML {
+		t.Fatalf("expected txt:60 and both html modes, got %+v", options)
+	}
+	if _, err := (settings{Format: "rag-md"}).options(); err != nil {
+		t.Fatalf("expected the app's rag-md format to be accepted: %v", err)
+	}
+	if _, err := (settings{Format: "pdf"}).options(); err == nil {
+		t.Fatal("expected an unsupported format to fail")
+	}
+	if _, err := (settings{Cover: "thumbnail"}).options(); err == nil {
+		t.Fatal("expected an unsupported cover mode to fail")
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "book.epub")
+	if err := os.WriteFile(filepath.Join(dir, "book_athanor.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "book_athanor_2"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	options := athanor.Options{}
+	if proceed, err := resolveConflict(input, &options, conflictSkip); err != nil || !proceed {
+		t.Fatalf("a text export the job does not write should not conflict: %v %v", proceed, err)
+	}
+	options.PlainText = true
+	if proceed, err := resolveConflict(input, &options, conflictSkip); err != nil || proceed {
+		t.Fatalf("expected the existing text export to skip the book: %v %v", proceed, err)
+	}
+	if proceed, err := resolveConflict(input, &options, conflictRename); err != nil || !proceed || options.BaseName != "book_athanor_3" {
+		t.Fatalf("expected a rename to book_athanor_3, got %q (%v %v)", options.BaseName, proceed, err)
+	}
+}
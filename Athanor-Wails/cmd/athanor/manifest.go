@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// manifestEntry is one book of a batch manifest. Empty fields fall back to
+// the command-line flags; relative paths are taken from the manifest's
+// directory so a manifest keeps working when run from elsewhere.
+type manifestEntry struct {
+	Input   string `json:"input"`
+	Name    string `json:"name,omitempty"`
+	Out     string `json:"out,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Wrap    string `json:"wrap,omitempty"`
+	Columns int    `json:"columns,omitempty"`
+	Math    string `json:"math,omitempty"`
+	Images  string `json:"images,omitempty"`
+	Dir     string `json:"dir,omitempty"`
+	Chinese string `json:"chinese,omitempty"`
+}
+
+func (e manifestEntry) apply(s settings) settings {
+	for _, field := range []struct {
+		dst   *string
+		value string
+	}{
+		{&s.Out, e.Out},
+		{&s.Format, e.Format},
+		{&s.Wrap, e.Wrap},
+		{&s.Math, e.Math},
+		{&s.Images, e.Images},
+		{&s.Dir, e.Dir},
+		{&s.Chinese, e.Chinese},
+	} {
+		if field.value != "" {
+			*field.dst = field.value
+		}
+	}
+	if e.Columns != 0 {
+		s.Columns = e.Columns
+	}
+	return s
+}
+
+func readManifest(path string) ([]manifestEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var entries []manifestEntry
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		entries, err = parseCSVManifest(string(data))
+	} else {
+		err = json.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", filepath.Base(path), err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range entries {
+		entry := &entries[i]
+		if strings.TrimSpace(entry.Input) == "" {
+			return nil, fmt.Errorf("manifest %s: entry %d has no input", filepath.Base(path), i+1)
+		}
+		if entry.Name != "" && entry.Name != filepath.Base(entry.Name) {
+			return nil, fmt.Errorf("manifest %s: name %q must not contain a path", filepath.Base(path), entry.Name)
+		}
+		entry.Input = resolveManifestPath(dir, entry.Input)
+		entry.Out = resolveManifestPath(dir, entry.Out)
+	}
+	return entries, nil
+}
+
+// parseCSVManifest reads a CSV manifest whose header names the columns, in
+// any order, using the JSON field names.
+func parseCSVManifest(data string) ([]manifestEntry, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	entries := make([]manifestEntry, 0, len(records)-1)
+	for line, record := range records[1:] {
+		var entry manifestEntry
+		for i, column := range header {
+			value := strings.TrimSpace(record[i])
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "input":
+				entry.Input = value
+			case "name":
+				entry.Name = value
+			case "out":
+				entry.Out = value
+			case "format":
+				entry.Format = value
+			case "wrap":
+				entry.Wrap = value
+			case "columns":
+				if value == "" {
+					continue
+				}
+				if entry.Columns, err = strconv.Atoi(value); err != nil {
+					return nil, fmt.Errorf("line %d: invalid columns %q", line+2, value)
+				}
+			case "math":
+				entry.Math = value
+			case "images":
+				entry.Images = value
+			case "dir":
+				entry.Dir = value
+			case "chinese":
+				entry.Chinese = value
+			default:
+				return nil, fmt.Errorf("unknown column %q", column)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func resolveManifestPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
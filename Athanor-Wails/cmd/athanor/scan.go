@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"Athanor-Wails/pkg/athanor"
+)
+
+// scanEntry is one line of a scan report. Books that fail to parse keep
+// their path and size and carry the error instead.
+type scanEntry struct {
+	athanor.Inspection
+	Error string `json:"error,omitempty"`
+}
+
+// scan walks library folders, inspects every EPUB without converting it and
+// prints a JSON or CSV report, to help plan a bulk conversion.
+func scan(args []string) int {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	asCSV := flags.Bool("csv", false, "")
+	if err := applyEnv(flags); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	dirs, err := parseInterleaved(flags, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(dirs) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var entries []scanEntry
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				entries = append(entries, scanEntry{Inspection: athanor.Inspection{Path: path}, Error: err.Error()})
+				return nil
+			}
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".epub") {
+				return nil
+			}
+			report, err := athanor.Inspect(ctx, path)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				report = athanor.Inspection{Path: path}
+				if info, statErr := d.Info(); statErr == nil {
+					report.Size = info.Size()
+				}
+				entries = append(entries, scanEntry{Inspection: report, Error: err.Error()})
+				return nil
+			}
+			entries = append(entries, scanEntry{Inspection: report})
+			return nil
+		})
+		if errors.Is(err, context.Canceled) {
+			return exitError
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", dir, err)
+			return exitError
+		}
+	}
+
+	if *asCSV {
+		err = writeScanCSV(entries)
+	} else {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(entries)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	var size int64
+	failed, drm := 0, 0
+	for _, entry := range entries {
+		size += entry.Size
+		if entry.Error != "" {
+			failed++
+		}
+		if entry.DRM {
+			drm++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "scanned %d books (%.1f MB): %d failed, %d with DRM\n", len(entries), float64(size)/1024/1024, failed, drm)
+	if failed > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+func writeScanCSV(entries []scanEntry) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"path", "size", "title", "authors", "language", "chapters", "frontBackMatter", "footnotes", "characters", "images", "drm", "parseMillis", "warnings", "error"})
+	for _, e := range entries {
+		w.Write([]string{
+			e.Path,
+			strconv.FormatInt(e.Size, 10),
+			e.Title,
+			strings.Join(e.Authors, "; "),
+			e.Language,
+			strconv.Itoa(e.Chapters),
+			strconv.Itoa(e.FrontBack),
+			strconv.Itoa(e.Footnotes),
+			strconv.Itoa(e.Characters),
+			strconv.Itoa(e.Images),
+			strconv.FormatBool(e.DRM),
+			strconv.FormatInt(e.ParseMillis, 10),
+			strings.Join(e.Warnings, "; "),
+			e.Error,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
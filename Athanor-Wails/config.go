@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"Athanor-Wails/internal/rag"
+)
+
+const defaultOutputFormat = "rag-md"
+
+// Config holds the defaults that used to be hard-coded in the shell. It is
+// stored as JSON under the platform config directory, e.g.
+// ~/.config/athanor/config.json or %AppData%\athanor\config.json.
+type Config struct {
+	OutputFormat     string                `json:"outputFormat,omitempty"`
+	OutputDir        string                `json:"outputDir,omitempty"`
+	QueueConcurrency int                   `json:"queueConcurrency,omitempty"`
+	ChunkConfig      rag.ChunkConfig       `json:"chunkConfig,omitempty"`
+	Readability      bool                  `json:"readability,omitempty"`
+	Cover            rag.CoverMode         `json:"cover,omitempty"`
+	IncludeOrphans   bool                  `json:"includeOrphans,omitempty"`
+	DropDuplicates   bool                  `json:"dropDuplicates,omitempty"`
+	SeriesNumbering  bool                  `json:"seriesNumbering,omitempty"`
+	Math             rag.MathStyle         `json:"math,omitempty"`
+	Images           rag.ImageMode         `json:"images,omitempty"`
+	Emphasis         bool                  `json:"emphasis,omitempty"`
+	Blockquotes      bool                  `json:"blockquotes,omitempty"`
+	Alignment        bool                  `json:"alignment,omitempty"`
+	Callouts         bool                  `json:"callouts,omitempty"`
+	Headings         rag.HeadingRule       `json:"headings,omitempty"`
+	Wrap             rag.WrapMode          `json:"wrap,omitempty"`
+	Columns          int                   `json:"columns,omitempty"`
+	Provenance       bool                  `json:"provenance,omitempty"`
+	Direction        rag.TextDirection     `json:"direction,omitempty"`
+	Chinese          rag.ChineseConversion `json:"chinese,omitempty"`
+	TOCDepth         int                   `json:"tocDepth,omitempty"`
+	NoTOC            bool                  `json:"noToc,omitempty"`
+	OnWarning        rag.WarningPolicy     `json:"onWarning,omitempty"`
+	Notes            rag.NotePlacement     `json:"notes,omitempty"`
+	Labels           rag.LabelLanguage     `json:"labels,omitempty"`
+	ConflictPolicy   string                `json:"conflictPolicy,omitempty"`
+	DOCXReference    string                `json:"docxReference,omitempty"`
+	// Settings holds the frontend's own preferences; see GetSettings.
+	Settings Settings `json:"settings,omitempty"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		OutputFormat:     defaultOutputFormat,
+		QueueConcurrency: defaultQueueConcurrency,
+		ConflictPolicy:   ConflictOverwrite,
+	}
+}
+
+func normalizeConfig(cfg Config) Config {
+	if cfg.OutputFormat == "" {
+		cfg.OutputFormat = defaultOutputFormat
+	}
+	cfg.QueueConcurrency = min(max(cfg.QueueConcurrency, 1), maxQueueConcurrency)
+	cfg.TOCDepth = min(max(cfg.TOCDepth, 0), rag.MaxTOCDepth)
+	cfg.ConflictPolicy = normalizeConflictPolicy(cfg.ConflictPolicy)
+	return cfg
+}
+
+// validateConfig rejects values the pipeline would otherwise quietly treat
+// as the default, as the CLI does for its flags. Each key also accepts the
+// CLI's spelling of its default.
+func validateConfig(cfg Config) error {
+	for _, field := range []struct {
+		key     string
+		value   string
+		allowed []string
+	}{
+		{"cover", string(cfg.Cover), []string{"auto", "extract", "skip"}},
+		{"math", string(cfg.Math), []string{"dollar", "latex", "fenced"}},
+		{"images", string(cfg.Images), []string{"strip", "relative", "absolute", "embed"}},
+		{"headings", string(cfg.Headings), []string{"keep", "compact", "shift"}},
+		{"wrap", string(cfg.Wrap), []string{"none", "auto"}},
+		{"direction", string(cfg.Direction), []string{"auto", "ltr", "rtl"}},
+		{"chinese", string(cfg.Chinese), []string{"none", "s2t", "t2s"}},
+		{"onWarning", string(cfg.OnWarning), []string{"report", "fail", "ignore"}},
+		{"notes", string(cfg.Notes), []string{"chapter", "book"}},
+		{"labels", string(cfg.Labels), []string{"default", "zh", "en", "auto"}},
+	} {
+		if field.value != "" && !slices.Contains(field.allowed, field.value) {
+			return fmt.Errorf("不支持的配置 %s=%q: 可用 %s", field.key, field.value, strings.Join(field.allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("无法定位配置目录: %w", err)
+	}
+	return filepath.Join(dir, "athanor", "config.json"), nil
+}
+
+// readConfig loads a config file. A missing file is not an error; it yields
+// the defaults.
+func readConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return defaultConfig(), nil
+	}
+	if err != nil {
+		return defaultConfig(), fmt.Errorf("读取配置失败: %w", err)
+	}
+	cfg := defaultConfig()
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), fmt.Errorf("解析配置失败: %w", err)
+	}
+	if err := validateConfig(cfg); err != nil {
+		return defaultConfig(), fmt.Errorf("解析配置失败: %w", err)
+	}
+	return normalizeConfig(cfg), nil
+}
+
+// writeConfig writes the file under a temporary name first, so a crash
+// mid-write cannot leave it truncated.
+func writeConfig(path string, cfg Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入配置失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入配置失败: %w", err)
+	}
+	return nil
+}
+
+func (a *App) configFile() (string, error) {
+	if a.configPath != "" {
+		return a.configPath, nil
+	}
+	return defaultConfigPath()
+}
+
+// LoadConfig re-reads the config file and applies it.
+func (a *App) LoadConfig() (Config, error) {
+	path, err := a.configFile()
+	if err != nil {
+		return a.currentConfig(), err
+	}
+	cfg, err := readConfig(path)
+	if err != nil {
+		return a.currentConfig(), err
+	}
+	a.applyConfig(cfg)
+	return cfg, nil
+}
+
+// SaveConfig writes cfg to disk and applies it to subsequent jobs. A cfg
+// without settings keeps the stored ones.
+func (a *App) SaveConfig(cfg Config) (Config, error) {
+	a.saveMu.Lock()
+	defer a.saveMu.Unlock()
+
+	cfg = normalizeConfig(cfg)
+	if err := validateConfig(cfg); err != nil {
+		return a.currentConfig(), err
+	}
+	if cfg.Settings == nil {
+		cfg.Settings = a.currentConfig().Settings
+	}
+	path, err := a.configFile()
+	if err != nil {
+		return a.currentConfig(), err
+	}
+	if err := writeConfig(path, cfg); err != nil {
+		return a.currentConfig(), err
+	}
+	a.applyConfig(cfg)
+	a.log(fmt.Sprintf("Config saved: %s", path))
+	return cfg, nil
+}
+
+func (a *App) applyConfig(cfg Config) {
+	a.configMu.Lock()
+	a.config = cfg
+	a.configMu.Unlock()
+
+	a.SetQueueConcurrency(cfg.QueueConcurrency)
+}
+
+func (a *App) currentConfig() Config {
+	a.configMu.RLock()
+	defer a.configMu.RUnlock()
+	return a.config
+}
+
+// outputDir is where a job writes its outputs: the configured directory, or
+// the input's own directory when none is set.
+func (a *App) outputDir(inputPath string) string {
+	if dir := a.currentConfig().OutputDir; dir != "" {
+		return dir
+	}
+	return filepath.Dir(inputPath)
+}
+
+// checkOutputDir creates dir if needed and makes sure it is writable, so a
+// read-only target fails up front with a clear message instead of after the
+// whole book has been converted.
+func checkOutputDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("无法创建输出目录: %w", err)
+	}
+	probe, err := os.CreateTemp(dir, ".athanor-probe-*")
+	if err != nil {
+		return fmt.Errorf("输出目录不可写: %w", err)
+	}
+	name := probe.Name()
+	probe.Close()
+	return os.Remove(name)
+}
+
+// conversionOptions fills the config-driven parts of rag.Options for one
+// input file.
+func (a *App) conversionOptions(inputPath string) rag.Options {
+	cfg := a.currentConfig()
+	return rag.Options{
+		OutputRootDir:   a.outputDir(inputPath),
+		BaseName:        rag.DefaultBaseName(inputPath),
+		ChunkConfig:     cfg.ChunkConfig,
+		Readability:     cfg.Readability,
+		Cover:           cfg.Cover,
+		IncludeOrphans:  cfg.IncludeOrphans,
+		DropDuplicates:  cfg.DropDuplicates,
+		SeriesNumbering: cfg.SeriesNumbering,
+		Headings:        rag.HeadingConfig{Rule: cfg.Headings},
+		Wrap:            cfg.Wrap,
+		Columns:         cfg.Columns,
+		Provenance:      cfg.Provenance,
+		Direction:       cfg.Direction,
+		Chinese:         cfg.Chinese,
+		TOCDepth:        cfg.TOCDepth,
+		NoTOC:           cfg.NoTOC,
+		OnWarning:       cfg.OnWarning,
+		Notes:           cfg.Notes,
+		Labels:          cfg.Labels,
+		DOCXReference:   cfg.DOCXReference,
+		Styles: rag.StyleConfig{
+			Emphasis:    cfg.Emphasis,
+			Blockquotes: cfg.Blockquotes,
+			Alignment:   cfg.Alignment,
+			Callouts:    cfg.Callouts,
+			Math:        cfg.Math,
+			Images:      cfg.Images,
+		},
+	}
+}
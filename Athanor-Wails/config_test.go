@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"Athanor-Wails/internal/rag"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	dir := filepath.Join(".", ".tmp", "test-config")
+	_ = os.RemoveAll(dir)
+
+	app := NewApp()
+	app.configPath = filepath.Join(dir, "config.json")
+
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		t.Fatalf("load missing config: %v", err)
+	}
+	if cfg.OutputFormat != defaultOutputFormat || cfg.QueueConcurrency != defaultQueueConcurrency {
+		t.Fatalf("expected defaults, got %+v", cfg)
+	}
+
+	saved, err := app.SaveConfig(Config{
+		OutputDir:        filepath.Join(dir, "out"),
+		QueueConcurrency: 99,
+		Cover:            rag.CoverModeExtract,
+		Emphasis:         true,
+	})
+	if err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	if saved.QueueConcurrency != maxQueueConcurrency || saved.OutputFormat != defaultOutputFormat {
+		t.Fatalf("expected normalized config, got %+v", saved)
+	}
+
+	reloaded := NewApp()
+	reloaded.configPath = app.configPath
+	cfg, err = reloaded.LoadConfig()
+	if err != nil {
+		t.Fatalf("reload config: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, saved) {
+		t.Fatalf("expected %+v after reload, got %+v", saved, cfg)
+	}
+
+	options := reloaded.conversionOptions(filepath.Join("books", "a.epub"))
+	if options.OutputRootDir != cfg.OutputDir || options.Cover != rag.CoverModeExtract || !options.Styles.Emphasis {
+		t.Fatalf("config not applied to options: %+v", options)
+	}
+}
+
+func TestConfigRejectsUnknownNotes(t *testing.T) {
+	dir := filepath.Join(".", ".tmp", "test-config-notes")
+	_ = os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	app.configPath = filepath.Join(dir, "config.json")
+	if _, err := app.SaveConfig(Config{Notes: "footer"}); err == nil {
+		t.Fatal("expected saving an unknown notes value to fail")
+	}
+	if err := os.WriteFile(app.configPath, []byte(`{"notes": "footer"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.LoadConfig(); err == nil {
+		t.Fatal("expected loading an unknown notes value to fail")
+	}
+	if err := os.WriteFile(app.configPath, []byte(`{"notes": "book"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := app.LoadConfig(); err != nil || cfg.Notes != rag.NotesBook {
+		t.Fatalf("expected book notes, got %+v (%v)", cfg, err)
+	}
+}
+
+func TestConfigRejectsUnknownEnumValues(t *testing.T) {
+	for _, cfg := range []Config{
+		{Cover: "thumbnail"},
+		{Math: "mathml"},
+		{Images: "inline"},
+		{Headings: "flatten"},
+		{Wrap: "hard"},
+		{Direction: "ttb"},
+		{Chinese: "s2hk"},
+		{OnWarning: "panic"},
+		{Labels: "fr"},
+	} {
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+	if err := validateConfig(Config{Math: "dollar", Images: rag.ImageModeEmbed, Labels: rag.LabelsAuto}); err != nil {
+		t.Fatalf("expected valid values to pass: %v", err)
+	}
+}
+
+func TestOutputDirFallsBackToInputDir(t *testing.T) {
+	app := NewApp()
+	input := filepath.Join("books", "a.epub")
+	if got := app.outputDir(input); got != "books" {
+		t.Fatalf("expected input directory, got %q", got)
+	}
+	app.applyConfig(Config{OutputDir: filepath.Join("elsewhere", "out"), QueueConcurrency: 1})
+	if got := app.outputDir(input); got != filepath.Join("elsewhere", "out") {
+		t.Fatalf("expected configured directory, got %q", got)
+	}
+}
+
+func TestCheckOutputDir(t *testing.T) {
+	dir := filepath.Join(".", ".tmp", "test-output-dir")
+	_ = os.RemoveAll(dir)
+
+	if err := checkOutputDir(filepath.Join(dir, "nested")); err != nil {
+		t.Fatalf("expected writable directory to pass: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "nested"))
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("probe file should be removed, got %v (%v)", entries, err)
+	}
+
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkOutputDir(filepath.Join(blocker, "out")); err == nil {
+		t.Fatal("expected an error when the directory cannot be created")
+	}
+}
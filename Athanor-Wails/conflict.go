@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"Athanor-Wails/internal/rag"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Conflict policies decide what happens when a previous run already left
+// outputs under the same name.
+const (
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+	ConflictSkip      = "skip"
+	ConflictAsk       = "ask"
+)
+
+func normalizeConflictPolicy(policy string) string {
+	switch policy = strings.ToLower(strings.TrimSpace(policy)); policy {
+	case ConflictRename, ConflictSkip, ConflictAsk:
+		return policy
+	default:
+		return ConflictOverwrite
+	}
+}
+
+// outputExists reports whether anything options would write at the top of
+// its output directory under base, such as the main Markdown, the artifact
+// directory or one of the exports, already exists.
+func outputExists(options rag.Options, base string) bool {
+	options.BaseName = base
+	for _, p := range rag.TopLevelOutputs(options) {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// finalBaseName is the name the outputs for options end up under once the
+// pipeline has put series in front of a series-numbered book.
+func finalBaseName(options rag.Options, series rag.Metadata) string {
+	if options.SeriesNumbering {
+		return rag.SeriesBaseName(series, options.BaseName)
+	}
+	return options.BaseName
+}
+
+// nextFreeBaseName appends _2, _3, ... to the base name of options until
+// nothing in its output directory uses the final name.
+func nextFreeBaseName(options rag.Options, series rag.Metadata) string {
+	base := options.BaseName
+	for n := 2; ; n++ {
+		options.BaseName = fmt.Sprintf("%s_%d", base, n)
+		if !outputExists(options, finalBaseName(options, series)) {
+			return options.BaseName
+		}
+	}
+}
+
+// resolveConflict applies the configured conflict policy to options before
+// the pipeline runs, checking the name series numbering will give the
+// outputs. It reports false when the job should be skipped.
+func (a *App) resolveConflict(options *rag.Options, series rag.Metadata) bool {
+	if !outputExists(*options, finalBaseName(*options, series)) {
+		return true
+	}
+	policy := a.currentConfig().ConflictPolicy
+	if policy == ConflictAsk {
+		policy = a.askConflict(finalBaseName(*options, series))
+	}
+	switch policy {
+	case ConflictSkip:
+		return false
+	case ConflictRename:
+		options.BaseName = nextFreeBaseName(*options, series)
+		a.log(fmt.Sprintf("Output exists, writing to: %s", finalBaseName(*options, series)))
+	default:
+		a.log(fmt.Sprintf("Output exists, overwriting: %s", finalBaseName(*options, series)))
+	}
+	return true
+}
+
+// askConflict asks whether to overwrite. Without a window to ask in, the
+// existing output is kept.
+func (a *App) askConflict(base string) string {
+	if a.ctx == nil {
+		return ConflictSkip
+	}
+	answer, err := wailsRuntime.MessageDialog(a.ctx, wailsRuntime.MessageDialogOptions{
+		Type:          wailsRuntime.QuestionDialog,
+		Title:         "输出已存在",
+		Message:       fmt.Sprintf("%s 已存在，是否覆盖？选择“否”将保留现有文件并跳过。", base),
+		Buttons:       []string{"Yes", "No"},
+		DefaultButton: "No",
+	})
+	if err != nil || !strings.EqualFold(answer, "yes") {
+		return ConflictSkip
+	}
+	return ConflictOverwrite
+}
+
+func (a *App) skipped(jobID, outputPath string) ConversionProgress {
+	a.log("Skipped, output exists: " + outputPath)
+
+	result := ConversionProgress{
+		JobID:        jobID,
+		Stage:        "skipped",
+		Progress:     100,
+		IsComplete:   true,
+		Message:      "输出已存在，已跳过",
+		OutputPath:   outputPath,
+		MarkdownPath: outputPath,
+	}
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "conversion:progress", result)
+	}
+	return result
+}
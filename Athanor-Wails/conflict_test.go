@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"Athanor-Wails/internal/rag"
+)
+
+func TestResolveConflict(t *testing.T) {
+	dir := filepath.Join(".", ".tmp", "test-conflict")
+	_ = os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "book_athanor_2"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "book_athanor.md"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy   string
+		proceed  bool
+		baseName string
+	}{
+		{"", true, "book_athanor"},
+		{ConflictRename, true, "book_athanor_3"},
+		{ConflictSkip, false, "book_athanor"},
+		{ConflictAsk, false, "book_athanor"},
+	}
+	for _, tt := range tests {
+		app := NewApp()
+		app.applyConfig(normalizeConfig(Config{ConflictPolicy: tt.policy}))
+		options := rag.Options{OutputRootDir: dir, BaseName: "book_athanor"}
+		if got := app.resolveConflict(&options, rag.Metadata{}); got != tt.proceed || options.BaseName != tt.baseName {
+			t.Fatalf("policy %q: got proceed=%v base=%q, want %v %q", tt.policy, got, options.BaseName, tt.proceed, tt.baseName)
+		}
+	}
+
+	app := NewApp()
+	app.applyConfig(normalizeConfig(Config{ConflictPolicy: ConflictSkip}))
+	options := rag.Options{OutputRootDir: dir, BaseName: "fresh_athanor"}
+	if !app.resolveConflict(&options, rag.Metadata{}) || options.BaseName != "fresh_athanor" {
+		t.Fatalf("no conflict should leave options alone, got %+v", options)
+	}
+}
+
+func TestResolveConflictChecksExports(t *testing.T) {
+	dir := filepath.Join(".", ".tmp", "test-conflict-exports")
+	_ = os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "book_athanor.docbook.xml"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	app.applyConfig(normalizeConfig(Config{ConflictPolicy: ConflictSkip}))
+	options := rag.Options{OutputRootDir: dir, BaseName: "book_athanor"}
+	if !app.resolveConflict(&options, rag.Metadata{}) {
+		t.Fatal("an export the job does not write should not count as a conflict")
+	}
+	options.DocBook = true
+	if app.resolveConflict(&options, rag.Metadata{}) {
+		t.Fatal("expected an existing DocBook export to count as a conflict")
+	}
+}
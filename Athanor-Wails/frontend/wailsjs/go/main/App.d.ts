@@ -1,9 +1,36 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
+import {rag} from '../models';
+
+export function CancelJob(arg1:string):Promise<void>;
 
 export function ConvertBook(arg1:string,arg2:string):Promise<main.ConversionProgress>;
 
+export function CreateFailureReport(arg1:string):Promise<string>;
+
+export function EnqueueBook(arg1:string,arg2:string):Promise<main.QueueJob>;
+
+export function ExportJobBundle(arg1:string):Promise<string>;
+
 export function GetLogsSince(arg1:number):Promise<Record<string, any>>;
 
+export function GetSettings():Promise<Record<string, any>>;
+
+export function ListQueue():Promise<Array<main.QueueJob>>;
+
+export function LoadConfig():Promise<main.Config>;
+
+export function PreviewConversion(arg1:string):Promise<rag.Preview>;
+
+export function RemoveFromQueue(arg1:string):Promise<void>;
+
+export function SaveConfig(arg1:main.Config):Promise<main.Config>;
+
 export function SelectEpub():Promise<string>;
+
+export function SelectOutputDir():Promise<string>;
+
+export function SetQueueConcurrency(arg1:number):Promise<number>;
+
+export function SetSettings(arg1:Record<string, any>):Promise<void>;
@@ -2,14 +2,66 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelJob(arg1) {
+  return window['go']['main']['App']['CancelJob'](arg1);
+}
+
 export function ConvertBook(arg1, arg2) {
   return window['go']['main']['App']['ConvertBook'](arg1, arg2);
 }
 
+export function CreateFailureReport(arg1) {
+  return window['go']['main']['App']['CreateFailureReport'](arg1);
+}
+
+export function EnqueueBook(arg1, arg2) {
+  return window['go']['main']['App']['EnqueueBook'](arg1, arg2);
+}
+
+export function ExportJobBundle(arg1) {
+  return window['go']['main']['App']['ExportJobBundle'](arg1);
+}
+
 export function GetLogsSince(arg1) {
   return window['go']['main']['App']['GetLogsSince'](arg1);
 }
 
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
+export function ListQueue() {
+  return window['go']['main']['App']['ListQueue']();
+}
+
+export function LoadConfig() {
+  return window['go']['main']['App']['LoadConfig']();
+}
+
+export function PreviewConversion(arg1) {
+  return window['go']['main']['App']['PreviewConversion'](arg1);
+}
+
+export function RemoveFromQueue(arg1) {
+  return window['go']['main']['App']['RemoveFromQueue'](arg1);
+}
+
+export function SaveConfig(arg1) {
+  return window['go']['main']['App']['SaveConfig'](arg1);
+}
+
 export function SelectEpub() {
   return window['go']['main']['App']['SelectEpub']();
 }
+
+export function SelectOutputDir() {
+  return window['go']['main']['App']['SelectOutputDir']();
+}
+
+export function SetQueueConcurrency(arg1) {
+  return window['go']['main']['App']['SetQueueConcurrency'](arg1);
+}
+
+export function SetSettings(arg1) {
+  return window['go']['main']['App']['SetSettings'](arg1);
+}
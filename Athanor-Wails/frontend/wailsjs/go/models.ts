@@ -1,5 +1,91 @@
 export namespace main {
 	
+	export class Config {
+	    outputFormat?: string;
+	    outputDir?: string;
+	    queueConcurrency?: number;
+	    chunkConfig?: rag.ChunkConfig;
+	    readability?: boolean;
+	    cover?: string;
+	    includeOrphans?: boolean;
+	    dropDuplicates?: boolean;
+	    seriesNumbering?: boolean;
+	    math?: string;
+	    images?: string;
+	    emphasis?: boolean;
+	    blockquotes?: boolean;
+	    alignment?: boolean;
+	    callouts?: boolean;
+	    headings?: string;
+	    wrap?: string;
+	    columns?: number;
+	    provenance?: boolean;
+	    direction?: string;
+	    chinese?: string;
+	    tocDepth?: number;
+	    noToc?: boolean;
+	    onWarning?: string;
+	    notes?: string;
+	    labels?: string;
+	    conflictPolicy?: string;
+	    docxReference?: string;
+	    settings?: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.outputFormat = source["outputFormat"];
+	        this.outputDir = source["outputDir"];
+	        this.queueConcurrency = source["queueConcurrency"];
+	        this.chunkConfig = this.convertValues(source["chunkConfig"], rag.ChunkConfig);
+	        this.readability = source["readability"];
+	        this.cover = source["cover"];
+	        this.includeOrphans = source["includeOrphans"];
+	        this.dropDuplicates = source["dropDuplicates"];
+	        this.seriesNumbering = source["seriesNumbering"];
+	        this.math = source["math"];
+	        this.images = source["images"];
+	        this.emphasis = source["emphasis"];
+	        this.blockquotes = source["blockquotes"];
+	        this.alignment = source["alignment"];
+	        this.callouts = source["callouts"];
+	        this.headings = source["headings"];
+	        this.wrap = source["wrap"];
+	        this.columns = source["columns"];
+	        this.provenance = source["provenance"];
+	        this.direction = source["direction"];
+	        this.chinese = source["chinese"];
+	        this.tocDepth = source["tocDepth"];
+	        this.noToc = source["noToc"];
+	        this.onWarning = source["onWarning"];
+	        this.notes = source["notes"];
+	        this.labels = source["labels"];
+	        this.conflictPolicy = source["conflictPolicy"];
+	        this.docxReference = source["docxReference"];
+	        this.settings = source["settings"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ConversionProgress {
 	    jobId: string;
 	    stage: string;
@@ -9,6 +95,7 @@ export namespace main {
 	    isError: boolean;
 	    outputPath?: string;
 	    markdownPath?: string;
+	    warnings?: rag.Warning[];
 	
 	    static createFrom(source: any = {}) {
 	        return new ConversionProgress(source);
@@ -24,6 +111,114 @@ export namespace main {
 	        this.isError = source["isError"];
 	        this.outputPath = source["outputPath"];
 	        this.markdownPath = source["markdownPath"];
+	        this.warnings = this.convertValues(source["warnings"], rag.Warning);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueueJob {
+	    jobId: string;
+	    inputPath: string;
+	    outputFormat: string;
+	    status: string;
+	    progress: number;
+	    message?: string;
+	    outputPath?: string;
+	    enqueuedAt: string;
+	    lane: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueJob(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.jobId = source["jobId"];
+	        this.inputPath = source["inputPath"];
+	        this.outputFormat = source["outputFormat"];
+	        this.status = source["status"];
+	        this.progress = source["progress"];
+	        this.message = source["message"];
+	        this.outputPath = source["outputPath"];
+	        this.enqueuedAt = source["enqueuedAt"];
+	        this.lane = source["lane"];
+	    }
+	}
+
+}
+
+export namespace rag {
+	
+	export class ChunkConfig {
+	    includeBackmatter?: boolean;
+	    targetSize?: number;
+	    minSize?: number;
+	    maxSize?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChunkConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.includeBackmatter = source["includeBackmatter"];
+	        this.targetSize = source["targetSize"];
+	        this.minSize = source["minSize"];
+	        this.maxSize = source["maxSize"];
+	    }
+	}
+	export class Preview {
+	    title: string;
+	    chapter: string;
+	    chapters: number;
+	    markdown: string;
+	    html: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Preview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.chapter = source["chapter"];
+	        this.chapters = source["chapters"];
+	        this.markdown = source["markdown"];
+	        this.html = source["html"];
+	    }
+	}
+	export class Warning {
+	    code: string;
+	    detail?: string;
+	    chapter?: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Warning(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.detail = source["detail"];
+	        this.chapter = source["chapter"];
+	        this.message = source["message"];
 	    }
 	}
 
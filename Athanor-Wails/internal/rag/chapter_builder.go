@@ -2,9 +2,11 @@ package rag
 
 import (
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/net/html"
 )
@@ -18,6 +20,13 @@ type chapterBuilder struct {
 	footnoteMap map[string]int
 	noteTargets map[string]struct{}
 	noteLookup  noteRegistry
+	styles      styleMapper
+	// align is the CSS alignment of the element being read, which the
+	// paragraphs inside it take over.
+	align TextAlign
+	// pendingAnchors holds "path#id" keys of elements seen since the last
+	// block was added.
+	pendingAnchors []string
 }
 
 func newChapterBuilder(sourceRef string, order int, tocTitle string, noteTargets map[string]struct{}, noteLookup noteRegistry) *chapterBuilder {
@@ -44,6 +53,7 @@ func (b *chapterBuilder) build() Chapter {
 			b.chapter.Title = fmt.Sprintf("章节 %03d", b.chapter.Order)
 		}
 	}
+	b.flushAnchors()
 	b.chapter.Footnotes = b.footnotes
 	return b.chapter
 }
@@ -66,16 +76,42 @@ func (b *chapterBuilder) consumeNode(node *html.Node) {
 		return
 	}
 
-	if isNoteNode(node) {
+	if b.noteLookup.isNote(b.chapter.SourceRef, node) {
 		b.captureFootnoteNode(node)
 		return
 	}
+	b.markAnchor(node)
+
+	if label, ok := b.calloutLabel(node); ok {
+		text := strings.TrimSpace(b.inlineText(node))
+		if text != "" {
+			b.appendBlock(Block{Kind: BlockKindCallout, Text: text, Label: label})
+		}
+		return
+	}
+
+	if tex, ok := displayMathOnly(node); ok {
+		b.appendBlock(b.styles.config.Math.display(tex))
+		return
+	}
+
+	if align := b.styles.alignment(node); align != AlignDefault && align != b.align {
+		outer := b.align
+		b.align = align
+		defer func() { b.align = outer }()
+	}
 
 	switch node.Data {
-	case "script", "style", "img", "svg", "figure", "video", "audio":
+	case "script", "style", "video", "audio":
 		return
+	case "img", "svg":
+		b.appendParagraph(b.imageMarkup(node))
+	case "figure":
+		if b.styles.config.Images != ImageModeStrip {
+			b.consumeChildren(node)
+		}
 	case "h1", "h2", "h3", "h4", "h5", "h6":
-		text := strings.TrimSpace(b.inlineText(node))
+		text := stripImages(unlinkText(strings.TrimSpace(b.inlineText(node))))
 		if text == "" {
 			return
 		}
@@ -86,32 +122,37 @@ func (b *chapterBuilder) consumeNode(node *html.Node) {
 		if b.title == "" {
 			b.title = text
 		}
-		b.chapter.Blocks = append(b.chapter.Blocks, Block{Kind: BlockKindHeading, Text: text, Level: level})
+		b.appendBlock(Block{Kind: BlockKindHeading, Text: text, Level: level})
 	case "p":
+		if b.styles.isQuoteBlock(node) {
+			b.appendBlockquote(node)
+			return
+		}
 		b.appendParagraph(strings.TrimSpace(b.inlineText(node)))
 	case "blockquote":
-		text := strings.TrimSpace(b.inlineText(node))
-		if text != "" {
-			b.chapter.Blocks = append(b.chapter.Blocks, Block{Kind: BlockKindBlockquote, Text: text})
-		}
+		b.appendBlockquote(node)
 	case "pre":
 		code := strings.TrimSpace(nodeText(node))
 		if code != "" {
-			b.chapter.Blocks = append(b.chapter.Blocks, Block{Kind: BlockKindCode, Text: code})
+			b.appendBlock(Block{Kind: BlockKindCode, Text: code})
 		}
 	case "ul", "ol":
 		items := b.collectListItems(node)
 		if len(items) > 0 {
-			b.chapter.Blocks = append(b.chapter.Blocks, Block{Kind: BlockKindList, Items: items, Ordered: node.Data == "ol"})
+			b.appendBlock(Block{Kind: BlockKindList, Items: items, Ordered: node.Data == "ol"})
 		}
 	case "table":
 		rows := b.collectTable(node)
 		if len(rows) > 0 {
-			b.chapter.Blocks = append(b.chapter.Blocks, Block{Kind: BlockKindTable, Rows: rows})
+			b.appendBlock(Block{Kind: BlockKindTable, Rows: rows})
 		}
 	case "hr":
-		b.chapter.Blocks = append(b.chapter.Blocks, Block{Kind: BlockKindSeparator})
+		b.appendBlock(Block{Kind: BlockKindSeparator})
 	case "section", "article", "div", "main", "body":
+		if b.styles.isQuoteBlock(node) {
+			b.appendBlockquote(node)
+			return
+		}
 		b.consumeChildren(node)
 	default:
 		text := strings.TrimSpace(b.inlineText(node))
@@ -130,31 +171,128 @@ func (b *chapterBuilder) appendParagraph(text string) {
 	}
 	if len(b.chapter.Blocks) > 0 {
 		last := &b.chapter.Blocks[len(b.chapter.Blocks)-1]
-		if last.Kind == BlockKindParagraph && shouldMergeParagraph(last.Text, text) {
+		if last.Kind == BlockKindParagraph && last.Align == b.align && shouldMergeParagraph(last.Text, text) {
 			last.Text = mergeParagraphs(last.Text, text)
+			b.flushAnchors()
 			return
 		}
 	}
-	b.chapter.Blocks = append(b.chapter.Blocks, Block{Kind: BlockKindParagraph, Text: text})
+	b.appendBlock(Block{Kind: BlockKindParagraph, Text: text, Align: b.align})
+}
+
+// appendBlock adds a block and files the element ids seen since the last
+// block under it, so cross-references can find their target later.
+func (b *chapterBuilder) appendBlock(block Block) {
+	if block.Kind == BlockKindHeading {
+		block.anchorKeys = append(block.anchorKeys, b.pendingAnchors...)
+		b.pendingAnchors = nil
+	} else {
+		b.flushAnchors()
+	}
+	b.chapter.Blocks = append(b.chapter.Blocks, block)
+}
+
+func (b *chapterBuilder) markAnchor(node *html.Node) {
+	if id := strings.TrimSpace(attr(node, "id")); id != "" {
+		b.pendingAnchors = append(b.pendingAnchors, b.chapter.SourceRef+"#"+id)
+	}
+}
+
+// flushAnchors files pending ids under the closest heading above them, or
+// under the chapter itself before its first heading, so a link into the
+// middle of a section lands on that section.
+func (b *chapterBuilder) flushAnchors() {
+	if len(b.pendingAnchors) == 0 {
+		return
+	}
+	for i := len(b.chapter.Blocks) - 1; i >= 0; i-- {
+		if b.chapter.Blocks[i].Kind == BlockKindHeading {
+			b.chapter.Blocks[i].anchorKeys = append(b.chapter.Blocks[i].anchorKeys, b.pendingAnchors...)
+			b.pendingAnchors = nil
+			return
+		}
+	}
+	b.chapter.anchorKeys = append(b.chapter.anchorKeys, b.pendingAnchors...)
+	b.pendingAnchors = nil
+}
+
+func (b *chapterBuilder) appendBlockquote(node *html.Node) {
+	text := strings.TrimSpace(b.inlineText(node))
+	if text != "" {
+		b.appendBlock(Block{Kind: BlockKindBlockquote, Text: text})
+	}
+}
+
+// calloutLabel reports whether a node is a sidebar-style aside that should be
+// kept apart from the running text, and which callout label it maps to.
+func (b *chapterBuilder) calloutLabel(node *html.Node) (string, bool) {
+	if !b.styles.config.Callouts {
+		return "", false
+	}
+	epubType := strings.ToLower(attr(node, "epub:type") + " " + attr(node, "role"))
+	class := strings.ToLower(attr(node, "class"))
+	switch {
+	case strings.Contains(epubType, "warning") || strings.Contains(epubType, "caution"):
+		return "WARNING", true
+	case strings.Contains(epubType, "tip"):
+		return "TIP", true
+	case node.Data == "aside",
+		strings.Contains(epubType, "sidebar"),
+		strings.Contains(epubType, "notice"),
+		strings.Contains(class, "sidebar"),
+		strings.Contains(class, "callout"),
+		strings.Contains(class, "boxout"):
+		return "NOTE", true
+	default:
+		return "", false
+	}
 }
 
 func (b *chapterBuilder) inlineText(node *html.Node) string {
 	var parts []string
+	glueNext := false
 	var walk func(*html.Node)
 	walk = func(current *html.Node) {
 		if current.Type == html.TextNode {
 			text := normalizeInlineText(current.Data)
-			if text != "" {
+			if text == "" {
+				return
+			}
+			if glueNext && len(parts) > 0 && !startsWithSpace(current.Data) {
+				parts[len(parts)-1] += text
+			} else {
 				parts = append(parts, text)
 			}
+			glueNext = false
 			return
 		}
 		if current.Type != html.ElementNode {
 			return
 		}
 		if current.Data == "img" || current.Data == "svg" {
+			parts = append(parts, b.imageMarkup(current))
 			return
 		}
+		if current != node {
+			b.markAnchor(current)
+		}
+		if tex, ok := mathTeX(current); ok {
+			parts = append(parts, b.styles.config.Math.inline(tex))
+			return
+		}
+		if initial, ok := dropCapText(current); ok {
+			parts = append(parts, initial)
+			glueNext = true
+			return
+		}
+		if current != node {
+			if marker := b.styles.emphasisMarker(current); marker != "" {
+				if text := b.inlineText(current); text != "" {
+					parts = append(parts, marker+text+marker)
+				}
+				return
+			}
+		}
 		if current.Data == "a" {
 			href := attr(current, "href")
 			if def, ok := b.resolveFootnote(href); ok {
@@ -180,8 +318,14 @@ func (b *chapterBuilder) inlineText(node *html.Node) string {
 				parts = append(parts, fmt.Sprintf("[^%d]", index))
 				return
 			}
+			if key, ok := b.crossLinkKey(href); ok && current != node {
+				if text := b.inlineText(current); text != "" {
+					parts = append(parts, "["+text+"]("+crossLinkScheme+key+")")
+				}
+				return
+			}
 		}
-		if isNoteNode(current) {
+		if b.noteLookup.isNote(b.chapter.SourceRef, current) {
 			return
 		}
 		for child := current.FirstChild; child != nil; child = child.NextSibling {
@@ -201,7 +345,7 @@ func (b *chapterBuilder) captureFootnoteNode(node *html.Node) {
 		id = fmt.Sprintf("note-%d", len(b.footnotes)+1)
 	}
 	key := b.chapter.SourceRef + "#" + id
-	content := strings.TrimSpace(b.inlineText(node))
+	content := unlinkText(strings.TrimSpace(b.inlineText(node)))
 	if content == "" {
 		content = strings.TrimSpace(nodeText(node))
 	}
@@ -236,6 +380,20 @@ func (b *chapterBuilder) resolveFootnote(href string) (noteDefinition, bool) {
 	return noteDefinition{}, false
 }
 
+// crossLinkKey resolves an internal href to the "path#id" key its target
+// element registers, or to the bare path for a link to a whole document.
+func (b *chapterBuilder) crossLinkKey(href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.Contains(href, ":") {
+		return "", false
+	}
+	if key := resolveNoteKey(b.chapter.SourceRef, href); key != "" {
+		return key, true
+	}
+	target := resolveHref(path.Dir(b.chapter.SourceRef), href)
+	return target, target != ""
+}
+
 func (b *chapterBuilder) collectListItems(node *html.Node) []string {
 	var items []string
 	for child := node.FirstChild; child != nil; child = child.NextSibling {
@@ -272,14 +430,14 @@ func (b *chapterBuilder) collectTable(node *html.Node) [][]string {
 	return rows
 }
 
-func collectNoteTargets(node *html.Node, targets map[string]struct{}) {
-	if node.Type == html.ElementNode && isNoteNode(node) {
+func collectNoteTargets(node *html.Node, sourceRef string, notes noteRegistry, targets map[string]struct{}) {
+	if node.Type == html.ElementNode && notes.isNote(sourceRef, node) {
 		if id := attr(node, "id"); id != "" {
 			targets[id] = struct{}{}
 		}
 	}
 	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		collectNoteTargets(child, targets)
+		collectNoteTargets(child, sourceRef, notes, targets)
 	}
 }
 
@@ -420,6 +578,36 @@ func findElement(node *html.Node, tag string) *html.Node {
 	return nil
 }
 
+// dropCapText recognizes the decorative first letter many EPUBs wrap in its
+// own span ("<span class="dropcap">T</span>he"), which must be glued back to
+// the rest of the word instead of being joined with a space.
+func dropCapText(node *html.Node) (string, bool) {
+	class := strings.ToLower(attr(node, "class"))
+	if class == "" {
+		return "", false
+	}
+	matched := false
+	for _, marker := range []string{"dropcap", "drop-cap", "drop_cap", "firstletter", "first-letter", "initial-letter", "initialcap", "initial-cap"} {
+		if strings.Contains(class, marker) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return "", false
+	}
+	text := nodeText(node)
+	if text == "" || len([]rune(text)) > 3 {
+		return "", false
+	}
+	return text, true
+}
+
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
+
 func isStandaloneBlock(node *html.Node) bool {
 	switch node.Data {
 	case "aside", "nav", "header", "footer":
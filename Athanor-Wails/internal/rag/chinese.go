@@ -0,0 +1,215 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+)
+
+// chineseProtectedRe matches spans that must keep their exact characters:
+// inline code and link or image destinations, which are looked up later.
+var chineseProtectedRe = regexp.MustCompile("`[^`\\n]*`|\\]\\([^)\\n]*\\)")
+
+var simplifiedToTraditional, traditionalToSimplified = buildChineseMaps()
+
+// ConvertChineseScript rewrites the book's text between Simplified and
+// Traditional Chinese, character by character, and retags its Chinese
+// language codes to match. Simplified characters with several traditional
+// forms (发 for 發 and 髮, 后 for 後 and 后, ...) need context to convert,
+// so s2t leaves them as they are; t2s folds every form into one.
+func ConvertChineseScript(book *Book, conversion ChineseConversion) {
+	var table map[rune]rune
+	var tag string
+	switch conversion {
+	case ChineseToTraditional:
+		table, tag = simplifiedToTraditional, "zh-Hant"
+	case ChineseToSimplified:
+		table, tag = traditionalToSimplified, "zh-Hans"
+	default:
+		return
+	}
+	convert := func(text string) string { return convertChineseText(text, table) }
+	retag := func(language string) string {
+		if languageScript(language) == "han" {
+			return tag
+		}
+		return language
+	}
+
+	book.Metadata.Title = convert(book.Metadata.Title)
+	for i := range book.Metadata.Authors {
+		book.Metadata.Authors[i] = convert(book.Metadata.Authors[i])
+	}
+	book.Metadata.Publisher = convert(book.Metadata.Publisher)
+	book.Metadata.Series = convert(book.Metadata.Series)
+	book.Metadata.Language = retag(book.Metadata.Language)
+	for _, chapters := range [][]Chapter{book.Main, book.Back} {
+		for i := range chapters {
+			chapter := &chapters[i]
+			chapter.Title = convert(chapter.Title)
+			chapter.Language = retag(chapter.Language)
+			for j := range chapter.Blocks {
+				block := &chapter.Blocks[j]
+				if block.Kind == BlockKindCode {
+					continue
+				}
+				block.Text = convert(block.Text)
+				for k := range block.Items {
+					block.Items[k] = convert(block.Items[k])
+				}
+				for _, row := range block.Rows {
+					for k := range row {
+						row[k] = convert(row[k])
+					}
+				}
+			}
+			for j := range chapter.Footnotes {
+				chapter.Footnotes[j].Content = convert(chapter.Footnotes[j].Content)
+			}
+		}
+	}
+}
+
+func convertChineseText(text string, table map[rune]rune) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range chineseProtectedRe.FindAllStringIndex(text, -1) {
+		b.WriteString(mapChineseRunes(text[last:span[0]], table))
+		b.WriteString(text[span[0]:span[1]])
+		last = span[1]
+	}
+	b.WriteString(mapChineseRunes(text[last:], table))
+	return b.String()
+}
+
+func mapChineseRunes(text string, table map[rune]rune) string {
+	return strings.Map(func(r rune) rune {
+		if mapped, ok := table[r]; ok {
+			return mapped
+		}
+		return r
+	}, text)
+}
+
+func buildChineseMaps() (map[rune]rune, map[rune]rune) {
+	simplified, traditional := []rune(simplifiedChars), []rune(traditionalChars)
+	s2t := make(map[rune]rune, len(simplified))
+	t2s := make(map[rune]rune, len(simplified)+len(traditionalOnlyFolds))
+	for i, r := range simplified {
+		s2t[r] = traditional[i]
+		t2s[traditional[i]] = r
+	}
+	for forms, r := range traditionalOnlyFolds {
+		for _, form := range forms {
+			t2s[form] = r
+		}
+	}
+	return s2t, t2s
+}
+
+// simplifiedChars and traditionalChars pair simplified characters with
+// their only traditional form, position by position.
+var (
+	simplifiedChars = "" +
+		"爱碍袄罢摆败颁办帮宝报贝备笔币毕闭边编变标别宾饼补财参残惨灿仓舱侧测层产长尝偿厂" +
+		"车彻尘陈衬称惩迟齿虫筹处础触传创锤纯词辞聪从丛错达带单担胆导灯邓敌递点电垫钓调顶" +
+		"订东动冻独读断锻队对吨顿夺鹅额恶饿儿尔饵贰罚阀贩饭访纺飞废费纷坟奋愤粪丰风枫疯冯" +
+		"缝讽凤肤辅抚妇负该盖钢岗纲鸽阁个给宫巩贡沟构购顾关观馆惯贯广归规轨贵过韩汉号贺鹤" +
+		"轰红护沪华话画怀坏欢环还换唤挥辉会秽绘贿毁浑货祸击机积鸡极级挤济计记际继纪夹价驾" +
+		"坚歼监检俭减荐见舰渐践鉴键将奖讲酱胶骄娇脚饺较阶节洁结紧锦进惊经颈静镜纠旧举剧惧" +
+		"据觉绝军开凯壳课垦恳库块宽旷矿亏扩阔蜡腊来赖兰拦栏烂蓝篮览懒劳乐垒类泪离礼丽厉励" +
+		"连联怜莲脸炼练粮凉两辆谅疗辽猎临邻灵龄铃领刘龙楼陆录驴虑乱论罗逻锣骡络妈马吗买卖" +
+		"麦馒满猫贸没门们梦弥谜绵庙灭鸣铭谋亩纳难脑恼闹鸟宁农浓诺盘赔喷鹏骗贫频凭评苹扑铺" +
+		"谱齐骑岂启气弃牵铅迁谦钱钳浅枪墙抢桥乔侨窍亲轻倾庆穷区躯驱劝确让饶扰绕热认荣软锐" +
+		"润洒赛伞丧扫涩杀纱晒删闪陕赏烧绍设摄绅审婶肾渗声绳胜圣师诗狮湿时识实势适释饰视试" +
+		"寿兽书输属树术帅双谁税顺说丝饲诉肃虽随岁孙损锁态谈叹汤烫涛讨腾誊题体条铁听厅头图" +
+		"驼袜弯湾顽万网为违围伟卫稳问窝乌无务雾误牺习戏细虾吓厦鲜闲显险宪县现线献乡详响项" +
+		"协胁写泻谢兴绣许续绪选学寻训讯压鸭亚严盐颜艳验阳杨养样钥药爷页业叶医仪遗亿忆义议" +
+		"艺译异阴银饮隐应营蝇赢拥优忧邮犹鱼渔与语狱预誉园员圆远约跃阅运杂灾载赞凿枣灶责择" +
+		"贼赠闸诈斋债毡战张涨帐账胀赵这针侦诊镇阵争挣睁郑证织职执纸质种众肿诸猪烛嘱筑驻专" +
+		"砖转赚庄装妆壮状浊资综总纵邹组钻国当请询诚谓终绿统维间闻闷闯仅伤伦伪侠侣俩储兑党" +
+		"冈册决况净凑则刚剑劲勋卢却厌叙呜哑哗啰场垄够娱婴宠岛岭径恋扬拟挂挡捞撑数昼晓暂权" +
+		"柜档欧泽滚炉烟烦狭琐畅码竞笼简缘缩肠芦苏茧萧虚蚀袭贪贴赶趋踪轮辈迈铜链销锅饱驶鲁" +
+		"龟"
+	traditionalChars = "" +
+		"愛礙襖罷擺敗頒辦幫寶報貝備筆幣畢閉邊編變標別賓餅補財參殘慘燦倉艙側測層產長嘗償廠" +
+		"車徹塵陳襯稱懲遲齒蟲籌處礎觸傳創錘純詞辭聰從叢錯達帶單擔膽導燈鄧敵遞點電墊釣調頂" +
+		"訂東動凍獨讀斷鍛隊對噸頓奪鵝額惡餓兒爾餌貳罰閥販飯訪紡飛廢費紛墳奮憤糞豐風楓瘋馮" +
+		"縫諷鳳膚輔撫婦負該蓋鋼崗綱鴿閣個給宮鞏貢溝構購顧關觀館慣貫廣歸規軌貴過韓漢號賀鶴" +
+		"轟紅護滬華話畫懷壞歡環還換喚揮輝會穢繪賄毀渾貨禍擊機積雞極級擠濟計記際繼紀夾價駕" +
+		"堅殲監檢儉減薦見艦漸踐鑑鍵將獎講醬膠驕嬌腳餃較階節潔結緊錦進驚經頸靜鏡糾舊舉劇懼" +
+		"據覺絕軍開凱殼課墾懇庫塊寬曠礦虧擴闊蠟臘來賴蘭攔欄爛藍籃覽懶勞樂壘類淚離禮麗厲勵" +
+		"連聯憐蓮臉煉練糧涼兩輛諒療遼獵臨鄰靈齡鈴領劉龍樓陸錄驢慮亂論羅邏鑼騾絡媽馬嗎買賣" +
+		"麥饅滿貓貿沒門們夢彌謎綿廟滅鳴銘謀畝納難腦惱鬧鳥寧農濃諾盤賠噴鵬騙貧頻憑評蘋撲鋪" +
+		"譜齊騎豈啟氣棄牽鉛遷謙錢鉗淺槍牆搶橋喬僑竅親輕傾慶窮區軀驅勸確讓饒擾繞熱認榮軟銳" +
+		"潤灑賽傘喪掃澀殺紗曬刪閃陝賞燒紹設攝紳審嬸腎滲聲繩勝聖師詩獅濕時識實勢適釋飾視試" +
+		"壽獸書輸屬樹術帥雙誰稅順說絲飼訴肅雖隨歲孫損鎖態談嘆湯燙濤討騰謄題體條鐵聽廳頭圖" +
+		"駝襪彎灣頑萬網為違圍偉衛穩問窩烏無務霧誤犧習戲細蝦嚇廈鮮閒顯險憲縣現線獻鄉詳響項" +
+		"協脅寫瀉謝興繡許續緒選學尋訓訊壓鴨亞嚴鹽顏艷驗陽楊養樣鑰藥爺頁業葉醫儀遺億憶義議" +
+		"藝譯異陰銀飲隱應營蠅贏擁優憂郵猶魚漁與語獄預譽園員圓遠約躍閱運雜災載贊鑿棗竈責擇" +
+		"賊贈閘詐齋債氈戰張漲帳賬脹趙這針偵診鎮陣爭掙睜鄭證織職執紙質種眾腫諸豬燭囑築駐專" +
+		"磚轉賺莊裝妝壯狀濁資綜總縱鄒組鑽國當請詢誠謂終綠統維間聞悶闖僅傷倫偽俠侶倆儲兌黨" +
+		"岡冊決況淨湊則剛劍勁勳盧卻厭敘嗚啞嘩囉場壟夠娛嬰寵島嶺徑戀揚擬掛擋撈撐數晝曉暫權" +
+		"櫃檔歐澤滾爐煙煩狹瑣暢碼競籠簡緣縮腸蘆蘇繭蕭虛蝕襲貪貼趕趨蹤輪輩邁銅鏈銷鍋飽駛魯" +
+		"龜"
+)
+
+// traditionalOnlyFolds maps the traditional forms of simplified characters
+// that have more than one, which only t2s can convert.
+var traditionalOnlyFolds = map[string]rune{
+	"發髮":  '发',
+	"乾幹":  '干',
+	"後":   '后',
+	"裡裏":  '里',
+	"鬆":   '松',
+	"麵":   '面',
+	"隻":   '只',
+	"臺檯颱": '台',
+	"鐘鍾":  '钟',
+	"復複":  '复',
+	"範":   '范',
+	"穀":   '谷',
+	"醜":   '丑',
+	"係繫":  '系',
+	"歷曆":  '历',
+	"衝":   '冲',
+	"製":   '制',
+	"準":   '准',
+	"鬥":   '斗',
+	"錶":   '表',
+	"雲":   '云',
+	"幾":   '几',
+	"髒臟":  '脏',
+	"獲穫":  '获',
+	"盡儘":  '尽',
+	"匯彙":  '汇',
+	"鹹":   '咸',
+	"捲":   '卷',
+	"徵":   '征',
+	"遊":   '游',
+	"餘":   '余',
+	"鬱":   '郁',
+	"薑":   '姜',
+	"夥":   '伙',
+	"簽籤":  '签',
+	"團糰":  '团',
+	"壇罈":  '坛',
+	"緻":   '致',
+	"纔":   '才',
+	"劃":   '划',
+	"塗":   '涂',
+	"韆":   '千',
+	"樸":   '朴',
+	"並併":  '并',
+	"麼":   '么',
+	"於":   '于',
+	"願":   '愿',
+	"嶽":   '岳',
+	"紮":   '扎',
+	"兇":   '凶',
+	"須鬚":  '须',
+	"捨":   '舍',
+	"飢饑":  '饥',
+	"誇":   '夸',
+	"颳":   '刮',
+	"傭":   '佣',
+}
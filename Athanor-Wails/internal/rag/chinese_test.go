@@ -0,0 +1,55 @@
+package rag
+
+import "testing"
+
+func TestConvertChineseScript(t *testing.T) {
+	newBook := func(text string) Book {
+		return Book{
+			Metadata: Metadata{Title: text, Language: "zh-CN"},
+			Main: []Chapter{{
+				ID:       "chapter-001",
+				Title:    text,
+				Language: "zh-CN",
+				Blocks: []Block{
+					{Kind: BlockKindParagraph, Text: text + "，见[这里](#这里) `这个`"},
+					{Kind: BlockKindCode, Text: text},
+					{Kind: BlockKindList, Items: []string{text}},
+				},
+				Footnotes: []Footnote{{Label: "1", Content: text}},
+			}},
+		}
+	}
+
+	book := newBook("这本书说的是头发")
+	ConvertChineseScript(&book, ChineseToTraditional)
+	chapter := book.Main[0]
+	if book.Metadata.Title != "這本書說的是頭发" || chapter.Title != book.Metadata.Title {
+		t.Fatalf("unexpected traditional title %q", book.Metadata.Title)
+	}
+	if chapter.Blocks[0].Text != "這本書說的是頭发，見[這里](#这里) `这个`" {
+		t.Fatalf("link targets and code spans should be kept: %q", chapter.Blocks[0].Text)
+	}
+	if chapter.Blocks[1].Text != "这本书说的是头发" {
+		t.Fatalf("code blocks should be kept: %q", chapter.Blocks[1].Text)
+	}
+	if chapter.Blocks[2].Items[0] != book.Metadata.Title || chapter.Footnotes[0].Content != book.Metadata.Title {
+		t.Fatalf("lists and footnotes should be converted: %+v", chapter)
+	}
+	if book.Metadata.Language != "zh-Hant" || chapter.Language != "zh-Hant" {
+		t.Fatalf("expected zh-Hant tags, got %q and %q", book.Metadata.Language, chapter.Language)
+	}
+
+	book = newBook("這本書說的是頭髮，後來發現")
+	ConvertChineseScript(&book, ChineseToSimplified)
+	if book.Metadata.Title != "这本书说的是头发，后来发现" || book.Metadata.Language != "zh-Hans" {
+		t.Fatalf("unexpected simplified result %q (%s)", book.Metadata.Title, book.Metadata.Language)
+	}
+}
+
+func TestChineseTablesRoundTrip(t *testing.T) {
+	for s, tr := range simplifiedToTraditional {
+		if traditionalToSimplified[tr] != s {
+			t.Fatalf("%c -> %c does not convert back", s, tr)
+		}
+	}
+}
@@ -33,6 +33,7 @@ func BuildChunks(book Book, config ChunkConfig) []Chunk {
 
 	for _, chapter := range chapters {
 		chapterSequence := 0
+		language := chapterLanguage(chapter, book)
 		units, noteIndex := buildChunkUnits(chapter, config)
 		usedNotes := map[string]struct{}{}
 		var bucket []string
@@ -50,14 +51,14 @@ func BuildChunks(book Book, config ChunkConfig) []Chunk {
 			}
 			attachedNotes := footnotesForChunk(text, noteIndex, usedNotes)
 			if len(attachedNotes) > 0 {
-				text += "\n\n## Footnotes\n" + strings.Join(attachedNotes, "\n")
+				text += "\n\n## " + book.text().ChunkFootnotes + "\n" + strings.Join(attachedNotes, "\n")
 			}
 			globalSequence++
 			chapterSequence++
 			headingPath := append([]string(nil), pendingHeadings...)
 			chunks = append(chunks, Chunk{
 				ID:            fmt.Sprintf("%s-%03d", chapter.ID, chapterSequence),
-				BookTitle:     safeTitle(book.Metadata.Title),
+				BookTitle:     bookTitle(book),
 				ChapterID:     chapter.ID,
 				ChapterTitle:  chapter.Title,
 				ChapterOrder:  chapter.Order,
@@ -69,9 +70,9 @@ func BuildChunks(book Book, config ChunkConfig) []Chunk {
 				CharacterSize: len([]rune(text)),
 				BlockCount:    bucketBlocks,
 				HeadingPath:   headingPath,
-				Language:      book.Metadata.Language,
+				Language:      language,
 				HasFootnotes:  len(attachedNotes) > 0,
-				TokenEstimate: estimateTokens(text, book.Metadata.Language),
+				TokenEstimate: estimateTokens(text, language),
 			})
 			bucket = nil
 			bucketSize = 0
@@ -128,10 +129,10 @@ func BuildChunks(book Book, config ChunkConfig) []Chunk {
 			}
 			globalSequence++
 			chapterSequence++
-			text := "## Footnotes\n" + strings.Join(orphanNotes, "\n")
+			text := "## " + book.text().ChunkFootnotes + "\n" + strings.Join(orphanNotes, "\n")
 			chunks = append(chunks, Chunk{
 				ID:            fmt.Sprintf("%s-%03d", chapter.ID, chapterSequence),
-				BookTitle:     safeTitle(book.Metadata.Title),
+				BookTitle:     bookTitle(book),
 				ChapterID:     chapter.ID,
 				ChapterTitle:  chapter.Title,
 				ChapterOrder:  chapter.Order,
@@ -142,10 +143,10 @@ func BuildChunks(book Book, config ChunkConfig) []Chunk {
 				SourceRef:     chapter.SourceRef,
 				CharacterSize: len([]rune(text)),
 				BlockCount:    len(orphanNotes),
-				HeadingPath:   []string{"## Footnotes"},
-				Language:      book.Metadata.Language,
+				HeadingPath:   []string{"## " + book.text().ChunkFootnotes},
+				Language:      language,
 				HasFootnotes:  true,
-				TokenEstimate: estimateTokens(text, book.Metadata.Language),
+				TokenEstimate: estimateTokens(text, language),
 			})
 		}
 		flush()
@@ -298,7 +299,7 @@ func chunkText(block Block) string {
 		headingBase:      1,
 		includeSeparator: false,
 	})
-	return strings.TrimSpace(strings.Join(lines, "\n"))
+	return stripImages(strings.TrimSpace(strings.Join(lines, "\n")))
 }
 
 func normalizeChunkConfig(config ChunkConfig) ChunkConfig {
@@ -13,6 +13,27 @@ import (
 	"strings"
 )
 
+// Where each stage starts on the 0-100 progress scale. Stages that work
+// through chapters or files report their own steps up to the next stage, so
+// a large book does not leave the bar standing still.
+const (
+	progressInspect   = 5
+	progressNormalize = 30
+	progressChapters  = 45
+	progressRender    = 65
+	progressWrite     = 85
+	progressComplete  = 100
+)
+
+// stepProgress places step done of total inside a stage running from from to
+// to. The last step stays short of to, which belongs to the next stage.
+func stepProgress(from, to float64, done, total int) float64 {
+	if total <= 0 {
+		return from
+	}
+	return from + (to-from)*float64(done)/float64(total+1)
+}
+
 func ConvertEPUB(ctx context.Context, inputPath string, options Options) (ConvertResult, error) {
 	if ctx == nil {
 		ctx = options.Context
@@ -30,38 +51,178 @@ func ConvertEPUB(ctx context.Context, inputPath string, options Options) (Conver
 		progress = func(string, float64, string) {}
 	}
 
-	progress("inspect", 5, "📦 读取 EPUB 容器...")
-	book, err := ParseEPUB(ctx, inputPath)
+	book, err := prepareBook(ctx, inputPath, &options, logf, progress)
 	if err != nil {
 		return ConvertResult{}, err
 	}
-	book.Metadata.SourcePath = inputPath
-
-	hash, err := fileSHA256(inputPath)
-	if err != nil {
-		return ConvertResult{}, fmt.Errorf("计算文件指纹失败: %w", err)
+	warnings := conversionWarnings(book)
+	if options.OnWarning == WarningsFail && len(warnings) > 0 {
+		return ConvertResult{}, warningsError(warnings)
 	}
-	book.Metadata.SourceSHA256 = hash
 
-	progress("normalize", 30, "🧹 清洗结构并生成文档模型...")
-	NormalizeBook(&book)
-	logf(fmt.Sprintf("📚 正文章节: %d | 前后置材料: %d", len(book.Main), len(book.Back)))
+	progress("chapters", progressChapters, "📄 逐章写出 Markdown...")
+	if err := writeChapterFiles(ctx, options, book, progress); err != nil {
+		return ConvertResult{}, err
+	}
 
-	progress("render", 65, "📝 渲染 Markdown...")
-	mainMD := RenderBookMarkdown(book)
+	progress("render", progressRender, "📝 渲染 Markdown...")
+	mainMD := WrapMarkdown(RenderBookMarkdown(book), options.Wrap, options.Columns)
+	if options.Provenance {
+		mainMD += "\n" + provenanceFooter(book.Metadata, options)
+	}
 	debugMD := RenderDebugMarkdown(book)
-	chapterDocs := RenderChapterMarkdown(book)
 	chunks := BuildChunks(book, options.ChunkConfig)
 	book.Stats.ChunkCount = len(chunks)
 	diagnostics := BuildDiagnostics(book, chunks, options.ChunkConfig)
 
-	progress("write", 85, "💾 写出主文档与章节文件...")
-	mainPath, debugPath, artifactDir, err := writeArtifacts(options, book, mainMD, debugMD, chapterDocs, chunks, diagnostics)
+	if err := ctx.Err(); err != nil {
+		return ConvertResult{}, err
+	}
+
+	progress("write", progressWrite, "💾 写出主文档与章节文件...")
+	steps := 1
+	for _, enabled := range []bool{
+		len(book.images.files) > 0, options.Readability, options.PlainText, options.AsciiDoc,
+		options.DocBook, options.SQLScript, options.DOCX, options.ODT, options.RTF, options.HTML,
+		options.Cover == CoverModeExtract && book.Metadata.CoverImage != "",
+	} {
+		if enabled {
+			steps++
+		}
+	}
+	written := 0
+	wrote := func(what string) {
+		written++
+		progress("write", stepProgress(progressWrite, progressComplete, written, steps), "💾 已写出"+what)
+	}
+
+	mainPath, debugPath, artifactDir, err := writeArtifacts(options, book, mainMD, debugMD, chunks, diagnostics)
 	if err != nil {
 		return ConvertResult{}, err
 	}
+	wrote("主文档与 chunks")
+	if err := writeImages(artifactDir, book.images); err != nil {
+		return ConvertResult{}, err
+	}
+	if len(book.images.files) > 0 {
+		wrote(fmt.Sprintf(" %d 张图片", len(book.images.files)))
+	}
+
+	readabilityPath := ""
+	if options.Readability {
+		readabilityPath = filepath.Join(artifactDir, "readability.json")
+		if err := writeJSON(readabilityPath, BuildReadabilityReport(book)); err != nil {
+			return ConvertResult{}, err
+		}
+		wrote("可读性报告")
+	}
 
-	progress("complete", 100, "✅ 输出已生成")
+	textPath := ""
+	if options.PlainText {
+		textPath = filepath.Join(options.OutputRootDir, options.BaseName+".txt")
+		if err := os.WriteFile(textPath, []byte(RenderBookText(book, options.TextWrap)), 0o644); err != nil {
+			return ConvertResult{}, fmt.Errorf("写入纯文本失败: %w", err)
+		}
+		wrote("纯文本")
+	}
+
+	asciiDocPath := ""
+	if options.AsciiDoc {
+		asciiDocPath = filepath.Join(options.OutputRootDir, options.BaseName+".adoc")
+		if err := os.WriteFile(asciiDocPath, []byte(RenderBookAsciiDoc(book)), 0o644); err != nil {
+			return ConvertResult{}, fmt.Errorf("写入 AsciiDoc 失败: %w", err)
+		}
+		wrote(" AsciiDoc")
+	}
+
+	docBookPath := ""
+	if options.DocBook {
+		docBookPath = filepath.Join(options.OutputRootDir, options.BaseName+".docbook.xml")
+		if err := os.WriteFile(docBookPath, []byte(RenderBookDocBook(book)), 0o644); err != nil {
+			return ConvertResult{}, fmt.Errorf("写入 DocBook 失败: %w", err)
+		}
+		wrote(" DocBook")
+	}
+
+	sqlScriptPath := ""
+	if options.SQLScript {
+		sqlScriptPath = filepath.Join(options.OutputRootDir, options.BaseName+".sql")
+		if err := os.WriteFile(sqlScriptPath, []byte(RenderBookSQL(book)), 0o644); err != nil {
+			return ConvertResult{}, fmt.Errorf("写入 SQL 脚本失败: %w", err)
+		}
+		wrote(" SQL 脚本")
+	}
+
+	docxPath := ""
+	if options.DOCX {
+		var styles []byte
+		if options.DOCXReference != "" {
+			if styles, err = ReadDOCXReferenceStyles(options.DOCXReference); err != nil {
+				return ConvertResult{}, err
+			}
+		}
+		data, err := RenderBookDOCX(book, styles)
+		if err != nil {
+			return ConvertResult{}, fmt.Errorf("生成 DOCX 失败: %w", err)
+		}
+		docxPath = filepath.Join(options.OutputRootDir, options.BaseName+".docx")
+		if err := os.WriteFile(docxPath, data, 0o644); err != nil {
+			return ConvertResult{}, fmt.Errorf("写入 DOCX 失败: %w", err)
+		}
+		wrote(" DOCX")
+	}
+
+	odtPath := ""
+	if options.ODT {
+		data, err := RenderBookODT(book)
+		if err != nil {
+			return ConvertResult{}, fmt.Errorf("生成 ODT 失败: %w", err)
+		}
+		odtPath = filepath.Join(options.OutputRootDir, options.BaseName+".odt")
+		if err := os.WriteFile(odtPath, data, 0o644); err != nil {
+			return ConvertResult{}, fmt.Errorf("写入 ODT 失败: %w", err)
+		}
+		wrote(" ODT")
+	}
+
+	rtfPath := ""
+	if options.RTF {
+		rtfPath = filepath.Join(options.OutputRootDir, options.BaseName+".rtf")
+		if err := os.WriteFile(rtfPath, []byte(RenderBookRTF(book)), 0o644); err != nil {
+			return ConvertResult{}, fmt.Errorf("写入 RTF 失败: %w", err)
+		}
+		wrote(" RTF")
+	}
+
+	htmlPath := ""
+	if options.HTML {
+		book.applyHTMLOptions(options)
+		if book.Metadata.CoverImage != "" {
+			if book.coverData, err = readCoverImage(inputPath, book.Metadata.CoverImage); err != nil {
+				logf("⚠️ HTML 阅读器不显示封面: " + err.Error())
+			}
+		}
+		htmlPath, err = writeHTMLReader(filepath.Join(artifactDir, "html"), RenderBookHTML(book))
+		if err != nil {
+			return ConvertResult{}, err
+		}
+		wrote(" HTML 阅读器")
+	}
+
+	coverPath := ""
+	if options.Cover == CoverModeExtract && book.Metadata.CoverImage != "" {
+		coverPath, err = writeCoverImage(inputPath, book.Metadata.CoverImage, artifactDir)
+		if err != nil {
+			return ConvertResult{}, err
+		}
+		wrote("封面")
+	}
+
+	if options.OnWarning == WarningsIgnore {
+		warnings = nil
+	}
+
+	progress("complete", progressComplete, "✅ 输出已生成")
 	return ConvertResult{
 		MainMarkdownPath:  mainPath,
 		DebugMarkdownPath: debugPath,
@@ -70,17 +231,119 @@ func ConvertEPUB(ctx context.Context, inputPath string, options Options) (Conver
 		TOCPath:           filepath.Join(artifactDir, "toc.json"),
 		ChunksPath:        filepath.Join(artifactDir, "chunks.jsonl"),
 		DiagnosticsPath:   filepath.Join(artifactDir, "diagnostics.json"),
+		ReadabilityPath:   readabilityPath,
+		CoverPath:         coverPath,
+		TextPath:          textPath,
+		AsciiDocPath:      asciiDocPath,
+		DocBookPath:       docBookPath,
+		SQLScriptPath:     sqlScriptPath,
+		DOCXPath:          docxPath,
+		ODTPath:           odtPath,
+		RTFPath:           rtfPath,
+		HTMLPath:          htmlPath,
 		Stats:             book.Stats,
+		Warnings:          warnings,
 	}, nil
 }
 
-func writeArtifacts(options Options, book Book, mainMD string, debugMD string, chapterDocs map[string]string, chunks []Chunk, diagnostics Diagnostics) (string, string, string, error) {
+// prepareBook parses and normalizes a book and applies every option that
+// changes its content, so the renderers only have to format it. It may
+// rewrite options.BaseName for series numbering.
+func prepareBook(ctx context.Context, inputPath string, options *Options, logf func(string), progress func(string, float64, string)) (Book, error) {
+	progress("inspect", progressInspect, "📦 读取 EPUB 容器...")
+	book, err := parseEPUB(ctx, inputPath, *options)
+	if err != nil {
+		return Book{}, err
+	}
+	for _, resource := range book.encrypted {
+		logf(fmt.Sprintf("🔒 加密资源: %s (%s)", logDetail(resource.Path), resource.Kind))
+	}
+	book.Metadata.SourcePath = inputPath
+
+	hash, err := fileSHA256(inputPath)
+	if err != nil {
+		return Book{}, fmt.Errorf("计算文件指纹失败: %w", err)
+	}
+	book.Metadata.SourceSHA256 = hash
+	if options.SeriesNumbering {
+		options.BaseName = SeriesBaseName(book.Metadata, options.BaseName)
+	}
+	if options.Cover == CoverModeSkip {
+		book.Metadata.CoverImage = ""
+	}
+
+	progress("normalize", progressNormalize, "🧹 清洗结构并生成文档模型...")
+	NormalizeBook(&book)
+	book.labels = resolveLabelLanguage(options.Labels, book.Metadata.Language)
+	book.notes = options.Notes
+	for _, duplicate := range DetectDuplicateChapters(&book, options.DropDuplicates) {
+		if duplicate.Dropped {
+			logf(fmt.Sprintf("🗑️ 已移除重复章节 %s (%s)，与 %s 相同", duplicate.ID, logDetail(duplicate.Title), duplicate.DuplicateOf))
+		} else {
+			logf(fmt.Sprintf("⚠️ 章节 %s (%s) 与 %s 内容重复", duplicate.ID, logDetail(duplicate.Title), duplicate.DuplicateOf))
+		}
+	}
+	ConvertChineseScript(&book, options.Chinese)
+	NormalizeHeadingLevels(&book, options.Headings)
+	ResolveCrossLinks(&book)
+	if err := planImages(&book, inputPath, *options); err != nil {
+		return Book{}, err
+	}
+	for _, raw := range book.warnings {
+		warning := parseWarning(raw)
+		if warning.Detail != "" {
+			warning.Detail = logDetail(warning.Detail)
+		}
+		logf("⚠️ " + warning.String())
+	}
+	logf(fmt.Sprintf("📚 正文章节: %d | 前后置材料: %d", len(book.Main), len(book.Back)))
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
+	return book, nil
+}
+
+// writeChapterFiles renders and writes the per-chapter Markdown one chapter
+// at a time, so the first chapters of a large book can be read while the
+// rest of the conversion is still running. Each chapter also advances the
+// "chapters" stage of the progress bar.
+func writeChapterFiles(ctx context.Context, options Options, book Book, progress func(string, float64, string)) error {
+	chaptersDir := filepath.Join(options.OutputRootDir, options.BaseName, "chapters")
+	if err := os.MkdirAll(chaptersDir, 0o755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	owners := anchorChapters(book)
+	all := append(append([]Chapter(nil), book.Main...), book.Back...)
+	for i, chapter := range all {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		doc := WrapMarkdown(renderChapterDoc(book, chapter, owners), options.Wrap, options.Columns)
+		filename := filepath.Join(chaptersDir, sanitizePathComponent(chapter.ID)+".md")
+		if err := os.WriteFile(filename, []byte(doc), 0o644); err != nil {
+			return fmt.Errorf("写入章节 Markdown 失败: %w", err)
+		}
+		if options.ChapterWritten != nil {
+			options.ChapterWritten(ChapterOutput{
+				ID:    chapter.ID,
+				Title: displayChapterTitle(chapter),
+				Path:  filename,
+				Index: i + 1,
+				Total: len(all),
+			})
+		}
+		progress("chapters", stepProgress(progressChapters, progressRender, i+1, len(all)), fmt.Sprintf("📄 已写出章节 %d/%d", i+1, len(all)))
+	}
+	return nil
+}
+
+func writeArtifacts(options Options, book Book, mainMD string, debugMD string, chunks []Chunk, diagnostics Diagnostics) (string, string, string, error) {
 	mainPath := filepath.Join(options.OutputRootDir, options.BaseName+".md")
 	artifactDir := filepath.Join(options.OutputRootDir, options.BaseName)
-	chaptersDir := filepath.Join(artifactDir, "chapters")
 	debugPath := filepath.Join(artifactDir, "debug.md")
 
-	if err := os.MkdirAll(chaptersDir, 0o755); err != nil {
+	if err := os.MkdirAll(artifactDir, 0o755); err != nil {
 		return "", "", "", fmt.Errorf("创建输出目录失败: %w", err)
 	}
 	if err := os.WriteFile(mainPath, []byte(mainMD), 0o644); err != nil {
@@ -90,13 +353,6 @@ func writeArtifacts(options Options, book Book, mainMD string, debugMD string, c
 		return "", "", "", fmt.Errorf("写入 debug markdown 失败: %w", err)
 	}
 
-	for id, content := range chapterDocs {
-		filename := filepath.Join(chaptersDir, sanitizePathComponent(id)+".md")
-		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
-			return "", "", "", fmt.Errorf("写入章节 Markdown 失败: %w", err)
-		}
-	}
-
 	toc := make([]TOCItem, 0, len(book.Main)+len(book.Back))
 	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
 		toc = append(toc, TOCItem{
@@ -106,6 +362,8 @@ func writeArtifacts(options Options, book Book, mainMD string, debugMD string, c
 			ClassifyReason: chapter.ClassifyReason,
 			Order:          chapter.Order,
 			Source:         chapter.SourceRef,
+			Language:       chapter.Language,
+			Depth:          chapter.Depth,
 		})
 	}
 
@@ -128,6 +386,18 @@ func writeArtifacts(options Options, book Book, mainMD string, debugMD string, c
 	return mainPath, debugPath, artifactDir, nil
 }
 
+func writeHTMLReader(dir string, pages map[string]string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建 HTML 目录失败: %w", err)
+	}
+	for name, content := range pages {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return "", fmt.Errorf("写入 HTML 失败: %w", err)
+		}
+	}
+	return filepath.Join(dir, "index.html"), nil
+}
+
 func writeJSON(path string, value any) error {
 	data, err := json.MarshalIndent(value, "", "  ")
 	if err != nil {
@@ -180,6 +450,16 @@ func fileSHA256(path string) (string, error) {
 	return hex.EncodeToString(sum[:]), nil
 }
 
+// DefaultBaseName names a book's outputs after its file: the file name
+// without its extension, made safe as a path component, plus "_athanor".
+func DefaultBaseName(inputPath string) string {
+	name := sanitizePathComponent(strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath)))
+	if name == "" {
+		name = "book"
+	}
+	return name + "_athanor"
+}
+
 func sanitizePathComponent(s string) string {
 	replacer := strings.NewReplacer(
 		"/", "_",
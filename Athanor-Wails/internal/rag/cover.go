@@ -0,0 +1,164 @@
+package rag
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const maxCoverPageRunes = 200
+
+type coverInfo struct {
+	ImageHref string
+	PageHref  string
+}
+
+// detectCover finds the cover image declared by the OPF (EPUB3 cover-image
+// property, EPUB2 <meta name="cover">, or the guide) and the spine page that
+// only exists to display it, so the page can be dropped instead of leaking
+// alt text or an empty "Cover" chapter into the Markdown.
+func detectCover(entries map[string]zipEntry, opfDir string, pkg packageXML) coverInfo {
+	var info coverInfo
+
+	coverID := ""
+	for _, meta := range pkg.Metadata.Meta {
+		if strings.EqualFold(strings.TrimSpace(meta.Name), "cover") {
+			coverID = strings.TrimSpace(meta.Content)
+			break
+		}
+	}
+	for _, item := range pkg.Manifest.Items {
+		if hasProperty(item.Properties, "cover-image") {
+			info.ImageHref = resolveHref(opfDir, item.Href)
+			break
+		}
+	}
+	if info.ImageHref == "" && coverID != "" {
+		for _, item := range pkg.Manifest.Items {
+			if item.ID == coverID && strings.HasPrefix(strings.ToLower(item.MediaType), "image/") {
+				info.ImageHref = resolveHref(opfDir, item.Href)
+				break
+			}
+		}
+	}
+
+	candidates := make([]string, 0, 2)
+	for _, ref := range pkg.Guide.Refs {
+		if normalizeTitle(ref.Type) == "cover" {
+			candidates = append(candidates, resolveHref(opfDir, ref.Href))
+		}
+	}
+	manifestHrefs := make(map[string]string, len(pkg.Manifest.Items))
+	for _, item := range pkg.Manifest.Items {
+		manifestHrefs[item.ID] = resolveHref(opfDir, item.Href)
+	}
+	if len(pkg.Spine.Itemrefs) > 0 {
+		candidates = append(candidates, manifestHrefs[pkg.Spine.Itemrefs[0].IDRef])
+	}
+
+	for _, href := range candidates {
+		entry, ok := entries[href]
+		if !ok {
+			continue
+		}
+		image, ok := coverPageImage(href, entry.data)
+		if !ok {
+			continue
+		}
+		if info.ImageHref == "" {
+			info.ImageHref = image
+		}
+		if image == info.ImageHref {
+			info.PageHref = href
+			break
+		}
+	}
+	return info
+}
+
+// coverPageImage reports the single image a page displays when the page has
+// no meaningful text of its own.
+func coverPageImage(pageHref string, data []byte) (string, bool) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", false
+	}
+	body := findElement(doc, "body")
+	if body == nil {
+		return "", false
+	}
+	if len([]rune(nodeText(body))) > maxCoverPageRunes {
+		return "", false
+	}
+
+	var images []string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			src := ""
+			switch node.Data {
+			case "img":
+				src = attr(node, "src")
+			case "image":
+				src = firstNonEmpty(attr(node, "xlink:href"), attr(node, "href"))
+			}
+			if src != "" {
+				images = append(images, resolveHref(path.Dir(pageHref), src))
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(body)
+	if len(images) != 1 {
+		return "", false
+	}
+	return images[0], true
+}
+
+func hasProperty(properties, name string) bool {
+	for _, property := range strings.Fields(properties) {
+		if property == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readCoverImage returns the cover bytes from the EPUB untouched so the
+// original resolution is preserved.
+func readCoverImage(inputPath, imageHref string) ([]byte, error) {
+	reader, entries, err := openEPUBEntries(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	entry, ok := entries[imageHref]
+	if !ok {
+		return nil, fmt.Errorf("找不到封面图片: %s", imageHref)
+	}
+	return entry.data, nil
+}
+
+func coverFileName(imageHref string) string {
+	return "cover" + strings.ToLower(path.Ext(imageHref))
+}
+
+func writeCoverImage(inputPath, imageHref, artifactDir string) (string, error) {
+	data, err := readCoverImage(inputPath, imageHref)
+	if err != nil {
+		return "", err
+	}
+	output := filepath.Join(artifactDir, coverFileName(imageHref))
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		return "", fmt.Errorf("写入封面图片失败: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertEPUBDropsCoverPageAndExtractsImage(t *testing.T) {
+	workDir := testOutputDir(t, "cover")
+	input := filepath.Join(workDir, "cover.epub")
+	writeTestEPUB(t, input, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf": `<?xml version="1.0" encoding="UTF-8"?>
+<package version="2.0" xmlns="http://www.idpf.org/2007/opf">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Cover Book</dc:title>
+    <dc:language>en</dc:language>
+    <meta name="cover" content="cover-img"/>
+  </metadata>
+  <manifest>
+    <item id="cover-img" href="images/cover.jpg" media-type="image/jpeg"/>
+    <item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="chap1" href="chap1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="cover"/>
+    <itemref idref="chap1"/>
+  </spine>
+</package>`,
+		"OEBPS/images/cover.jpg": "original-cover-bytes",
+		"OEBPS/cover.xhtml":      `<html><body><h1>Cover</h1><div><img src="images/cover.jpg" alt="Cover Book"/></div></body></html>`,
+		"OEBPS/chap1.xhtml":      `<html><body><h1>Chapter One</h1><p>Body text.</p></body></html>`,
+	})
+
+	result, err := ConvertEPUB(context.Background(), input, Options{
+		OutputRootDir: workDir,
+		BaseName:      "cover",
+		Cover:         CoverModeExtract,
+		HTML:          true,
+	})
+	if err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+
+	mainData, err := os.ReadFile(result.MainMarkdownPath)
+	if err != nil {
+		t.Fatalf("read main markdown: %v", err)
+	}
+	if strings.Contains(string(mainData), "Cover\n") {
+		t.Fatalf("cover page should be dropped: %s", mainData)
+	}
+	if result.Stats.FrontMatterCount != 0 {
+		t.Fatalf("cover page should not become frontmatter, got %+v", result.Stats)
+	}
+
+	if result.CoverPath != filepath.Join(result.ArtifactDir, "cover.jpg") {
+		t.Fatalf("unexpected cover path: %s", result.CoverPath)
+	}
+	coverData, err := os.ReadFile(result.CoverPath)
+	if err != nil {
+		t.Fatalf("read cover: %v", err)
+	}
+	if string(coverData) != "original-cover-bytes" {
+		t.Fatalf("cover should be copied untouched, got %q", coverData)
+	}
+
+	index, err := os.ReadFile(result.HTMLPath)
+	if err != nil {
+		t.Fatalf("read html index: %v", err)
+	}
+	if !strings.Contains(string(index), `<figure class="cover"><img src="cover.jpg" alt="封面"></figure>`+"\n<h1>Cover Book</h1>") {
+		t.Fatalf("html reader should open with the cover:\n%s", index)
+	}
+	if data, err := os.ReadFile(filepath.Join(filepath.Dir(result.HTMLPath), "cover.jpg")); err != nil || string(data) != "original-cover-bytes" {
+		t.Fatalf("html reader should carry the cover image: %q %v", data, err)
+	}
+
+	skipped, err := ConvertEPUB(context.Background(), input, Options{
+		OutputRootDir: workDir,
+		BaseName:      "cover-skip",
+		Cover:         CoverModeSkip,
+		HTML:          true,
+	})
+	if err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+	if index, _ := os.ReadFile(skipped.HTMLPath); strings.Contains(string(index), "figure") {
+		t.Fatalf("skipped cover should not appear in the html reader:\n%s", index)
+	}
+}
+
+func TestDetectCoverKeepsTextHeavyFirstPage(t *testing.T) {
+	entries := map[string]zipEntry{
+		"chap1.xhtml": {name: "chap1.xhtml", data: []byte(`<html><body><img src="map.png"/><p>` + strings.Repeat("Long opening text. ", 20) + `</p></body></html>`)},
+	}
+	var pkg packageXML
+	opf := `<package><manifest><item id="chap1" href="chap1.xhtml" media-type="application/xhtml+xml"/></manifest><spine><itemref idref="chap1"/></spine></package>`
+	if err := decodeXML([]byte(opf), &pkg); err != nil {
+		t.Fatalf("decode opf: %v", err)
+	}
+
+	info := detectCover(entries, ".", pkg)
+	if info.PageHref != "" {
+		t.Fatalf("text-heavy page should not be treated as cover: %+v", info)
+	}
+}
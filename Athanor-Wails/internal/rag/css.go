@@ -0,0 +1,263 @@
+package rag
+
+import (
+	"cmp"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var cssCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+type styleHints struct {
+	italic bool
+	bold   bool
+	quote  bool
+	align  TextAlign
+	// centred marks blocks with auto side margins, which centres images
+	// but not the text inside them.
+	centred bool
+}
+
+type styleMapper struct {
+	config  StyleConfig
+	classes map[string]styleHints
+}
+
+func (c StyleConfig) enabled() bool {
+	return c.Emphasis || c.Blockquotes || c.Alignment
+}
+
+// buildStyleMapper collects class rules from every stylesheet in the manifest.
+// Only single-class selectors ("p.quote", ".calibre5") are mapped; descendant
+// and pseudo-element rules are too contextual to apply to a flat block list.
+func buildStyleMapper(entries map[string]zipEntry, opfDir string, pkg packageXML, config StyleConfig) styleMapper {
+	mapper := styleMapper{config: config, classes: map[string]styleHints{}}
+	if !config.enabled() {
+		return mapper
+	}
+	for _, item := range pkg.Manifest.Items {
+		if !strings.EqualFold(strings.TrimSpace(item.MediaType), "text/css") {
+			continue
+		}
+		entry, ok := entries[resolveHref(opfDir, item.Href)]
+		if !ok {
+			continue
+		}
+		for class, hints := range parseCSSClassHints(string(entry.data)) {
+			mapper.classes[class] = mergeStyleHints(mapper.classes[class], hints)
+		}
+	}
+	return mapper
+}
+
+func parseCSSClassHints(css string) map[string]styleHints {
+	css = cssCommentRe.ReplaceAllString(css, "")
+	out := map[string]styleHints{}
+	for _, rule := range strings.Split(css, "}") {
+		selectorText, body, ok := strings.Cut(rule, "{")
+		if !ok || strings.HasPrefix(strings.TrimSpace(selectorText), "@") {
+			continue
+		}
+		hints := parseDeclarationHints(body)
+		if hints == (styleHints{}) {
+			continue
+		}
+		for _, selector := range strings.Split(selectorText, ",") {
+			class, ok := singleClassSelector(selector)
+			if !ok {
+				continue
+			}
+			out[class] = mergeStyleHints(out[class], hints)
+		}
+	}
+	return out
+}
+
+func singleClassSelector(selector string) (string, bool) {
+	selector = strings.TrimSpace(selector)
+	if selector == "" || strings.ContainsAny(selector, " >+~:[#*") {
+		return "", false
+	}
+	_, class, ok := strings.Cut(selector, ".")
+	if !ok || class == "" || strings.Contains(class, ".") {
+		return "", false
+	}
+	return class, true
+}
+
+func parseDeclarationHints(body string) styleHints {
+	var hints styleHints
+	var leftAuto, rightAuto bool
+	for _, declaration := range strings.Split(body, ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important")))
+		switch property {
+		case "font-style":
+			hints.italic = value == "italic" || value == "oblique"
+		case "font-weight":
+			hints.bold = value == "bold" || value == "bolder" || cssWeightAtLeast(value, 600)
+		case "border-left", "border":
+			hints.quote = hints.quote || cssBorderVisible(value)
+		case "margin-left", "padding-left":
+			hints.quote = hints.quote || cssIndentIsQuote(value)
+			leftAuto = leftAuto || property == "margin-left" && value == "auto"
+		case "margin-right":
+			rightAuto = value == "auto"
+		case "margin":
+			// "auto", "0 auto", "0 auto 1em" and "0 auto 0 auto" all put
+			// auto on both sides.
+			fields := strings.Fields(value)
+			hints.centred = len(fields) > 0 && fields[min(1, len(fields)-1)] == "auto" &&
+				(len(fields) < 4 || fields[3] == "auto")
+		case "text-align":
+			switch value {
+			case "center":
+				hints.align = AlignCenter
+			case "right":
+				hints.align = AlignRight
+			}
+		}
+	}
+	hints.centred = hints.centred || leftAuto && rightAuto
+	return hints
+}
+
+func cssWeightAtLeast(value string, min int) bool {
+	weight, err := strconv.Atoi(value)
+	return err == nil && weight >= min
+}
+
+func cssBorderVisible(value string) bool {
+	if value == "" || strings.Contains(value, "none") || strings.Contains(value, "hidden") {
+		return false
+	}
+	for _, field := range strings.Fields(value) {
+		if amount, _, ok := parseCSSLength(field); ok && amount == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func cssIndentIsQuote(value string) bool {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return false
+	}
+	amount, unit, ok := parseCSSLength(fields[0])
+	if !ok {
+		return false
+	}
+	switch unit {
+	case "em", "rem":
+		return amount >= 1.5
+	case "%":
+		return amount >= 5
+	case "px", "pt":
+		return amount >= 24
+	default:
+		return false
+	}
+}
+
+func parseCSSLength(value string) (float64, string, bool) {
+	index := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-'
+	})
+	number, unit := value, ""
+	if index >= 0 {
+		number, unit = value[:index], value[index:]
+	}
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return amount, unit, true
+}
+
+func mergeStyleHints(a, b styleHints) styleHints {
+	return styleHints{
+		italic:  a.italic || b.italic,
+		bold:    a.bold || b.bold,
+		quote:   a.quote || b.quote,
+		align:   cmp.Or(b.align, a.align),
+		centred: a.centred || b.centred,
+	}
+}
+
+func (m styleMapper) hints(node *html.Node) styleHints {
+	if !m.config.enabled() || node == nil || node.Type != html.ElementNode {
+		return styleHints{}
+	}
+	var hints styleHints
+	for _, class := range strings.Fields(attr(node, "class")) {
+		hints = mergeStyleHints(hints, m.classes[class])
+	}
+	if style := attr(node, "style"); style != "" {
+		hints = mergeStyleHints(hints, parseDeclarationHints(style))
+	}
+	return hints
+}
+
+// emphasisMarker returns the Markdown emphasis to wrap an inline element in,
+// honouring both semantic tags and CSS classes when emphasis mapping is on.
+func (m styleMapper) emphasisMarker(node *html.Node) string {
+	if !m.config.Emphasis || node == nil || node.Type != html.ElementNode {
+		return ""
+	}
+	hints := m.hints(node)
+	switch node.Data {
+	case "em", "i", "cite":
+		hints.italic = true
+	case "strong", "b":
+		hints.bold = true
+	}
+	switch {
+	case hints.italic && hints.bold:
+		return "***"
+	case hints.bold:
+		return "**"
+	case hints.italic:
+		return "*"
+	default:
+		return ""
+	}
+}
+
+func (m styleMapper) isQuoteBlock(node *html.Node) bool {
+	return m.config.Blockquotes && m.hints(node).quote
+}
+
+// alignment returns the alignment an element gives the paragraphs inside
+// it: its own text-align, or centred when it holds nothing but an image
+// with auto side margins.
+func (m styleMapper) alignment(node *html.Node) TextAlign {
+	if !m.config.Alignment || node == nil || node.Type != html.ElementNode {
+		return AlignDefault
+	}
+	if align := m.hints(node).align; align != AlignDefault {
+		return align
+	}
+	if image := soleImage(node); image != nil && m.hints(image).centred {
+		return AlignCenter
+	}
+	return AlignDefault
+}
+
+// soleImage returns the image an element consists of, if it has no text.
+func soleImage(node *html.Node) *html.Node {
+	if strings.TrimSpace(nodeText(node)) != "" {
+		return nil
+	}
+	if image := findElement(node, "img"); image != nil {
+		return image
+	}
+	return findElement(node, "svg")
+}
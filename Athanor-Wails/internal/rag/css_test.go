@@ -0,0 +1,127 @@
+package rag
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseCSSClassHints(t *testing.T) {
+	css := `
+/* calibre output */
+.calibre3 { font-style: italic }
+p.quote, div.sidebar { margin-left: 2em; }
+.boxed { border: 1px solid #000 }
+.flat { border: 0 }
+span.heavy { font-weight: 700 !important; }
+.nested .inner { font-style: italic }
+p:first-letter { font-weight: bold }
+@media print { .print { font-weight: bold } }
+.centre { text-align: center }
+p.signature { text-align: right }
+img.figure { display: block; margin: 0 auto 1em }
+.pushed { margin-left: auto; margin-right: auto }
+.left { margin: 0 auto 0 1em }
+`
+	hints := parseCSSClassHints(css)
+
+	if !hints["calibre3"].italic {
+		t.Fatalf("expected calibre3 italic, got %+v", hints["calibre3"])
+	}
+	if !hints["quote"].quote || !hints["sidebar"].quote {
+		t.Fatalf("expected indented blocks to map to quotes, got %+v", hints)
+	}
+	if !hints["boxed"].quote {
+		t.Fatalf("expected visible border to map to quote, got %+v", hints["boxed"])
+	}
+	if hints["flat"].quote {
+		t.Fatalf("zero border should not map to quote, got %+v", hints["flat"])
+	}
+	if !hints["heavy"].bold {
+		t.Fatalf("expected numeric weight to map to bold, got %+v", hints["heavy"])
+	}
+	if _, ok := hints["inner"]; ok {
+		t.Fatal("descendant selectors should be ignored")
+	}
+	if hints["centre"].align != AlignCenter || hints["signature"].align != AlignRight {
+		t.Fatalf("expected text-align to map, got %+v and %+v", hints["centre"], hints["signature"])
+	}
+	if !hints["figure"].centred || !hints["pushed"].centred || hints["left"].centred {
+		t.Fatalf("expected only auto side margins to centre, got %+v", hints)
+	}
+}
+
+func TestChapterBuilderCarriesAlignment(t *testing.T) {
+	body := parseBodyFromHTML(t, `
+		<p class="centre">The End</p>
+		<p>plain text that follows</p>
+		<div class="centre"><p>centred by its div</p></div>
+		<p><img class="figure" src="a.png" alt="Map"/></p>
+		<p style="text-align: right">Signed</p>`)
+
+	builder := newChapterBuilder("one.xhtml", 1, "", nil, noteRegistry{})
+	builder.styles = styleMapper{
+		config: StyleConfig{Alignment: true, Images: ImageModeRelative},
+		classes: map[string]styleHints{
+			"centre": {align: AlignCenter},
+			"figure": {centred: true},
+		},
+	}
+	builder.consumeNode(body)
+	chapter := builder.build()
+
+	var aligns []TextAlign
+	for _, block := range chapter.Blocks {
+		aligns = append(aligns, block.Align)
+	}
+	expected := []TextAlign{AlignCenter, AlignDefault, AlignCenter, AlignCenter, AlignRight}
+	if !slices.Equal(aligns, expected) {
+		t.Fatalf("expected alignments %v, got %v in %+v", expected, aligns, chapter.Blocks)
+	}
+	if html := renderHTMLBlock(chapter.Blocks[0]); html != "<p class=\"align-center\">The End</p>\n" {
+		t.Fatalf("unexpected html for a centred paragraph: %q", html)
+	}
+}
+
+func TestChapterBuilderAppliesStyleMapping(t *testing.T) {
+	body := parseBodyFromHTML(t, `
+		<h1 class="calibre3">Title</h1>
+		<p>Plain <span class="calibre3">styled</span> and <strong>strong</strong> text.</p>
+		<div class="sidebar"><p>Boxed aside text.</p></div>`)
+
+	styles := styleMapper{
+		config: StyleConfig{Emphasis: true, Blockquotes: true},
+		classes: map[string]styleHints{
+			"calibre3": {italic: true},
+			"sidebar":  {quote: true},
+		},
+	}
+	builder := newChapterBuilder("one.xhtml", 1, "", nil, noteRegistry{})
+	builder.styles = styles
+	builder.consumeNode(body)
+	chapter := builder.build()
+
+	if len(chapter.Blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %+v", chapter.Blocks)
+	}
+	if chapter.Blocks[0].Text != "Title" {
+		t.Fatalf("headings should not be wrapped in emphasis, got %q", chapter.Blocks[0].Text)
+	}
+	if chapter.Blocks[1].Text != "Plain *styled* and **strong** text." {
+		t.Fatalf("unexpected emphasis mapping: %q", chapter.Blocks[1].Text)
+	}
+	if chapter.Blocks[2].Kind != BlockKindBlockquote || chapter.Blocks[2].Text != "Boxed aside text." {
+		t.Fatalf("expected sidebar to become blockquote, got %+v", chapter.Blocks[2])
+	}
+}
+
+func TestChapterBuilderIgnoresStylesWhenDisabled(t *testing.T) {
+	body := parseBodyFromHTML(t, `<p>Plain <em>styled</em> text.</p>`)
+
+	builder := newChapterBuilder("one.xhtml", 1, "", nil, noteRegistry{})
+	builder.consumeNode(body)
+	chapter := builder.build()
+
+	if len(chapter.Blocks) != 1 || chapter.Blocks[0].Text != "Plain styled text." {
+		t.Fatalf("expected unstyled text by default, got %+v", chapter.Blocks)
+	}
+}
@@ -5,7 +5,8 @@ import (
 	"time"
 )
 
-const pipelineVersion = "v0.4"
+// PipelineVersion is recorded in diagnostics.json and provenance footers.
+const PipelineVersion = "v0.4"
 
 func BuildDiagnostics(book Book, chunks []Chunk, config ChunkConfig) Diagnostics {
 	config = normalizeChunkConfig(config)
@@ -106,6 +107,7 @@ func BuildDiagnostics(book Book, chunks []Chunk, config ChunkConfig) Diagnostics
 			Kind:                     chapter.Kind,
 			ClassifyReason:           chapter.ClassifyReason,
 			SourceRef:                chapter.SourceRef,
+			Language:                 chapter.Language,
 			BlockCount:               len(chapter.Blocks),
 			FootnoteCount:            len(chapter.Footnotes),
 			ChunkCount:               chunkCounts[chapter.ID],
@@ -129,7 +131,7 @@ func BuildDiagnostics(book Book, chunks []Chunk, config ChunkConfig) Diagnostics
 
 	return Diagnostics{
 		Summary: DiagnosticsSummary{
-			PipelineVersion:          pipelineVersion,
+			PipelineVersion:          PipelineVersion,
 			GeneratedAt:              time.Now().UTC().Format(time.RFC3339),
 			SourcePath:               book.Metadata.SourcePath,
 			SourceSHA256:             book.Metadata.SourceSHA256,
@@ -148,9 +150,12 @@ func BuildDiagnostics(book Book, chunks []Chunk, config ChunkConfig) Diagnostics
 			P50ChunkCharacters:       p50ChunkChars,
 			P90ChunkCharacters:       p90ChunkChars,
 			MaxChunkCharacters:       maxChunkChars,
+			Warnings:                 append([]string(nil), book.warnings...),
 		},
-		Chapters: chapters,
-		Chunks:   chunkDiagnostics,
+		Chapters:   chapters,
+		Chunks:     chunkDiagnostics,
+		Duplicates: append([]DuplicateChapter(nil), book.duplicates...),
+		Encrypted:  append([]EncryptedResource(nil), book.encrypted...),
 	}
 }
 
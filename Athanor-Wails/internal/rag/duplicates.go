@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const minDuplicateChapterRunes = 200
+
+// DetectDuplicateChapters finds chapters whose body text repeats an earlier
+// chapter verbatim, a common artifact of EPUBs that list the same document
+// twice or ship a split and an unsplit copy. Headings are ignored so a
+// retitled copy is still caught. When drop is set the later copies are
+// removed from the book.
+func DetectDuplicateChapters(book *Book, drop bool) []DuplicateChapter {
+	if book == nil {
+		return nil
+	}
+
+	var duplicates []DuplicateChapter
+	seen := map[string]Chapter{}
+	filter := func(chapters []Chapter) []Chapter {
+		out := make([]Chapter, 0, len(chapters))
+		for _, chapter := range chapters {
+			fingerprint, ok := chapterFingerprint(chapter)
+			if !ok {
+				out = append(out, chapter)
+				continue
+			}
+			original, exists := seen[fingerprint]
+			if !exists {
+				seen[fingerprint] = chapter
+				out = append(out, chapter)
+				continue
+			}
+			duplicates = append(duplicates, DuplicateChapter{
+				ID:          chapter.ID,
+				Title:       chapter.Title,
+				SourceRef:   chapter.SourceRef,
+				DuplicateOf: original.ID,
+				Dropped:     drop,
+			})
+			if drop {
+				continue
+			}
+			chapter.warnings = append(chapter.warnings, "duplicate_of:"+original.ID)
+			out = append(out, chapter)
+		}
+		return out
+	}
+
+	book.Main = filter(book.Main)
+	book.Back = filter(book.Back)
+	book.duplicates = append(book.duplicates, duplicates...)
+	if drop && len(duplicates) > 0 {
+		recomputeStats(book)
+	}
+	return duplicates
+}
+
+func chapterFingerprint(chapter Chapter) (string, bool) {
+	var parts []string
+	size := 0
+	for _, block := range chapter.Blocks {
+		if block.Kind == BlockKindHeading || block.Kind == BlockKindSeparator {
+			continue
+		}
+		text := chunkText(block)
+		if text == "" {
+			continue
+		}
+		size += len([]rune(text))
+		parts = append(parts, text)
+	}
+	if size < minDuplicateChapterRunes {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:]), true
+}
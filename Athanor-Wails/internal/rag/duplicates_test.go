@@ -0,0 +1,45 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectDuplicateChapters(t *testing.T) {
+	body := []Block{{Kind: BlockKindParagraph, Text: strings.Repeat("Same body text. ", 20)}}
+	newBook := func() Book {
+		return Book{
+			Main: []Chapter{
+				{ID: "chapter-001", Title: "One", Kind: ChapterKindMain, Blocks: append([]Block{{Kind: BlockKindHeading, Text: "One", Level: 1}}, body...)},
+				{ID: "chapter-002", Title: "Two", Kind: ChapterKindMain, Blocks: []Block{{Kind: BlockKindParagraph, Text: strings.Repeat("Different text. ", 20)}}},
+				{ID: "chapter-003", Title: "One (copy)", Kind: ChapterKindMain, Blocks: append([]Block{{Kind: BlockKindHeading, Text: "One (copy)", Level: 1}}, body...)},
+				{ID: "chapter-004", Title: "Short", Kind: ChapterKindMain, Blocks: []Block{{Kind: BlockKindParagraph, Text: "tiny"}}},
+				{ID: "chapter-005", Title: "Short", Kind: ChapterKindMain, Blocks: []Block{{Kind: BlockKindParagraph, Text: "tiny"}}},
+			},
+		}
+	}
+
+	book := newBook()
+	duplicates := DetectDuplicateChapters(&book, false)
+	if len(duplicates) != 1 || duplicates[0].ID != "chapter-003" || duplicates[0].DuplicateOf != "chapter-001" {
+		t.Fatalf("unexpected duplicates: %+v", duplicates)
+	}
+	if len(book.Main) != 5 {
+		t.Fatalf("report-only mode should keep chapters, got %d", len(book.Main))
+	}
+	if len(book.Main[2].warnings) != 1 || book.Main[2].warnings[0] != "duplicate_of:chapter-001" {
+		t.Fatalf("expected duplicate warning, got %v", book.Main[2].warnings)
+	}
+
+	book = newBook()
+	duplicates = DetectDuplicateChapters(&book, true)
+	if len(duplicates) != 1 || !duplicates[0].Dropped {
+		t.Fatalf("expected dropped duplicate, got %+v", duplicates)
+	}
+	if len(book.Main) != 4 || book.Stats.ChapterCount != 4 {
+		t.Fatalf("expected duplicate removed, got %d chapters (stats %d)", len(book.Main), book.Stats.ChapterCount)
+	}
+	if len(book.duplicates) != 1 {
+		t.Fatalf("expected duplicates recorded on book, got %+v", book.duplicates)
+	}
+}
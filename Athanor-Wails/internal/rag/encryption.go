@@ -0,0 +1,73 @@
+package rag
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	encryptionIDPFFontObfuscation  = "http://www.idpf.org/2008/embedding"
+	encryptionAdobeFontObfuscation = "http://ns.adobe.com/pdf/enc#RC"
+)
+
+type encryptionXML struct {
+	Data []struct {
+		Method struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"EncryptionMethod"`
+		Reference struct {
+			URI string `xml:"URI,attr"`
+		} `xml:"CipherData>CipherReference"`
+	} `xml:"EncryptedData"`
+}
+
+// readEncryptedResources lists every resource declared in
+// META-INF/encryption.xml. Font obfuscation only affects embedded fonts, but
+// any other algorithm means DRM, and DRM-protected content documents cannot
+// be converted at all, so those are also surfaced as book warnings.
+func readEncryptedResources(entries map[string]zipEntry) ([]EncryptedResource, []string) {
+	entry, ok := entries["META-INF/encryption.xml"]
+	if !ok {
+		return nil, nil
+	}
+	var doc encryptionXML
+	if err := decodeXML(entry.data, &doc); err != nil {
+		return nil, []string{"encryption:unreadable"}
+	}
+
+	var resources []EncryptedResource
+	var warnings []string
+	for _, data := range doc.Data {
+		uri := strings.TrimSpace(data.Reference.URI)
+		if uri == "" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(uri); err == nil {
+			uri = unescaped
+		}
+		resourcePath := path.Clean(strings.TrimPrefix(uri, "/"))
+		algorithm := strings.TrimSpace(data.Method.Algorithm)
+		kind := encryptionKind(algorithm)
+		resources = append(resources, EncryptedResource{
+			Path:      resourcePath,
+			Algorithm: algorithm,
+			Kind:      kind,
+		})
+		if kind == "drm" && isXHTMLItem("", resourcePath) {
+			warnings = append(warnings, "encryption:drm_content:"+resourcePath)
+		}
+	}
+	return resources, warnings
+}
+
+func encryptionKind(algorithm string) string {
+	switch algorithm {
+	case encryptionIDPFFontObfuscation:
+		return "font-obfuscation-idpf"
+	case encryptionAdobeFontObfuscation:
+		return "font-obfuscation-adobe"
+	default:
+		return "drm"
+	}
+}
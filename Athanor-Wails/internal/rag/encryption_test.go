@@ -0,0 +1,47 @@
+package rag
+
+import "testing"
+
+func TestReadEncryptedResources(t *testing.T) {
+	entries := map[string]zipEntry{
+		"META-INF/encryption.xml": {name: "META-INF/encryption.xml", data: []byte(`<?xml version="1.0"?>
+<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#">
+  <enc:EncryptedData>
+    <enc:EncryptionMethod Algorithm="http://www.idpf.org/2008/embedding"/>
+    <enc:CipherData><enc:CipherReference URI="OEBPS/fonts/My%20Font.otf"/></enc:CipherData>
+  </enc:EncryptedData>
+  <enc:EncryptedData>
+    <enc:EncryptionMethod Algorithm="http://ns.adobe.com/pdf/enc#RC"/>
+    <enc:CipherData><enc:CipherReference URI="OEBPS/fonts/serif.ttf"/></enc:CipherData>
+  </enc:EncryptedData>
+  <enc:EncryptedData>
+    <enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+    <enc:CipherData><enc:CipherReference URI="OEBPS/chap1.xhtml"/></enc:CipherData>
+  </enc:EncryptedData>
+</encryption>`)},
+	}
+
+	resources, warnings := readEncryptedResources(entries)
+	if len(resources) != 3 {
+		t.Fatalf("expected 3 resources, got %+v", resources)
+	}
+	if resources[0].Path != "OEBPS/fonts/My Font.otf" || resources[0].Kind != "font-obfuscation-idpf" {
+		t.Fatalf("unexpected idpf resource: %+v", resources[0])
+	}
+	if resources[1].Kind != "font-obfuscation-adobe" {
+		t.Fatalf("unexpected adobe resource: %+v", resources[1])
+	}
+	if resources[2].Kind != "drm" || resources[2].Algorithm != "http://www.w3.org/2001/04/xmlenc#aes128-cbc" {
+		t.Fatalf("unexpected drm resource: %+v", resources[2])
+	}
+	if len(warnings) != 1 || warnings[0] != "encryption:drm_content:OEBPS/chap1.xhtml" {
+		t.Fatalf("expected drm content warning, got %v", warnings)
+	}
+}
+
+func TestReadEncryptedResourcesWithoutEncryptionXML(t *testing.T) {
+	resources, warnings := readEncryptedResources(map[string]zipEntry{})
+	if resources != nil || warnings != nil {
+		t.Fatalf("expected nothing, got %+v %v", resources, warnings)
+	}
+}
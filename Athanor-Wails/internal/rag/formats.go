@@ -0,0 +1,66 @@
+package rag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyOutputFormat reads a comma separated format list such as "rag-md" or
+// "md,txt:80" into options. Markdown is always written; "txt" adds a plain
+// text copy, optionally wrapped at the given width, "adoc"/"docbook" add
+// AsciiDoc and DocBook exports, "sql" adds a script that builds a SQLite
+// full-text search database, "docx", "odt" and "rtf" add word processor
+// documents and "html" adds a browser reading mode. The
+// html modes combine: "html:vertical" opens it in vertical (tategaki)
+// writing, "html:readable" in its dyslexia-friendly font and "html:dark" in
+// its dark theme, so "html:readable:dark" sets both.
+func ApplyOutputFormat(options *Options, format string) error {
+	for _, name := range strings.Split(format, ",") {
+		name, arg, _ := strings.Cut(strings.ToLower(strings.TrimSpace(name)), ":")
+		switch name {
+		case "", "md", "rag-md", "markdown":
+		case "txt", "text":
+			options.PlainText = true
+			if arg != "" {
+				width, err := strconv.Atoi(arg)
+				if err != nil || width < 0 {
+					return fmt.Errorf("无效的折行宽度: %s", arg)
+				}
+				options.TextWrap = width
+			}
+		case "adoc", "asciidoc":
+			options.AsciiDoc = true
+		case "docbook":
+			options.DocBook = true
+		case "sql":
+			options.SQLScript = true
+		case "docx":
+			options.DOCX = true
+		case "odt":
+			options.ODT = true
+		case "rtf":
+			options.RTF = true
+		case "html":
+			options.HTML = true
+			if arg == "" {
+				continue
+			}
+			for _, mode := range strings.Split(arg, ":") {
+				switch mode {
+				case "vertical":
+					options.VerticalHTML = true
+				case "readable":
+					options.ReadableHTML = true
+				case "dark":
+					options.DarkHTML = true
+				default:
+					return fmt.Errorf("不支持的 HTML 选项: %s", mode)
+				}
+			}
+		default:
+			return fmt.Errorf("不支持的输出格式: %s", name)
+		}
+	}
+	return nil
+}
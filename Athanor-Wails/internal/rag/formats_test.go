@@ -0,0 +1,33 @@
+package rag
+
+import "testing"
+
+func TestApplyOutputFormat(t *testing.T) {
+	var options Options
+	if err := ApplyOutputFormat(&options, "rag-md"); err != nil || options.PlainText {
+		t.Fatalf("rag-md should only write markdown: %v %+v", err, options)
+	}
+	if err := ApplyOutputFormat(&options, "md, txt:72"); err != nil || !options.PlainText || options.TextWrap != 72 {
+		t.Fatalf("expected wrapped plain text: %v %+v", err, options)
+	}
+	if err := ApplyOutputFormat(&options, "html:vertical"); err != nil || !options.HTML || !options.VerticalHTML {
+		t.Fatalf("expected vertical html reader: %v %+v", err, options)
+	}
+	options = Options{}
+	if err := ApplyOutputFormat(&options, "html:readable:dark"); err != nil || !options.ReadableHTML || !options.DarkHTML {
+		t.Fatalf("expected readable dark html reader: %v %+v", err, options)
+	}
+	options = Options{}
+	if err := ApplyOutputFormat(&options, "adoc,docbook,sql,docx,odt,rtf"); err != nil || !options.AsciiDoc || !options.DocBook || !options.SQLScript || !options.DOCX || !options.ODT || !options.RTF {
+		t.Fatalf("expected document exports: %v %+v", err, options)
+	}
+	if err := ApplyOutputFormat(&options, "html:sideways"); err == nil {
+		t.Fatal("expected unsupported html option error")
+	}
+	if err := ApplyOutputFormat(&options, "txt:wide"); err == nil {
+		t.Fatal("expected invalid wrap width error")
+	}
+	if err := ApplyOutputFormat(&options, "pdf"); err == nil {
+		t.Fatal("expected unsupported format error")
+	}
+}
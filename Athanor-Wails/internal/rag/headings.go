@@ -0,0 +1,79 @@
+package rag
+
+import "sort"
+
+// NormalizeHeadingLevels rewrites heading block levels across the whole book
+// when config asks for it, so the top heading level can be 1 regardless of
+// whether the source starts its chapters at <h1> or <h3>. The mapping is
+// computed book-wide so chapters keep the same relative hierarchy. The zero
+// config keeps the source levels.
+func NormalizeHeadingLevels(book *Book, config HeadingConfig) {
+	if book == nil {
+		return
+	}
+	mapping := headingLevelMapping(collectHeadingLevels(book), config)
+	if len(mapping) == 0 {
+		return
+	}
+	for _, chapters := range [][]Chapter{book.Main, book.Back} {
+		for i := range chapters {
+			for j := range chapters[i].Blocks {
+				block := &chapters[i].Blocks[j]
+				if block.Kind != BlockKindHeading {
+					continue
+				}
+				if level, ok := mapping[block.Level]; ok {
+					block.Level = level
+				}
+			}
+		}
+	}
+}
+
+func collectHeadingLevels(book *Book) []int {
+	seen := map[int]struct{}{}
+	for _, chapters := range [][]Chapter{book.Main, book.Back} {
+		for _, chapter := range chapters {
+			for _, block := range chapter.Blocks {
+				if block.Kind == BlockKindHeading {
+					seen[block.Level] = struct{}{}
+				}
+			}
+		}
+	}
+	levels := make([]int, 0, len(seen))
+	for level := range seen {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+	return levels
+}
+
+func headingLevelMapping(levels []int, config HeadingConfig) map[int]int {
+	if len(config.Map) > 0 {
+		mapping := make(map[int]int, len(config.Map))
+		for from, to := range config.Map {
+			mapping[from] = min(max(to, 1), 6)
+		}
+		return mapping
+	}
+	if len(levels) == 0 {
+		return nil
+	}
+
+	mapping := make(map[int]int, len(levels))
+	switch config.Rule {
+	case HeadingRuleCompact:
+		for index, level := range levels {
+			mapping[level] = index + 1
+		}
+	case HeadingRuleShift:
+		offset := levels[0] - 1
+		for _, level := range levels {
+			mapping[level] = level - offset
+		}
+	default:
+		return nil
+	}
+	return mapping
+}
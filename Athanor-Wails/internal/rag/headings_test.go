@@ -0,0 +1,98 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHeadingLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   HeadingConfig
+		expected []int
+	}{
+		{name: "default keeps source levels", config: HeadingConfig{}, expected: []int{3, 4, 6}},
+		{name: "compact closes gaps", config: HeadingConfig{Rule: HeadingRuleCompact}, expected: []int{1, 2, 3}},
+		{name: "shift keeps gaps", config: HeadingConfig{Rule: HeadingRuleShift}, expected: []int{1, 2, 4}},
+		{name: "explicit map wins", config: HeadingConfig{Map: map[int]int{3: 2, 6: 9}}, expected: []int{2, 4, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := Book{
+				Main: []Chapter{
+					{ID: "chapter-001", Blocks: []Block{
+						{Kind: BlockKindHeading, Text: "One", Level: 3},
+						{Kind: BlockKindHeading, Text: "One.A", Level: 4},
+					}},
+				},
+				Back: []Chapter{
+					{ID: "chapter-002", Blocks: []Block{
+						{Kind: BlockKindParagraph, Text: "Body"},
+						{Kind: BlockKindHeading, Text: "Deep", Level: 6},
+					}},
+				},
+			}
+
+			NormalizeHeadingLevels(&book, tt.config)
+
+			got := []int{book.Main[0].Blocks[0].Level, book.Main[0].Blocks[1].Level, book.Back[0].Blocks[1].Level}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("expected levels %v, got %v", tt.expected, got)
+				}
+			}
+			if book.Back[0].Blocks[0].Level != 0 {
+				t.Fatalf("non-heading blocks should be untouched, got %+v", book.Back[0].Blocks[0])
+			}
+		})
+	}
+}
+
+func TestConvertEPUBKeepsHeadingLevelsByDefault(t *testing.T) {
+	workDir := testOutputDir(t, "heading-levels")
+	input := filepath.Join(workDir, "levels.epub")
+	writeTestEPUB(t, input, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf": `<?xml version="1.0" encoding="UTF-8"?>
+<package version="2.0" xmlns="http://www.idpf.org/2007/opf">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Levels</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="chap1" href="chap1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="chap1"/>
+  </spine>
+</package>`,
+		"OEBPS/chap1.xhtml": `<html><body><h2>Chapter</h2><p>Opening text.</p>
+<h4>Deep Section</h4><p>Body text.</p></body></html>`,
+	})
+
+	chapter := func(options Options) string {
+		t.Helper()
+		options.OutputRootDir = workDir
+		options.BaseName = "levels"
+		result, err := ConvertEPUB(context.Background(), input, options)
+		if err != nil {
+			t.Fatalf("ConvertEPUB failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(result.ArtifactDir, "chapters", "chapter-001.md"))
+		if err != nil {
+			t.Fatalf("read chapter markdown: %v", err)
+		}
+		return string(data)
+	}
+
+	if got := chapter(Options{}); !strings.Contains(got, "#### Deep Section") {
+		t.Fatalf("default conversion should keep source heading levels:\n%s", got)
+	}
+	if got := chapter(Options{Headings: HeadingConfig{Rule: HeadingRuleCompact}}); strings.Contains(got, "#### Deep Section") {
+		t.Fatalf("compact headings should close the level gap:\n%s", got)
+	}
+}
@@ -0,0 +1,231 @@
+package rag
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// imageScheme marks an image reference by its path inside the EPUB. The
+// Markdown and HTML renderers swap it for a link that works from the file
+// they write; every other output drops the image.
+const imageScheme = "epub-image:"
+
+var (
+	imageRefRe   = regexp.MustCompile(`!\[([^\]\n]*)\]\(epub-image:([^)\n]+)\)`)
+	imageStripRe = regexp.MustCompile(` ?!\[[^\]\n]*\]\(epub-image:[^)\n]+\)`)
+)
+
+// imageRefs holds the resolved image targets of one conversion.
+type imageRefs struct {
+	// main maps EPUB paths to targets usable from the main Markdown file.
+	main map[string]string
+	// nested maps EPUB paths to targets usable one directory down inside
+	// the artifact directory, i.e. from chapters/ and html/.
+	nested map[string]string
+	// files maps file names under <artifact>/images to their bytes.
+	files map[string][]byte
+}
+
+// imageMarkup returns a Markdown image for an <img> or an <svg> wrapping an
+// <image>, or "" when images are stripped or the source is not inside the
+// EPUB.
+func (b *chapterBuilder) imageMarkup(node *html.Node) string {
+	if b.styles.config.Images == ImageModeStrip {
+		return ""
+	}
+	src := ""
+	if node.Data == "img" {
+		src = attr(node, "src")
+	} else if image := findElement(node, "image"); image != nil {
+		src = firstNonEmpty(attr(image, "xlink:href"), attr(image, "href"))
+	}
+	src = strings.TrimSpace(src)
+	if src == "" || strings.Contains(src, ":") {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(src); err == nil {
+		src = unescaped
+	}
+	target := resolveHref(path.Dir(b.chapter.SourceRef), src)
+	if target == "" {
+		return ""
+	}
+	alt := strings.NewReplacer("[", "", "]", "").Replace(normalizeInlineText(attr(node, "alt")))
+	return "![" + alt + "](" + imageScheme + target + ")"
+}
+
+// planImages reads the images the book references and decides how each
+// output links to them. Embedded images become data URIs; the other modes
+// copy them to <artifact>/images, written later by writeImages.
+func planImages(book *Book, inputPath string, options Options) error {
+	mode := options.Styles.Images
+	if mode == ImageModeStrip {
+		return nil
+	}
+	var sources []string
+	seen := map[string]bool{}
+	eachImageText(book, func(text string) string {
+		for _, match := range imageRefRe.FindAllStringSubmatch(text, -1) {
+			if !seen[match[2]] {
+				seen[match[2]] = true
+				sources = append(sources, match[2])
+			}
+		}
+		return text
+	})
+	if len(sources) == 0 {
+		return nil
+	}
+
+	reader, entries, err := openEPUBEntries(inputPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	refs := imageRefs{main: map[string]string{}, nested: map[string]string{}, files: map[string][]byte{}}
+	imagesDir := filepath.Join(options.OutputRootDir, options.BaseName, "images")
+	for _, source := range sources {
+		entry, ok := entries[source]
+		if !ok {
+			book.warnings = append(book.warnings, "image:missing:"+source)
+			continue
+		}
+		if mode == ImageModeEmbed {
+			uri := "data:" + imageMediaType(source) + ";base64," + base64.StdEncoding.EncodeToString(entry.data)
+			refs.main[source] = uri
+			refs.nested[source] = uri
+			continue
+		}
+
+		name := sanitizePathComponent(path.Base(source))
+		for n := 1; refs.files[name] != nil; n++ {
+			name = fmt.Sprintf("%d-%s", n, sanitizePathComponent(path.Base(source)))
+		}
+		refs.files[name] = entry.data
+		switch mode {
+		case ImageModeAbsolute:
+			abs, err := filepath.Abs(filepath.Join(imagesDir, name))
+			if err != nil {
+				return fmt.Errorf("解析图片路径失败: %w", err)
+			}
+			refs.main[source] = markdownDestination(filepath.ToSlash(abs))
+			refs.nested[source] = refs.main[source]
+		default:
+			refs.main[source] = markdownDestination(options.BaseName + "/images/" + name)
+			refs.nested[source] = markdownDestination("../images/" + name)
+		}
+	}
+	book.images = refs
+
+	// Drop references to images the EPUB does not contain, and the
+	// paragraphs that held nothing else.
+	eachImageText(book, func(text string) string {
+		return imageStripRe.ReplaceAllStringFunc(text, func(match string) string {
+			if _, ok := refs.main[imageRefRe.FindStringSubmatch(match)[2]]; ok {
+				return match
+			}
+			return ""
+		})
+	})
+	for _, chapters := range []*[]Chapter{&book.Main, &book.Back} {
+		for i := range *chapters {
+			chapter := &(*chapters)[i]
+			kept := chapter.Blocks[:0]
+			for _, block := range chapter.Blocks {
+				block.Text = strings.TrimSpace(block.Text)
+				if block.Kind == BlockKindParagraph && block.Text == "" {
+					continue
+				}
+				kept = append(kept, block)
+			}
+			chapter.Blocks = kept
+		}
+	}
+	return nil
+}
+
+// eachImageText applies fn to every piece of block text that can hold an
+// image reference.
+func eachImageText(book *Book, fn func(string) string) {
+	for _, chapters := range [][]Chapter{book.Main, book.Back} {
+		for i := range chapters {
+			for j := range chapters[i].Blocks {
+				block := &chapters[i].Blocks[j]
+				block.Text = fn(block.Text)
+				for k := range block.Items {
+					block.Items[k] = fn(block.Items[k])
+				}
+				for _, row := range block.Rows {
+					for k := range row {
+						row[k] = fn(row[k])
+					}
+				}
+			}
+		}
+	}
+}
+
+func writeImages(artifactDir string, refs imageRefs) error {
+	if len(refs.files) == 0 {
+		return nil
+	}
+	dir := filepath.Join(artifactDir, "images")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建图片目录失败: %w", err)
+	}
+	for name, data := range refs.files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return fmt.Errorf("写入图片失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveImages rewrites image references with the given targets and drops
+// those without one.
+func resolveImages(text string, targets map[string]string) string {
+	if !strings.Contains(text, imageScheme) {
+		return text
+	}
+	return imageRefRe.ReplaceAllStringFunc(text, func(match string) string {
+		parts := imageRefRe.FindStringSubmatch(match)
+		target, ok := targets[parts[2]]
+		if !ok {
+			return ""
+		}
+		return "![" + parts[1] + "](" + target + ")"
+	})
+}
+
+func stripImages(text string) string {
+	if !strings.Contains(text, imageScheme) {
+		return text
+	}
+	return strings.TrimSpace(imageStripRe.ReplaceAllString(text, ""))
+}
+
+// markdownDestination wraps destinations with spaces in angle brackets, as
+// CommonMark requires.
+func markdownDestination(target string) string {
+	if strings.ContainsAny(target, " ()") {
+		return "<" + target + ">"
+	}
+	return target
+}
+
+func imageMediaType(name string) string {
+	if media := mime.TypeByExtension(strings.ToLower(path.Ext(name))); media != "" {
+		return media
+	}
+	return "application/octet-stream"
+}
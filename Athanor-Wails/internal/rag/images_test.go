@@ -0,0 +1,95 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeImageTestEPUB(t *testing.T, input string) {
+	t.Helper()
+	writeTestEPUB(t, input, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf": `<?xml version="1.0" encoding="UTF-8"?>
+<package version="2.0" xmlns="http://www.idpf.org/2007/opf">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Picture Book</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="chap1" href="Text/chap1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="fig" href="Images/figure 1.png" media-type="image/png"/>
+  </manifest>
+  <spine>
+    <itemref idref="chap1"/>
+  </spine>
+</package>`,
+		"OEBPS/Images/figure 1.png": "png-bytes",
+		"OEBPS/Text/chap1.xhtml": `<html><body><h1>Chapter One</h1>
+<p>Some text before the figure.</p>
+<figure><img src="../Images/figure%201.png" alt="A [small] chart"/><figcaption>Figure 1</figcaption></figure>
+<p>An icon <img src="../Images/missing.png" alt="gone"/> inline.</p></body></html>`,
+	})
+}
+
+func TestImageModes(t *testing.T) {
+	tests := []struct {
+		mode     ImageMode
+		main     string
+		chapter  string
+		imageOut bool
+	}{
+		{ImageModeStrip, "", "", false},
+		{ImageModeRelative, "![A small chart](<pics/images/figure 1.png>)", "![A small chart](<../images/figure 1.png>)", true},
+		{ImageModeEmbed, "![A small chart](data:image/png;base64,cG5nLWJ5dGVz)", "![A small chart](data:image/png;base64,cG5nLWJ5dGVz)", false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+"mode", func(t *testing.T) {
+			workDir := testOutputDir(t, "images-"+string(tt.mode))
+			input := filepath.Join(workDir, "pics.epub")
+			writeImageTestEPUB(t, input)
+
+			result, err := ConvertEPUB(context.Background(), input, Options{
+				OutputRootDir: workDir,
+				BaseName:      "pics",
+				Styles:        StyleConfig{Images: tt.mode},
+			})
+			if err != nil {
+				t.Fatalf("ConvertEPUB failed: %v", err)
+			}
+			mainData, err := os.ReadFile(result.MainMarkdownPath)
+			if err != nil {
+				t.Fatalf("read main markdown: %v", err)
+			}
+			chapterData, err := os.ReadFile(filepath.Join(result.ArtifactDir, "chapters", "chapter-001.md"))
+			if err != nil {
+				t.Fatalf("read chapter markdown: %v", err)
+			}
+			chunkData, err := os.ReadFile(result.ChunksPath)
+			if err != nil {
+				t.Fatalf("read chunks: %v", err)
+			}
+
+			main := string(mainData)
+			if tt.main == "" {
+				if strings.Contains(main, "![") {
+					t.Fatalf("expected images to be stripped:\n%s", main)
+				}
+			} else if !strings.Contains(main, tt.main) || !strings.Contains(string(chapterData), tt.chapter) {
+				t.Fatalf("expected %q and %q, got:\n%s\n%s", tt.main, tt.chapter, main, chapterData)
+			}
+			if strings.Contains(main, "gone") || strings.Contains(main, imageScheme) {
+				t.Fatalf("missing images should be dropped:\n%s", main)
+			}
+			if strings.Contains(string(chunkData), "![") {
+				t.Fatalf("chunks should not carry images: %s", chunkData)
+			}
+			_, err = os.Stat(filepath.Join(result.ArtifactDir, "images", "figure 1.png"))
+			if tt.imageOut != (err == nil) {
+				t.Fatalf("image file written = %v, want %v", err == nil, tt.imageOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package rag
+
+import "regexp"
+
+type inlineKind int
+
+const (
+	inlineText inlineKind = iota
+	inlineEmphasis
+	inlineStrong
+	inlineStrongEmphasis
+	inlineFootnote
+	inlineLink
+	inlineImage
+)
+
+type inlineSpan struct {
+	kind   inlineKind
+	text   string
+	target string
+}
+
+var inlineMarkupRe = regexp.MustCompile(`\*\*\*([^*\n]+?)\*\*\*|\*\*([^*\n]+?)\*\*|\*([^*\n]+?)\*|\[\^([^\]]+)\]|\[([^\]\n]+)\]\(#([^)\s]+)\)|!\[([^\]\n]*)\]\(epub-image:([^)\n]+)\)`)
+
+// parseInline splits block text into the inline Markdown the chapter
+// builder emits (emphasis markers, footnote references, cross-links to
+// heading anchors and image references), so renderers for other markup
+// languages can translate it.
+func parseInline(text string) []inlineSpan {
+	var spans []inlineSpan
+	last := 0
+	for _, match := range inlineMarkupRe.FindAllStringSubmatchIndex(text, -1) {
+		if match[0] > last {
+			spans = append(spans, inlineSpan{kind: inlineText, text: text[last:match[0]]})
+		}
+		switch {
+		case match[2] >= 0:
+			spans = append(spans, inlineSpan{kind: inlineStrongEmphasis, text: text[match[2]:match[3]]})
+		case match[4] >= 0:
+			spans = append(spans, inlineSpan{kind: inlineStrong, text: text[match[4]:match[5]]})
+		case match[6] >= 0:
+			spans = append(spans, inlineSpan{kind: inlineEmphasis, text: text[match[6]:match[7]]})
+		case match[8] >= 0:
+			spans = append(spans, inlineSpan{kind: inlineFootnote, text: text[match[8]:match[9]]})
+		case match[10] >= 0:
+			spans = append(spans, inlineSpan{kind: inlineLink, text: text[match[10]:match[11]], target: text[match[12]:match[13]]})
+		default:
+			spans = append(spans, inlineSpan{kind: inlineImage, text: text[match[14]:match[15]], target: text[match[16]:match[17]]})
+		}
+		last = match[1]
+	}
+	if last < len(text) {
+		spans = append(spans, inlineSpan{kind: inlineText, text: text[last:]})
+	}
+	return spans
+}
+
+func footnoteIndex(chapter Chapter) map[string]string {
+	notes := make(map[string]string, len(chapter.Footnotes))
+	for _, note := range chapter.Footnotes {
+		notes[note.Label] = note.Content
+	}
+	return notes
+}
@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Inspection summarizes a book without writing any output, to help plan a
+// bulk conversion.
+type Inspection struct {
+	Path        string              `json:"path"`
+	Size        int64               `json:"size"`
+	Title       string              `json:"title"`
+	Authors     []string            `json:"authors,omitempty"`
+	Language    string              `json:"language,omitempty"`
+	Chapters    int                 `json:"chapters"`
+	FrontBack   int                 `json:"frontBackMatter"`
+	Footnotes   int                 `json:"footnotes"`
+	Characters  int                 `json:"characters"`
+	Images      int                 `json:"images"`
+	DRM         bool                `json:"drm"`
+	Encrypted   []EncryptedResource `json:"encryptedResources,omitempty"`
+	Warnings    []string            `json:"warnings,omitempty"`
+	ParseMillis int64               `json:"parseMillis"`
+}
+
+// InspectEPUB parses and normalizes a book the way ConvertEPUB does and
+// reports what it found. ParseMillis is the time this took, which is most
+// of what a conversion of the same book costs.
+func InspectEPUB(ctx context.Context, inputPath string) (Inspection, error) {
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return Inspection{}, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	started := time.Now()
+	book, err := ParseEPUB(ctx, inputPath)
+	if err != nil {
+		return Inspection{}, err
+	}
+	NormalizeBook(&book)
+	parsed := time.Since(started)
+
+	reader, entries, err := openEPUBEntries(inputPath)
+	if err != nil {
+		return Inspection{}, err
+	}
+	reader.Close()
+
+	report := Inspection{
+		Path:        inputPath,
+		Size:        info.Size(),
+		Title:       book.Metadata.Title,
+		Authors:     book.Metadata.Authors,
+		Language:    book.Metadata.Language,
+		Chapters:    book.Stats.ChapterCount,
+		FrontBack:   book.Stats.FrontMatterCount + book.Stats.BackMatterCount,
+		Footnotes:   book.Stats.FootnoteCount,
+		Encrypted:   book.encrypted,
+		Warnings:    book.warnings,
+		ParseMillis: parsed.Milliseconds(),
+	}
+	for name := range entries {
+		if strings.HasPrefix(imageMediaType(name), "image/") {
+			report.Images++
+		}
+	}
+	for _, resource := range book.encrypted {
+		if resource.Kind == "drm" {
+			report.DRM = true
+		}
+	}
+	eachImageText(&book, func(text string) string {
+		report.Characters += utf8.RuneCountInString(plainInline(text))
+		return text
+	})
+	return report, nil
+}
@@ -0,0 +1,24 @@
+package rag
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestInspectEPUB(t *testing.T) {
+	workDir := testOutputDir(t, "inspect")
+	input := filepath.Join(workDir, "pics.epub")
+	writeImageTestEPUB(t, input)
+
+	report, err := InspectEPUB(context.Background(), input)
+	if err != nil {
+		t.Fatalf("InspectEPUB failed: %v", err)
+	}
+	if report.Title != "Picture Book" || report.Language != "en" || report.Chapters != 1 {
+		t.Fatalf("unexpected book summary: %+v", report)
+	}
+	if report.Images != 1 || report.DRM || report.Size == 0 || report.Characters == 0 {
+		t.Fatalf("unexpected content summary: %+v", report)
+	}
+}
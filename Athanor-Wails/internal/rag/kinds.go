@@ -8,6 +8,104 @@ const (
 	ChapterKindBackMatter  ChapterKind = "backmatter"
 )
 
+type CoverMode string
+
+const (
+	CoverModeAuto    CoverMode = ""
+	CoverModeExtract CoverMode = "extract"
+	CoverModeSkip    CoverMode = "skip"
+)
+
+type HeadingRule string
+
+const (
+	HeadingRuleKeep    HeadingRule = ""
+	HeadingRuleCompact HeadingRule = "compact"
+	HeadingRuleShift   HeadingRule = "shift"
+)
+
+type MathStyle string
+
+const (
+	MathStyleDollar MathStyle = ""
+	MathStyleLaTeX  MathStyle = "latex"
+	MathStyleFenced MathStyle = "fenced"
+)
+
+type ImageMode string
+
+const (
+	ImageModeStrip    ImageMode = ""
+	ImageModeRelative ImageMode = "relative"
+	ImageModeAbsolute ImageMode = "absolute"
+	ImageModeEmbed    ImageMode = "embed"
+)
+
+type WrapMode string
+
+const (
+	WrapNone WrapMode = ""
+	WrapAuto WrapMode = "auto"
+)
+
+type TextDirection string
+
+const (
+	DirectionAuto TextDirection = ""
+	DirectionLTR  TextDirection = "ltr"
+	DirectionRTL  TextDirection = "rtl"
+)
+
+type ChineseConversion string
+
+const (
+	ChineseConversionNone ChineseConversion = ""
+	ChineseToTraditional  ChineseConversion = "s2t"
+	ChineseToSimplified   ChineseConversion = "t2s"
+)
+
+// WarningPolicy decides what non-fatal problems such as spine errors, DRM
+// content or missing images do to a conversion.
+type WarningPolicy string
+
+const (
+	WarningsReport WarningPolicy = ""
+	WarningsFail   WarningPolicy = "fail"
+	WarningsIgnore WarningPolicy = "ignore"
+)
+
+// NotePlacement decides where the main Markdown puts footnotes: after each
+// chapter (the default) or in one notes section at the end of the book.
+type NotePlacement string
+
+const (
+	NotesChapter NotePlacement = ""
+	NotesBook    NotePlacement = "book"
+)
+
+// LabelLanguage picks the language of the headings and navigation text
+// Athanor adds to the outputs itself. The default keeps the labels of
+// earlier versions and LabelsAuto follows the book's language.
+type LabelLanguage string
+
+const (
+	LabelsDefault LabelLanguage = ""
+	LabelsChinese LabelLanguage = "zh"
+	LabelsEnglish LabelLanguage = "en"
+	LabelsAuto    LabelLanguage = "auto"
+)
+
+// TextAlign is the alignment a paragraph keeps from the book's CSS. Only
+// centred and right-aligned text is carried over; everything else uses the
+// output's default.
+type TextAlign string
+
+const (
+	AlignDefault TextAlign = ""
+	AlignCenter  TextAlign = "center"
+	AlignRight   TextAlign = "right"
+)
+
 type BlockKind string
 
 const (
@@ -18,4 +116,5 @@ const (
 	BlockKindList       BlockKind = "list"
 	BlockKindTable      BlockKind = "table"
 	BlockKindSeparator  BlockKind = "separator"
+	BlockKindCallout    BlockKind = "callout"
 )
@@ -0,0 +1,107 @@
+package rag
+
+// artifactLabels is the text Athanor adds to the outputs on its own, as
+// opposed to text taken from the book.
+type artifactLabels struct {
+	Footnotes string
+	// ChunkFootnotes heads the footnotes attached to a chunk.
+	ChunkFootnotes string
+	UntitledBook   string
+	Cover          string
+	// Series and SeriesVolume are format strings taking the series name and,
+	// for SeriesVolume, its index.
+	Series       string
+	SeriesVolume string
+	Contents     string
+	Previous     string
+	Next         string
+	// The rest are read out by screen readers for the HTML reader's
+	// navigation, controls and footnote links.
+	Navigation string
+	Smaller    string
+	Larger     string
+	Writing    string
+	Font       string
+	Theme      string
+	BackToText string
+}
+
+// artifactLabelSets holds the label set for each LabelLanguage. The default
+// set keeps the labels of earlier versions, which mix Chinese document
+// headings with English chunk headings.
+var artifactLabelSets = map[LabelLanguage]artifactLabels{
+	LabelsDefault: {
+		Footnotes:      "脚注",
+		ChunkFootnotes: "Footnotes",
+		UntitledBook:   "未命名图书",
+		Cover:          "封面",
+		Series:         "系列：%s",
+		SeriesVolume:   "系列：%s（第 %s 卷）",
+		Contents:       "目录",
+		Previous:       "← 上一章",
+		Next:           "下一章 →",
+		Navigation:     "章节导航",
+		Smaller:        "缩小字号",
+		Larger:         "放大字号",
+		Writing:        "切换竖排",
+		Font:           "切换易读字体",
+		Theme:          "切换明暗主题",
+		BackToText:     "返回正文",
+	},
+	LabelsChinese: {
+		Footnotes:      "脚注",
+		ChunkFootnotes: "脚注",
+		UntitledBook:   "未命名图书",
+		Cover:          "封面",
+		Series:         "系列：%s",
+		SeriesVolume:   "系列：%s（第 %s 卷）",
+		Contents:       "目录",
+		Previous:       "← 上一章",
+		Next:           "下一章 →",
+		Navigation:     "章节导航",
+		Smaller:        "缩小字号",
+		Larger:         "放大字号",
+		Writing:        "切换竖排",
+		Font:           "切换易读字体",
+		Theme:          "切换明暗主题",
+		BackToText:     "返回正文",
+	},
+	LabelsEnglish: {
+		Footnotes:      "Footnotes",
+		ChunkFootnotes: "Footnotes",
+		UntitledBook:   "Untitled Book",
+		Cover:          "Cover",
+		Series:         "Series: %s",
+		SeriesVolume:   "Series: %s, volume %s",
+		Contents:       "Contents",
+		Previous:       "← Previous",
+		Next:           "Next →",
+		Navigation:     "Chapter navigation",
+		Smaller:        "Smaller text",
+		Larger:         "Larger text",
+		Writing:        "Toggle vertical writing",
+		Font:           "Toggle readable font",
+		Theme:          "Toggle dark theme",
+		BackToText:     "Back to text",
+	},
+}
+
+// resolveLabelLanguage turns LabelsAuto into Chinese labels for Chinese
+// books and English labels for everything else.
+func resolveLabelLanguage(labels LabelLanguage, bookLanguage string) LabelLanguage {
+	if labels != LabelsAuto {
+		return labels
+	}
+	if languageScript(bookLanguage) == "han" {
+		return LabelsChinese
+	}
+	return LabelsEnglish
+}
+
+// text returns the label set the book's outputs are written with.
+func (book Book) text() artifactLabels {
+	if labels, ok := artifactLabelSets[book.labels]; ok {
+		return labels
+	}
+	return artifactLabelSets[LabelsDefault]
+}
@@ -0,0 +1,54 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveLabelLanguage(t *testing.T) {
+	tests := []struct {
+		labels   LabelLanguage
+		language string
+		want     LabelLanguage
+	}{
+		{LabelsDefault, "en", LabelsDefault},
+		{LabelsEnglish, "zh-CN", LabelsEnglish},
+		{LabelsAuto, "zh-Hant", LabelsChinese},
+		{LabelsAuto, "en-US", LabelsEnglish},
+		{LabelsAuto, "ja", LabelsEnglish},
+		{LabelsAuto, "", LabelsEnglish},
+	}
+	for _, tt := range tests {
+		if got := resolveLabelLanguage(tt.labels, tt.language); got != tt.want {
+			t.Fatalf("resolveLabelLanguage(%q, %q) = %q, want %q", tt.labels, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestRenderBookLabels(t *testing.T) {
+	book := docExportTestBook()
+	book.Metadata.Title = ""
+	book.labels = LabelsEnglish
+
+	markdown := RenderBookMarkdown(book)
+	for _, want := range []string{"# Untitled Book", "## Footnotes"} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("expected %q in markdown:\n%s", want, markdown)
+		}
+	}
+	page := RenderBookHTML(book)["chapter-001.html"]
+	for _, want := range []string{"← Previous", ">Contents<", "Next →"} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected %q in html:\n%s", want, page)
+		}
+	}
+	if strings.Contains(markdown+page, "脚注") || strings.Contains(page, "上一章") {
+		t.Fatalf("english labels should not leave Chinese text:\n%s\n%s", markdown, page)
+	}
+
+	book.labels = LabelsChinese
+	chunks := BuildChunks(book, ChunkConfig{})
+	if len(chunks) == 0 || !strings.Contains(chunks[0].Text, "## 脚注\n") {
+		t.Fatalf("expected Chinese footnote heading in chunks: %#v", chunks)
+	}
+}
@@ -0,0 +1,219 @@
+package rag
+
+import (
+	"strings"
+	"unicode"
+)
+
+const minLanguageSampleRunes = 20
+
+// Common characters that only occur in one of the two Chinese orthographies,
+// paired by position. They tell zh-Hans from zh-Hant without a dictionary.
+const (
+	simplifiedOnly  = "这说国时们来对个会为发长开学经过还没么见问东车门书话让听现无与体实当应样"
+	traditionalOnly = "這說國時們來對個會為發長開學經過還沒麼見問東車門書話讓聽現無與體實當應樣"
+)
+
+var simplifiedRunes, traditionalRunes = runeSet(simplifiedOnly), runeSet(traditionalOnly)
+
+type scriptCounts struct {
+	han         int
+	kana        int
+	hangul      int
+	latin       int
+	cyrillic    int
+	arabic      int
+	hebrew      int
+	simplified  int
+	traditional int
+}
+
+// assignChapterLanguages tags every chapter with its language. Books whose
+// OPF declares no language get the dominant language of their text instead.
+func assignChapterLanguages(book *Book) {
+	if book == nil {
+		return
+	}
+	if language := strings.ToLower(strings.TrimSpace(book.Metadata.Language)); language == "" || language == "und" {
+		book.Metadata.Language = detectBookLanguage(*book)
+	}
+	for i := range book.Main {
+		book.Main[i].Language = detectChapterLanguage(book.Main[i], book.Metadata.Language)
+	}
+	for i := range book.Back {
+		book.Back[i].Language = detectChapterLanguage(book.Back[i], book.Metadata.Language)
+	}
+}
+
+// detectChapterLanguage keeps the OPF language unless the chapter is clearly
+// written in a different script, so "zh-CN" books keep their region tag while
+// a Latin-script appendix in the same book is reported as "und-Latn".
+func detectChapterLanguage(chapter Chapter, fallback string) string {
+	counts := countChapterScripts(chapter)
+	detected := counts.dominantLanguage()
+	if detected == "" {
+		return fallback
+	}
+	if languageScript(fallback) == languageScript(detected) {
+		return fallback
+	}
+	return detected
+}
+
+func detectBookLanguage(book Book) string {
+	var counts scriptCounts
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		counts.merge(countChapterScripts(chapter))
+	}
+	return counts.dominantLanguage()
+}
+
+func countChapterScripts(chapter Chapter) scriptCounts {
+	var counts scriptCounts
+	for _, block := range chapter.Blocks {
+		switch block.Kind {
+		case BlockKindHeading, BlockKindParagraph, BlockKindBlockquote, BlockKindCallout:
+			counts.add(block.Text)
+		case BlockKindList:
+			for _, item := range block.Items {
+				counts.add(item)
+			}
+		case BlockKindTable:
+			for _, row := range block.Rows {
+				for _, cell := range row {
+					counts.add(cell)
+				}
+			}
+		}
+	}
+	return counts
+}
+
+func (c *scriptCounts) add(text string) {
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			c.kana++
+		case unicode.Is(unicode.Hangul, r):
+			c.hangul++
+		case unicode.Is(unicode.Han, r):
+			c.han++
+			if simplifiedRunes[r] {
+				c.simplified++
+			} else if traditionalRunes[r] {
+				c.traditional++
+			}
+		case unicode.Is(unicode.Latin, r):
+			c.latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			c.cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			c.arabic++
+		case unicode.Is(unicode.Hebrew, r):
+			c.hebrew++
+		}
+	}
+}
+
+func (c *scriptCounts) merge(other scriptCounts) {
+	c.han += other.han
+	c.kana += other.kana
+	c.hangul += other.hangul
+	c.latin += other.latin
+	c.cyrillic += other.cyrillic
+	c.arabic += other.arabic
+	c.hebrew += other.hebrew
+	c.simplified += other.simplified
+	c.traditional += other.traditional
+}
+
+func (c scriptCounts) dominantLanguage() string {
+	cjk := c.han + c.kana + c.hangul
+	alphabetic := c.latin + c.cyrillic + c.arabic + c.hebrew
+	if cjk+alphabetic < minLanguageSampleRunes {
+		return ""
+	}
+	if cjk*2 < alphabetic {
+		// The script alone does not tell English from French or German, so
+		// Latin text is only tagged with its script.
+		language, most := "und-Latn", c.latin
+		for _, script := range []struct {
+			language string
+			count    int
+		}{{"ru", c.cyrillic}, {"ar", c.arabic}, {"he", c.hebrew}} {
+			if script.count > most {
+				language, most = script.language, script.count
+			}
+		}
+		return language
+	}
+	switch {
+	case c.hangul > c.han+c.kana:
+		return "ko"
+	case c.kana > 0 && c.kana*10 >= c.han:
+		return "ja"
+	case c.simplified > c.traditional:
+		return "zh-Hans"
+	case c.traditional > c.simplified:
+		return "zh-Hant"
+	default:
+		return "zh"
+	}
+}
+
+func languageScript(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	switch {
+	case language == "":
+		return ""
+	case strings.HasPrefix(language, "zh"):
+		return "han"
+	case strings.HasPrefix(language, "ja"):
+		return "ja"
+	case strings.HasPrefix(language, "ko"):
+		return "ko"
+	case cyrillicLanguages[primaryLanguage(language)]:
+		return "cyrillic"
+	case arabicLanguages[primaryLanguage(language)]:
+		return "arabic"
+	case hebrewLanguages[primaryLanguage(language)]:
+		return "hebrew"
+	default:
+		return "latin"
+	}
+}
+
+// rtlLanguage reports whether a language is written right to left.
+func rtlLanguage(language string) bool {
+	script := languageScript(language)
+	return script == "arabic" || script == "hebrew"
+}
+
+func chapterLanguage(chapter Chapter, book Book) string {
+	if chapter.Language != "" {
+		return chapter.Language
+	}
+	return book.Metadata.Language
+}
+
+var cyrillicLanguages = map[string]bool{
+	"ru": true, "uk": true, "be": true, "bg": true, "sr": true, "mk": true, "kk": true, "ky": true, "mn": true, "tg": true,
+}
+
+var arabicLanguages = map[string]bool{
+	"ar": true, "fa": true, "ur": true, "ps": true, "ckb": true, "sd": true, "ug": true,
+}
+
+var hebrewLanguages = map[string]bool{"he": true, "iw": true, "yi": true}
+
+func primaryLanguage(language string) string {
+	return strings.SplitN(language, "-", 2)[0]
+}
+
+func runeSet(s string) map[rune]bool {
+	set := make(map[rune]bool)
+	for _, r := range s {
+		set[r] = true
+	}
+	return set
+}
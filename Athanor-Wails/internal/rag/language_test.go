@@ -0,0 +1,135 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectChapterLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		fallback string
+		expected string
+	}{
+		{
+			name:     "same script keeps opf tag",
+			text:     "这是第一章的正文内容，用来验证语言检测是否保留原有标签。",
+			fallback: "zh-CN",
+			expected: "zh-CN",
+		},
+		{
+			name:     "japanese appendix in english book",
+			text:     "これは日本語の付録です。ひらがなとカタカナが含まれています。",
+			fallback: "en",
+			expected: "ja",
+		},
+		{
+			name:     "english appendix in chinese book",
+			text:     "This appendix is written entirely in English for reference.",
+			fallback: "zh-CN",
+			expected: "und-Latn",
+		},
+		{
+			name:     "korean chapter",
+			text:     "이것은 한국어로 작성된 장입니다. 언어 감지를 확인합니다.",
+			fallback: "en",
+			expected: "ko",
+		},
+		{
+			name:     "traditional chinese chapter in english book",
+			text:     "這是第一章的正文內容，我們來說說這個國家過去的時代。",
+			fallback: "en",
+			expected: "zh-Hant",
+		},
+		{
+			name:     "russian chapter in english book",
+			text:     "Это приложение написано полностью на русском языке.",
+			fallback: "en",
+			expected: "ru",
+		},
+		{
+			name:     "cyrillic chapter keeps ukrainian tag",
+			text:     "Цей розділ написаний українською мовою для перевірки.",
+			fallback: "uk",
+			expected: "uk",
+		},
+		{
+			name:     "arabic chapter in english book",
+			text:     "هذا الفصل مكتوب باللغة العربية للتحقق من اكتشاف اللغة.",
+			fallback: "en",
+			expected: "ar",
+		},
+		{
+			name:     "hebrew chapter keeps israeli tag",
+			text:     "הפרק הזה כתוב בעברית כדי לבדוק את זיהוי השפה.",
+			fallback: "he-IL",
+			expected: "he-IL",
+		},
+		{
+			name:     "short sample falls back",
+			text:     "Hi",
+			fallback: "zh",
+			expected: "zh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chapter := Chapter{Blocks: []Block{{Kind: BlockKindParagraph, Text: tt.text}}}
+			if got := detectChapterLanguage(chapter, tt.fallback); got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAssignChapterLanguagesDetectsMissingBookLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		text     string
+		expected string
+	}{
+		{"", "这是一本没有声明语言的书，我们来看看检测结果是否正确。", "zh-Hans"},
+		{"und", "這是一本沒有聲明語言的書，我們來看看檢測結果是否正確。", "zh-Hant"},
+		{"zh-CN", "這是一本聲明為簡體的書，但正文使用繁體字來書寫內容。", "zh-CN"},
+		{"", "Ce livre ne déclare pas sa langue dans le fichier OPF.", "und-Latn"},
+	}
+	for _, tt := range tests {
+		book := Book{
+			Metadata: Metadata{Language: tt.language},
+			Main:     []Chapter{{ID: "chapter-001", Blocks: []Block{{Kind: BlockKindParagraph, Text: tt.text}}}},
+		}
+		assignChapterLanguages(&book)
+		if book.Metadata.Language != tt.expected || book.Main[0].Language != tt.expected {
+			t.Fatalf("language %q: expected %q, got book %q chapter %q", tt.language, tt.expected, book.Metadata.Language, book.Main[0].Language)
+		}
+	}
+}
+
+func TestBuildChunksUsesChapterLanguage(t *testing.T) {
+	book := Book{
+		Metadata: Metadata{Title: "Book", Language: "en"},
+		Main: []Chapter{
+			{
+				ID:     "chapter-001",
+				Title:  "One",
+				Order:  1,
+				Kind:   ChapterKindMain,
+				Blocks: []Block{{Kind: BlockKindParagraph, Text: strings.Repeat("これは日本語です。", 20)}},
+			},
+		},
+	}
+	NormalizeBook(&book)
+
+	chunks := BuildChunks(book, ChunkConfig{})
+	if len(chunks) != 1 {
+		t.Fatalf("expected one chunk, got %d", len(chunks))
+	}
+	if chunks[0].Language != "ja" {
+		t.Fatalf("expected chapter language on chunk, got %q", chunks[0].Language)
+	}
+	if chunks[0].TokenEstimate != chunks[0].CharacterSize {
+		t.Fatalf("expected cjk token estimate, got %d for %d chars", chunks[0].TokenEstimate, chunks[0].CharacterSize)
+	}
+}
@@ -0,0 +1,174 @@
+package rag
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// crossLinkScheme marks the target of an internal link while the book is
+// still being normalized; ResolveCrossLinks swaps it for a heading anchor.
+const crossLinkScheme = "epub:"
+
+var (
+	crossLinkRe    = regexp.MustCompile(`\[([^\]\n]*)\]\(epub:([^)\n]+)\)`)
+	anchorLinkRe   = regexp.MustCompile(`\[([^\]\n]*)\]\(#[^)\s]+\)`)
+	mdAnchorHrefRe = regexp.MustCompile(`\]\(#([^)\s]+)\)`)
+)
+
+type linkTarget struct {
+	chapter *Chapter
+	block   int
+}
+
+// ResolveCrossLinks turns links between EPUB documents into Markdown links
+// to heading anchors. Only headings that something links to get an anchor;
+// anchors are derived from the heading text and unique across the book, so
+// they stay stable between runs. Links whose target did not survive
+// normalization fall back to their plain text.
+func ResolveCrossLinks(book *Book) {
+	var chapters []*Chapter
+	for _, list := range [][]Chapter{book.Main, book.Back} {
+		for i := range list {
+			chapters = append(chapters, &list[i])
+		}
+	}
+
+	targets := map[string]linkTarget{}
+	used := map[string]bool{}
+	for _, chapter := range chapters {
+		used[chapter.ID] = true
+		if _, ok := targets[chapter.SourceRef]; !ok {
+			targets[chapter.SourceRef] = linkTarget{chapter: chapter, block: -1}
+		}
+		for _, key := range chapter.anchorKeys {
+			targets[key] = linkTarget{chapter: chapter, block: -1}
+		}
+		for index, block := range chapter.Blocks {
+			for _, key := range block.anchorKeys {
+				targets[key] = linkTarget{chapter: chapter, block: index}
+			}
+		}
+	}
+
+	resolve := func(text string) string {
+		if !strings.Contains(text, crossLinkScheme) {
+			return text
+		}
+		return crossLinkRe.ReplaceAllStringFunc(text, func(match string) string {
+			parts := crossLinkRe.FindStringSubmatch(match)
+			target, ok := targets[parts[2]]
+			if !ok || parts[1] == "" {
+				return parts[1]
+			}
+			return "[" + parts[1] + "](#" + target.anchor(used) + ")"
+		})
+	}
+
+	for _, chapter := range chapters {
+		for i := range chapter.Blocks {
+			block := &chapter.Blocks[i]
+			block.Text = resolve(block.Text)
+			for j := range block.Items {
+				block.Items[j] = resolve(block.Items[j])
+			}
+			for _, row := range block.Rows {
+				for j := range row {
+					row[j] = resolve(row[j])
+				}
+			}
+		}
+		for i := range chapter.Footnotes {
+			chapter.Footnotes[i].Content = resolve(chapter.Footnotes[i].Content)
+		}
+	}
+}
+
+// anchor returns the anchor of the target, assigning one on first use. A
+// link to a whole chapter lands on its title heading, or on the chapter ID
+// when the title is not a heading of its own.
+func (t linkTarget) anchor(used map[string]bool) string {
+	index := t.block
+	if index < 0 || t.chapter.Blocks[index].Kind != BlockKindHeading {
+		index = titleHeadingIndex(*t.chapter, displayChapterTitle(*t.chapter))
+	}
+	if index < 0 {
+		t.chapter.anchor = t.chapter.ID
+		return t.chapter.anchor
+	}
+	block := &t.chapter.Blocks[index]
+	if block.Anchor == "" {
+		block.Anchor = uniqueAnchor(headingSlug(block.Text), used)
+	}
+	return block.Anchor
+}
+
+// headingSlug follows GitHub's rules: lower case, letters and digits kept
+// (CJK included), spaces become hyphens and other punctuation is dropped.
+func headingSlug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(plainInline(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+func uniqueAnchor(slug string, used map[string]bool) string {
+	if slug == "" {
+		slug = "section"
+	}
+	anchor := slug
+	for n := 1; used[anchor]; n++ {
+		anchor = slug + "-" + strconv.Itoa(n)
+	}
+	used[anchor] = true
+	return anchor
+}
+
+// unlinkText drops link markup and keeps the link text, for places that
+// need plain text such as titles and heuristics.
+func unlinkText(text string) string {
+	if !strings.Contains(text, "](") {
+		return text
+	}
+	text = crossLinkRe.ReplaceAllString(text, "$1")
+	return anchorLinkRe.ReplaceAllString(text, "$1")
+}
+
+// anchorChapters maps each assigned anchor to the chapter that holds it.
+func anchorChapters(book Book) map[string]string {
+	owners := map[string]string{}
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		if chapter.anchor != "" {
+			owners[chapter.anchor] = chapter.ID
+		}
+		for _, block := range chapter.Blocks {
+			if block.Anchor != "" {
+				owners[block.Anchor] = chapter.ID
+			}
+		}
+	}
+	return owners
+}
+
+// relinkAnchors points "#anchor" links in a per-chapter document at the file
+// that holds the anchor. re must capture the anchor in its first group.
+func relinkAnchors(doc, chapterID string, owners map[string]string, ext string, re *regexp.Regexp) string {
+	if len(owners) == 0 {
+		return doc
+	}
+	return re.ReplaceAllStringFunc(doc, func(match string) string {
+		anchor := re.FindStringSubmatch(match)[1]
+		owner, ok := owners[anchor]
+		if !ok || owner == chapterID {
+			return match
+		}
+		return strings.Replace(match, "#"+anchor, sanitizePathComponent(owner)+ext+"#"+anchor, 1)
+	})
+}
@@ -0,0 +1,104 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertEPUBResolvesCrossLinks(t *testing.T) {
+	workDir := testOutputDir(t, "cross-links")
+	input := filepath.Join(workDir, "links.epub")
+	writeTestEPUB(t, input, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf": `<?xml version="1.0" encoding="UTF-8"?>
+<package version="2.0" xmlns="http://www.idpf.org/2007/opf">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Linked Book</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="chap1" href="Text/chap1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="chap2" href="Text/chap2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="chap1"/>
+    <itemref idref="chap2"/>
+  </spine>
+</package>`,
+		"OEBPS/Text/chap1.xhtml": `<html><body><h1>Chapter One</h1>
+<p>See <a href="chap2.xhtml#later">the later part</a> and <a href="chap2.xhtml#p2">this paragraph</a> on <a href="https://example.com">the web</a> too</p>
+<p>A <a href="missing.xhtml#gone">dead link</a> stays text.</p></body></html>`,
+		"OEBPS/Text/chap2.xhtml": `<html><body><h1>Chapter Two</h1>
+<p>Opening text.</p>
+<section id="later"><h2>Later: Part</h2><p id="p2">Body.</p>
+<p>Back to <a href="chap1.xhtml">the start</a> again</p></section></body></html>`,
+	})
+
+	result, err := ConvertEPUB(context.Background(), input, Options{
+		OutputRootDir: workDir,
+		BaseName:      "links",
+		HTML:          true,
+	})
+	if err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+
+	mainData, err := os.ReadFile(result.MainMarkdownPath)
+	if err != nil {
+		t.Fatalf("read main markdown: %v", err)
+	}
+	main := string(mainData)
+	for _, want := range []string{
+		`See [the later part](#later-part) and [this paragraph](#later-part) on the web too`,
+		`A dead link stays text.`,
+		`#### Later: Part <a id="later-part"></a>`,
+		`### Chapter One <a id="chapter-one"></a>`,
+		`Back to [the start](#chapter-one) again`,
+	} {
+		if !strings.Contains(main, want) {
+			t.Fatalf("expected %q in main markdown:\n%s", want, main)
+		}
+	}
+	if strings.Contains(main, "epub:") || strings.Contains(main, "### Chapter Two <a") {
+		t.Fatalf("unexpected link markup:\n%s", main)
+	}
+
+	chapterData, err := os.ReadFile(filepath.Join(result.ArtifactDir, "chapters", "chapter-001.md"))
+	if err != nil {
+		t.Fatalf("read chapter markdown: %v", err)
+	}
+	if !strings.Contains(string(chapterData), "[the later part](chapter-002.md#later-part)") {
+		t.Fatalf("expected cross-file link in chapter file:\n%s", chapterData)
+	}
+
+	pageData, err := os.ReadFile(filepath.Join(result.ArtifactDir, "html", "chapter-002.html"))
+	if err != nil {
+		t.Fatalf("read html page: %v", err)
+	}
+	page := string(pageData)
+	if !strings.Contains(page, `id="later-part">Later: Part</h`) || !strings.Contains(page, `<a href="chapter-001.html#chapter-one">the start</a>`) {
+		t.Fatalf("expected anchors and cross-page links in html:\n%s", page)
+	}
+}
+
+func TestHeadingSlug(t *testing.T) {
+	used := map[string]bool{"chapter-001": true}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Later: *Part* Two", "later-part-two"},
+		{"第一章 总论", "第一章-总论"},
+		{"Later: Part Two", "later-part-two-1"},
+		{"???", "section"},
+		{"Chapter 001", "chapter-001-1"},
+	}
+	for _, tt := range tests {
+		if got := uniqueAnchor(headingSlug(tt.text), used); got != tt.want {
+			t.Fatalf("anchor for %q = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
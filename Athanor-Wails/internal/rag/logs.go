@@ -0,0 +1,26 @@
+package rag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Log lines put text taken from the book, such as chapter titles and
+// resource paths, between « and », so a failure report can hash it out
+// without knowing each message.
+var logDetailRe = regexp.MustCompile(`«[^»]*»`)
+
+func logDetail(s string) string {
+	return "«" + strings.ReplaceAll(s, "»", "") + "»"
+}
+
+// RedactLogDetails replaces the book text marked in a log line with a short
+// hash of it, so one title or path still reads the same across lines.
+func RedactLogDetails(line string) string {
+	return logDetailRe.ReplaceAllStringFunc(line, func(detail string) string {
+		sum := sha256.Sum256([]byte(detail))
+		return "«" + hex.EncodeToString(sum[:4]) + "»"
+	})
+}
@@ -0,0 +1,114 @@
+package rag
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// mathTeX returns the TeX source for a MathML <math> element, taken from its
+// alttext attribute or a TeX <annotation>. MathML without a TeX form is left
+// to the regular text walk.
+func mathTeX(node *html.Node) (string, bool) {
+	if node == nil || node.Type != html.ElementNode || localName(node.Data) != "math" {
+		return "", false
+	}
+	if alt := strings.TrimSpace(attr(node, "alttext")); alt != "" {
+		return alt, true
+	}
+	var tex string
+	var walk func(*html.Node)
+	walk = func(current *html.Node) {
+		if tex != "" {
+			return
+		}
+		if current.Type == html.ElementNode && localName(current.Data) == "annotation" &&
+			strings.Contains(strings.ToLower(attr(current, "encoding")), "tex") {
+			tex = strings.TrimSpace(rawText(current))
+			return
+		}
+		for child := current.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return tex, tex != ""
+}
+
+func isDisplayMath(node *html.Node) bool {
+	return attr(node, "display") == "block" || attr(node, "mode") == "display"
+}
+
+// displayMathOnly reports whether node is, or only wraps, a single display
+// equation, so it can become its own block.
+func displayMathOnly(node *html.Node) (string, bool) {
+	if tex, ok := mathTeX(node); ok {
+		return tex, isDisplayMath(node)
+	}
+	if node.Data != "p" && node.Data != "div" {
+		return "", false
+	}
+	var math *html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			if strings.TrimSpace(child.Data) != "" {
+				return "", false
+			}
+		case html.ElementNode:
+			if math != nil {
+				return "", false
+			}
+			math = child
+		}
+	}
+	if math == nil || !isDisplayMath(math) {
+		return "", false
+	}
+	return mathTeX(math)
+}
+
+func (s MathStyle) inline(tex string) string {
+	tex = whitespaceRe.ReplaceAllString(tex, " ")
+	switch s {
+	case MathStyleLaTeX:
+		return `\(` + tex + `\)`
+	case MathStyleFenced:
+		return "$`" + tex + "`$"
+	default:
+		return "$" + tex + "$"
+	}
+}
+
+func (s MathStyle) display(tex string) Block {
+	switch s {
+	case MathStyleLaTeX:
+		return Block{Kind: BlockKindParagraph, Text: `\[` + tex + `\]`}
+	case MathStyleFenced:
+		return Block{Kind: BlockKindCode, Label: "math", Text: tex}
+	default:
+		return Block{Kind: BlockKindParagraph, Text: "$$" + tex + "$$"}
+	}
+}
+
+func localName(name string) string {
+	if index := strings.LastIndex(name, ":"); index >= 0 {
+		return name[index+1:]
+	}
+	return name
+}
+
+func rawText(node *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(current *html.Node) {
+		if current.Type == html.TextNode {
+			b.WriteString(current.Data)
+		}
+		for child := current.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return b.String()
+}
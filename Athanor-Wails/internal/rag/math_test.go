@@ -0,0 +1,52 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+const mathTestBody = `
+	<p>Area is <math alttext="\pi r^2"><mi>π</mi></math> here.</p>
+	<p><math display="block"><semantics><mrow><mo>∑</mo></mrow><annotation encoding="application/x-tex">\sum_{i=1}^n i</annotation></semantics></math></p>`
+
+func buildMathChapter(t *testing.T, style MathStyle) Chapter {
+	t.Helper()
+	builder := newChapterBuilder("one.xhtml", 1, "", nil, noteRegistry{})
+	builder.styles = styleMapper{config: StyleConfig{Math: style}}
+	builder.consumeNode(parseBodyFromHTML(t, mathTestBody))
+	return builder.build()
+}
+
+func TestMathMLBecomesDollarDelimiters(t *testing.T) {
+	chapter := buildMathChapter(t, MathStyleDollar)
+	if len(chapter.Blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", chapter.Blocks)
+	}
+	if chapter.Blocks[0].Text != `Area is $\pi r^2$ here.` {
+		t.Fatalf("unexpected inline math: %q", chapter.Blocks[0].Text)
+	}
+	if chapter.Blocks[1].Text != `$$\sum_{i=1}^n i$$` {
+		t.Fatalf("unexpected display math: %q", chapter.Blocks[1].Text)
+	}
+}
+
+func TestMathMLLaTeXDelimiters(t *testing.T) {
+	chapter := buildMathChapter(t, MathStyleLaTeX)
+	if chapter.Blocks[0].Text != `Area is \(\pi r^2\) here.` {
+		t.Fatalf("unexpected inline math: %q", chapter.Blocks[0].Text)
+	}
+	if chapter.Blocks[1].Text != `\[\sum_{i=1}^n i\]` {
+		t.Fatalf("unexpected display math: %q", chapter.Blocks[1].Text)
+	}
+}
+
+func TestMathMLFencedDisplayBlock(t *testing.T) {
+	chapter := buildMathChapter(t, MathStyleFenced)
+	out := renderBlocks(chapter.Blocks, 2)
+	if !strings.Contains(out, "Area is $`\\pi r^2`$ here.") {
+		t.Fatalf("unexpected inline math: %q", out)
+	}
+	if !strings.Contains(out, "```math\n\\sum_{i=1}^n i\n```") {
+		t.Fatalf("expected fenced math block, got %q", out)
+	}
+}
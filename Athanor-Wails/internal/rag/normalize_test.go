@@ -112,3 +112,42 @@ func TestNormalizeParagraphV2(t *testing.T) {
 		})
 	}
 }
+
+func TestInlineTextGluesDropCaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "drop cap joins the rest of the word",
+			input:    `<p><span class="dropcap">T</span>he quick fox.</p>`,
+			expected: "The quick fox.",
+		},
+		{
+			name:     "small caps continuation",
+			input:    `<p><span class="first-letter">I</span><span class="smallcaps">t was</span> late.</p>`,
+			expected: "It was late.",
+		},
+		{
+			name:     "single letter word keeps its space",
+			input:    `<p><span class="dropcap">A</span> long time ago.</p>`,
+			expected: "A long time ago.",
+		},
+		{
+			name:     "long span is not a drop cap",
+			input:    `<p><span class="dropcap-line">Once</span>upon</p>`,
+			expected: "Once upon",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := parseBodyFromHTML(t, tt.input)
+			builder := newChapterBuilder("one.xhtml", 1, "", nil, noteRegistry{})
+			if got := builder.inlineText(findElement(body, "p")); got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
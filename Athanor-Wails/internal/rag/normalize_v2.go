@@ -5,6 +5,7 @@ import "strings"
 func NormalizeBook(book *Book) {
 	book.Main = normalizeChapterListV2(book.Main)
 	book.Back = normalizeChapterListV2(book.Back)
+	assignChapterLanguages(book)
 	recomputeStats(book)
 }
 
@@ -30,7 +31,7 @@ func normalizeBlocksV2(blocks []Block) []Block {
 	out := make([]Block, 0, len(blocks))
 	for _, block := range blocks {
 		switch block.Kind {
-		case BlockKindParagraph, BlockKindBlockquote, BlockKindHeading:
+		case BlockKindParagraph, BlockKindBlockquote, BlockKindHeading, BlockKindCallout:
 			block.Text = normalizeParagraphV2(block.Text)
 			if block.Text == "" {
 				continue
@@ -151,7 +152,7 @@ func duplicateBlockV2(prev, current Block) bool {
 		return false
 	}
 	switch prev.Kind {
-	case BlockKindParagraph, BlockKindBlockquote, BlockKindHeading, BlockKindCode:
+	case BlockKindParagraph, BlockKindBlockquote, BlockKindHeading, BlockKindCode, BlockKindCallout:
 		return prev.Text == current.Text
 	default:
 		return false
@@ -246,7 +247,7 @@ func blockLooksLikeTOCResidual(block Block) bool {
 }
 
 func textLooksLikeTOCResidual(text string) bool {
-	text = normalizeParagraphV2(text)
+	text = normalizeParagraphV2(unlinkText(text))
 	lower := strings.ToLower(text)
 	if lower == "" {
 		return false
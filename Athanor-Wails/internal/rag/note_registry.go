@@ -16,10 +16,20 @@ type noteDefinition struct {
 
 type noteRegistry struct {
 	byKey map[string]noteDefinition
+	// referenced holds the "path#id" keys that EPUB3 noteref links point
+	// at. Popup notes are often plain <aside> or <div> elements whose only
+	// note semantics is the link, so they would otherwise be read as body
+	// text.
+	referenced map[string]struct{}
 }
 
 func buildNoteRegistry(entries map[string]zipEntry, opfDir string, pkg packageXML) noteRegistry {
-	registry := noteRegistry{byKey: map[string]noteDefinition{}}
+	registry := noteRegistry{byKey: map[string]noteDefinition{}, referenced: map[string]struct{}{}}
+	type document struct {
+		ref  string
+		body *html.Node
+	}
+	var docs []document
 	for _, item := range pkg.Manifest.Items {
 		if !isXHTMLItem(item.MediaType, item.Href) {
 			continue
@@ -29,28 +39,63 @@ func buildNoteRegistry(entries map[string]zipEntry, opfDir string, pkg packageXM
 		if !ok {
 			continue
 		}
-		defs := collectNoteDefinitions(full, entry.data)
-		for key, def := range defs {
+		doc, err := html.Parse(bytes.NewReader(entry.data))
+		if err != nil {
+			continue
+		}
+		if body := findElement(doc, "body"); body != nil {
+			docs = append(docs, document{ref: full, body: body})
+			collectNoterefTargets(full, body, registry.referenced)
+		}
+	}
+	// Definitions are collected once every noteref is known, since a note
+	// file usually comes after the chapters that point into it.
+	for _, doc := range docs {
+		for key, def := range collectNoteDefinitions(doc.ref, doc.body, registry) {
 			registry.byKey[key] = def
 		}
 	}
 	return registry
 }
 
-func collectNoteDefinitions(sourceRef string, data []byte) map[string]noteDefinition {
-	doc, err := html.Parse(bytes.NewReader(data))
-	if err != nil {
-		return nil
+// collectNoterefTargets records the targets of epub:type="noteref" and
+// role="doc-noteref" links.
+func collectNoterefTargets(sourceRef string, node *html.Node, targets map[string]struct{}) {
+	if node.Type == html.ElementNode && node.Data == "a" {
+		kind := strings.ToLower(attr(node, "epub:type") + " " + attr(node, "role"))
+		if strings.Contains(kind, "noteref") {
+			if key := resolveNoteKey(sourceRef, attr(node, "href")); key != "" {
+				targets[key] = struct{}{}
+			}
+		}
 	}
-	body := findElement(doc, "body")
-	if body == nil {
-		return nil
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		collectNoterefTargets(sourceRef, child, targets)
 	}
+}
+
+// isNote reports whether node holds a note: it either says so itself or a
+// noteref link points at it.
+func (r noteRegistry) isNote(sourceRef string, node *html.Node) bool {
+	if isNoteNode(node) {
+		return true
+	}
+	if node == nil || node.Type != html.ElementNode || len(r.referenced) == 0 {
+		return false
+	}
+	id := strings.TrimSpace(attr(node, "id"))
+	if id == "" {
+		return false
+	}
+	_, ok := r.referenced[strings.SplitN(sourceRef, "#", 2)[0]+"#"+id]
+	return ok
+}
 
+func collectNoteDefinitions(sourceRef string, body *html.Node, registry noteRegistry) map[string]noteDefinition {
 	defs := map[string]noteDefinition{}
 	var walk func(*html.Node)
 	walk = func(node *html.Node) {
-		if isNoteNode(node) {
+		if registry.isNote(sourceRef, node) {
 			id := strings.TrimSpace(attr(node, "id"))
 			if id != "" {
 				key := sourceRef + "#" + id
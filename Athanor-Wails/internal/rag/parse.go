@@ -12,6 +12,10 @@ import (
 )
 
 func ParseEPUB(ctx context.Context, inputPath string) (Book, error) {
+	return parseEPUB(ctx, inputPath, Options{})
+}
+
+func parseEPUB(ctx context.Context, inputPath string, options Options) (Book, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -60,49 +64,40 @@ func ParseEPUB(ctx context.Context, inputPath string) (Book, error) {
 		return Book{}, fmt.Errorf("解析 OPF 失败: %w", err)
 	}
 
-	book := Book{
-		Metadata: Metadata{
-			Title:         firstNonEmpty(pkg.Metadata.Title...),
-			Authors:       filterNonEmpty(pkg.Metadata.Creator),
-			Language:      firstNonEmpty(pkg.Metadata.Language...),
-			Publisher:     firstNonEmpty(pkg.Metadata.Publisher...),
-			PublishedDate: firstNonEmpty(pkg.Metadata.Date...),
-			Identifier:    firstNonEmpty(pkg.Metadata.Identifier...),
-		},
-	}
+	book := Book{Metadata: metadataFromPackage(pkg)}
+
+	book.Metadata.Series, book.Metadata.SeriesIndex = seriesFromPackage(pkg)
 
 	opfDir := path.Dir(opfPath)
-	manifest := map[string]struct {
-		Href       string
-		Properties string
-	}{}
-	for _, item := range pkg.Manifest.Items {
-		manifest[item.ID] = struct {
-			Href       string
-			Properties string
-		}{
-			Href:       resolveHref(opfDir, item.Href),
-			Properties: item.Properties,
-		}
-	}
+	manifest := buildManifestIndex(opfDir, pkg)
 
 	tocTargets := extractTOCTargets(entries, opfDir, pkg)
 	targetsByHref := groupTOCTargetsByBase(tocTargets)
 	noteRegistry := buildNoteRegistry(entries, opfDir, pkg)
+	styles := buildStyleMapper(entries, opfDir, pkg, options.Styles)
+	cover := detectCover(entries, opfDir, pkg)
+	book.Metadata.CoverImage = cover.ImageHref
+	spine := checkSpine(entries, pkg, manifest, tocTargets)
+	book.warnings = append(book.warnings, spine.warnings...)
+	encrypted, encryptionWarnings := readEncryptedResources(entries)
+	book.encrypted = encrypted
+	book.warnings = append(book.warnings, encryptionWarnings...)
+
+	documents := spine.documents
+	if options.IncludeOrphans {
+		documents = append(append([]string(nil), documents...), spine.orphans...)
+	}
 	order := 0
-	for _, itemref := range pkg.Spine.Itemrefs {
+	for index, href := range documents {
 		if err := ctx.Err(); err != nil {
 			return Book{}, err
 		}
-		item, ok := manifest[itemref.IDRef]
-		if !ok {
+		if href == cover.PageHref {
 			continue
 		}
-		entry, ok := entries[item.Href]
-		if !ok {
-			continue
-		}
-		chapters, err := parseChapters(entry.name, entry.data, order+1, targetsByHref[item.Href], noteRegistry)
+		entry := entries[href]
+		orphan := index >= len(spine.documents)
+		chapters, err := parseChapters(entry.name, entry.data, order+1, targetsByHref[href], noteRegistry, styles)
 		if err != nil {
 			return Book{}, err
 		}
@@ -110,6 +105,10 @@ func ParseEPUB(ctx context.Context, inputPath string) (Book, error) {
 			order++
 			chapter.Order = order
 			chapter.ID = fmt.Sprintf("chapter-%03d", order)
+			if orphan {
+				chapter.Kind = ChapterKindBackMatter
+				chapter.ClassifyReason = "spine:orphan"
+			}
 			if chapter.ClassifyReason == "" {
 				classifyChapter(&chapter, pkg.Guide.Refs)
 			}
@@ -129,7 +128,7 @@ func ParseEPUB(ctx context.Context, inputPath string) (Book, error) {
 	return book, nil
 }
 
-func parseChapters(sourceRef string, data []byte, startOrder int, targets []tocTarget, notes noteRegistry) ([]Chapter, error) {
+func parseChapters(sourceRef string, data []byte, startOrder int, targets []tocTarget, notes noteRegistry, styles styleMapper) ([]Chapter, error) {
 	doc, err := html.Parse(bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("解析 XHTML 失败 (%s): %w", sourceRef, err)
@@ -141,13 +140,13 @@ func parseChapters(sourceRef string, data []byte, startOrder int, targets []tocT
 	}
 
 	noteTargets := map[string]struct{}{}
-	collectNoteTargets(body, noteTargets)
+	collectNoteTargets(body, sourceRef, notes, noteTargets)
 
 	if segments := splitBodyByTargets(body, targets); len(segments) > 1 {
 		chapters := make([]Chapter, 0, len(segments))
 		nextOrder := startOrder
 		for _, segment := range segments {
-			chapter, ok := buildChapterFromNodes(sourceRef, nextOrder, segment.Title, segment.Nodes, noteTargets, notes)
+			chapter, ok := buildChapterFromNodes(sourceRef, nextOrder, segment.Title, segment.Nodes, noteTargets, notes, styles)
 			if !ok {
 				continue
 			}
@@ -155,6 +154,7 @@ func parseChapters(sourceRef string, data []byte, startOrder int, targets []tocT
 				chapter.Kind = segment.ForceKind
 				chapter.ClassifyReason = segment.ForceReason
 			}
+			chapter.Depth = segment.Depth
 			chapter.warnings = append(chapter.warnings, segment.Warnings...)
 			chapters = append(chapters, chapter)
 			nextOrder++
@@ -163,18 +163,22 @@ func parseChapters(sourceRef string, data []byte, startOrder int, targets []tocT
 	}
 
 	tocTitle := ""
+	tocDepth := 0
 	if len(targets) > 0 {
 		tocTitle = targets[0].Title
+		tocDepth = targets[0].Depth
 	}
-	chapter, ok := buildChapterFromNodes(sourceRef, startOrder, tocTitle, bodyChildren(body), noteTargets, notes)
+	chapter, ok := buildChapterFromNodes(sourceRef, startOrder, tocTitle, bodyChildren(body), noteTargets, notes, styles)
 	if !ok {
 		return nil, nil
 	}
+	chapter.Depth = tocDepth
 	return []Chapter{chapter}, nil
 }
 
-func buildChapterFromNodes(sourceRef string, order int, tocTitle string, nodes []*html.Node, noteTargets map[string]struct{}, notes noteRegistry) (Chapter, bool) {
+func buildChapterFromNodes(sourceRef string, order int, tocTitle string, nodes []*html.Node, noteTargets map[string]struct{}, notes noteRegistry, styles styleMapper) (Chapter, bool) {
 	builder := newChapterBuilder(sourceRef, order, tocTitle, noteTargets, notes)
+	builder.styles = styles
 	for _, node := range nodes {
 		builder.consumeNode(node)
 	}
@@ -30,6 +30,14 @@ type packageXML struct {
 		Publisher  []string `xml:"publisher"`
 		Date       []string `xml:"date"`
 		Identifier []string `xml:"identifier"`
+		Meta       []struct {
+			ID       string `xml:"id,attr"`
+			Name     string `xml:"name,attr"`
+			Content  string `xml:"content,attr"`
+			Property string `xml:"property,attr"`
+			Refines  string `xml:"refines,attr"`
+			Text     string `xml:",chardata"`
+		} `xml:"meta"`
 	} `xml:"metadata"`
 	Manifest struct {
 		Items []struct {
@@ -59,6 +67,7 @@ type tocTarget struct {
 	Fragment  string
 	Title     string
 	PlayOrder int
+	Depth     int
 }
 
 type manifestItem struct {
@@ -136,9 +145,56 @@ func metadataFromPackage(pkg packageXML) Metadata {
 		Publisher:     firstNonEmpty(pkg.Metadata.Publisher...),
 		PublishedDate: firstNonEmpty(pkg.Metadata.Date...),
 		Identifier:    firstNonEmpty(pkg.Metadata.Identifier...),
+		ISBN:          isbnFromIdentifiers(pkg.Metadata.Identifier),
 	}
 }
 
+// isbnFromIdentifiers returns the first identifier that is a valid ISBN-10
+// or ISBN-13, such as "urn:isbn:9780306406157" or "0-306-40615-2", without
+// its prefix and hyphens. Books often list a UUID first and the ISBN later.
+func isbnFromIdentifiers(identifiers []string) string {
+	for _, identifier := range identifiers {
+		value := strings.TrimSpace(identifier)
+		for _, prefix := range []string{"urn:isbn:", "isbn:", "isbn"} {
+			if len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+				value = strings.TrimSpace(value[len(prefix):])
+				break
+			}
+		}
+		value = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(value))
+		if validISBN(value) {
+			return value
+		}
+	}
+	return ""
+}
+
+func validISBN(isbn string) bool {
+	sum := 0
+	switch len(isbn) {
+	case 10:
+		for i, r := range isbn {
+			digit := int(r - '0')
+			if r == 'X' && i == 9 {
+				digit = 10
+			} else if r < '0' || r > '9' {
+				return false
+			}
+			sum += (10 - i) * digit
+		}
+		return sum%11 == 0
+	case 13:
+		for i, r := range isbn {
+			if r < '0' || r > '9' {
+				return false
+			}
+			sum += int(r-'0') * (1 + 2*(i%2))
+		}
+		return sum%10 == 0
+	}
+	return false
+}
+
 func decodeXML(data []byte, out any) error {
 	decoder := xml.NewDecoder(bytes.NewReader(data))
 	decoder.Strict = false
@@ -8,6 +8,7 @@ import (
 
 type chapterSegment struct {
 	Title       string
+	Depth       int
 	Nodes       []*html.Node
 	ForceKind   ChapterKind
 	ForceReason string
@@ -73,6 +74,7 @@ func splitSiblingsByTargets(siblings []*html.Node, targets []tocTarget, start in
 		if matchCount <= 1 {
 			segments = append(segments, chapterSegment{
 				Title: targets[matchFirst].Title,
+				Depth: targets[matchFirst].Depth,
 				Nodes: []*html.Node{node},
 			})
 			next = matchFirst + 1
@@ -83,6 +85,7 @@ func splitSiblingsByTargets(siblings []*html.Node, targets []tocTarget, start in
 		if len(children) == 0 {
 			segments = append(segments, chapterSegment{
 				Title:    targets[matchFirst].Title,
+				Depth:    targets[matchFirst].Depth,
 				Nodes:    []*html.Node{node},
 				Warnings: []string{"splitter:multi_target_unsplittable"},
 			})
@@ -102,6 +105,7 @@ func splitSiblingsByTargets(siblings []*html.Node, targets []tocTarget, start in
 			if len(selfNodes) == 0 && len(subSegments) == 0 {
 				segments = append(segments, chapterSegment{
 					Title: targets[selfIdx].Title,
+					Depth: targets[selfIdx].Depth,
 					Nodes: []*html.Node{node},
 				})
 				next = subNext
@@ -110,6 +114,7 @@ func splitSiblingsByTargets(siblings []*html.Node, targets []tocTarget, start in
 			if len(selfNodes) > 0 {
 				segments = append(segments, chapterSegment{
 					Title: targets[selfIdx].Title,
+					Depth: targets[selfIdx].Depth,
 					Nodes: selfNodes,
 				})
 			}
@@ -122,6 +127,7 @@ func splitSiblingsByTargets(siblings []*html.Node, targets []tocTarget, start in
 		if len(subSegments) <= 1 {
 			segments = append(segments, chapterSegment{
 				Title:    targets[matchFirst].Title,
+				Depth:    targets[matchFirst].Depth,
 				Nodes:    []*html.Node{node},
 				Warnings: []string{"splitter:multi_target_no_split"},
 			})
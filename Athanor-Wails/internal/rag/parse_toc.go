@@ -80,8 +80,8 @@ func parseNCX(data []byte, currentPath string) []tocTarget {
 	}
 
 	var results []tocTarget
-	var walk func(points []navPoint)
-	walk = func(points []navPoint) {
+	var walk func(points []navPoint, depth int)
+	walk = func(points []navPoint, depth int) {
 		for _, point := range points {
 			if point.Content.Src != "" {
 				resolved := resolveHref(path.Dir(currentPath), point.Content.Src)
@@ -89,12 +89,13 @@ func parseNCX(data []byte, currentPath string) []tocTarget {
 					HrefBase: strings.SplitN(resolved, "#", 2)[0],
 					Fragment: fragmentID(point.Content.Src),
 					Title:    strings.TrimSpace(point.Label.Text),
+					Depth:    depth,
 				})
 			}
-			walk(point.Children)
+			walk(point.Children, depth+1)
 		}
 	}
-	walk(ncx.NavMap.Points)
+	walk(ncx.NavMap.Points, 1)
 	return results
 }
 
@@ -104,9 +105,17 @@ func parseNavXHTML(data []byte, currentPath string) []tocTarget {
 		return nil
 	}
 
+	root := findTOCNav(doc)
+	if root == nil {
+		root = doc
+	}
+
 	var results []tocTarget
-	var walk func(*html.Node)
-	walk = func(node *html.Node) {
+	var walk func(*html.Node, int)
+	walk = func(node *html.Node, depth int) {
+		if node.Type == html.ElementNode && (node.Data == "ol" || node.Data == "ul") {
+			depth++
+		}
 		if node.Type == html.ElementNode && node.Data == "a" {
 			href := attr(node, "href")
 			text := strings.TrimSpace(nodeText(node))
@@ -116,13 +125,32 @@ func parseNavXHTML(data []byte, currentPath string) []tocTarget {
 					HrefBase: strings.SplitN(resolved, "#", 2)[0],
 					Fragment: fragmentID(href),
 					Title:    text,
+					Depth:    max(depth, 1),
 				})
 			}
 		}
 		for child := node.FirstChild; child != nil; child = child.NextSibling {
-			walk(child)
+			walk(child, depth)
 		}
 	}
-	walk(doc)
+	walk(root, 0)
 	return results
 }
+
+// findTOCNav returns the <nav epub:type="toc"> element so landmarks and
+// page-list links in the same document do not leak into the TOC.
+func findTOCNav(node *html.Node) *html.Node {
+	if node.Type == html.ElementNode && node.Data == "nav" {
+		for _, value := range strings.Fields(attr(node, "epub:type") + " " + attr(node, "role")) {
+			if value == "toc" || value == "doc-toc" {
+				return node
+			}
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findTOCNav(child); found != nil {
+			return found
+		}
+	}
+	return nil
+}
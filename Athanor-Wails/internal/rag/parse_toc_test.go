@@ -0,0 +1,57 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNavXHTMLUsesTOCNavDepth(t *testing.T) {
+	nav := `<html xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="toc"><ol>
+  <li><a href="part1.xhtml">Part One</a>
+    <ol><li><a href="chap1.xhtml#c1">Chapter 1</a></li></ol>
+  </li>
+</ol></nav>
+<nav epub:type="landmarks"><ol><li><a href="cover.xhtml">Cover</a></li></ol></nav>
+<nav epub:type="page-list"><ol><li><a href="chap1.xhtml#p1">1</a></li></ol></nav>
+</body></html>`
+
+	targets := parseNavXHTML([]byte(nav), "OEBPS/nav.xhtml")
+	if len(targets) != 2 {
+		t.Fatalf("expected only toc nav entries, got %+v", targets)
+	}
+	if targets[0].Depth != 1 || targets[1].Depth != 2 {
+		t.Fatalf("unexpected depths: %+v", targets)
+	}
+	if targets[1].HrefBase != "OEBPS/chap1.xhtml" || targets[1].Fragment != "c1" {
+		t.Fatalf("unexpected target: %+v", targets[1])
+	}
+}
+
+func TestParseNCXDepth(t *testing.T) {
+	ncx := `<ncx><navMap>
+<navPoint><navLabel><text>Part One</text></navLabel><content src="part1.xhtml"/>
+  <navPoint><navLabel><text>Chapter 1</text></navLabel><content src="chap1.xhtml"/></navPoint>
+</navPoint>
+</navMap></ncx>`
+
+	targets := parseNCX([]byte(ncx), "toc.ncx")
+	if len(targets) != 2 || targets[0].Depth != 1 || targets[1].Depth != 2 {
+		t.Fatalf("unexpected ncx targets: %+v", targets)
+	}
+}
+
+func TestRenderBookMarkdownNestsChaptersByTOCDepth(t *testing.T) {
+	book := Book{
+		Metadata: Metadata{Title: "Book"},
+		Main: []Chapter{
+			{ID: "chapter-001", Title: "Part One", Order: 1, Depth: 1, Blocks: []Block{{Kind: BlockKindParagraph, Text: "Intro"}}},
+			{ID: "chapter-002", Title: "Chapter 1", Order: 2, Depth: 2, Blocks: []Block{{Kind: BlockKindParagraph, Text: "Body"}}},
+		},
+	}
+
+	out := RenderBookMarkdown(book)
+	if !strings.Contains(out, "## Part One") || !strings.Contains(out, "### Chapter 1") {
+		t.Fatalf("expected nested chapter headings, got %q", out)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -41,6 +42,98 @@ func TestConvertEPUBWritesDiagnostics(t *testing.T) {
 	}
 }
 
+func TestConvertEPUBStopsWhenCancelled(t *testing.T) {
+	workDir := testOutputDir(t, "cancelled")
+	input := filepath.Join(workDir, "sample.epub")
+	createRAGTestEPUB(t, input)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ConvertEPUB(ctx, input, Options{
+		OutputRootDir: workDir,
+		BaseName:      "sample",
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "sample.md")); !os.IsNotExist(err) {
+		t.Fatalf("cancelled conversion should not write outputs, stat err: %v", err)
+	}
+}
+
+func TestConvertEPUBStreamsChapterFiles(t *testing.T) {
+	workDir := testOutputDir(t, "chapter-stream")
+	input := filepath.Join(workDir, "sample.epub")
+	createRAGTestEPUB(t, input)
+
+	var written []ChapterOutput
+	result, err := ConvertEPUB(context.Background(), input, Options{
+		OutputRootDir: workDir,
+		BaseName:      "sample",
+		ChapterWritten: func(chapter ChapterOutput) {
+			if _, err := os.Stat(chapter.Path); err != nil {
+				t.Errorf("chapter %s reported before it was written: %v", chapter.ID, err)
+			}
+			if _, err := os.Stat(filepath.Join(workDir, "sample.md")); !os.IsNotExist(err) {
+				t.Errorf("chapter %s should be written before the main Markdown", chapter.ID)
+			}
+			written = append(written, chapter)
+		},
+	})
+	if err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+
+	if len(written) == 0 || len(written) != written[0].Total {
+		t.Fatalf("expected one event per chapter, got %+v", written)
+	}
+	if entries, err := os.ReadDir(filepath.Join(result.ArtifactDir, "chapters")); err != nil || len(entries) != len(written) {
+		t.Fatalf("expected %d chapter files, got %d (%v)", len(written), len(entries), err)
+	}
+	for i, chapter := range written {
+		if chapter.Index != i+1 || chapter.Title == "" {
+			t.Fatalf("unexpected chapter event %d: %+v", i, chapter)
+		}
+	}
+}
+
+func TestConvertEPUBReportsStepProgress(t *testing.T) {
+	workDir := testOutputDir(t, "step-progress")
+	input := filepath.Join(workDir, "sample.epub")
+	createRAGTestEPUB(t, input)
+
+	var stages []string
+	var pcts []float64
+	_, err := ConvertEPUB(context.Background(), input, Options{
+		OutputRootDir: workDir,
+		BaseName:      "sample",
+		PlainText:     true,
+		HTML:          true,
+		Progress: func(stage string, pct float64, message string) {
+			stages = append(stages, stage)
+			pcts = append(pcts, pct)
+		},
+	})
+	if err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+
+	counts := map[string]int{}
+	for i, stage := range stages {
+		counts[stage]++
+		if i > 0 && pcts[i] < pcts[i-1] {
+			t.Fatalf("progress went backwards at %s: %v", stage, pcts)
+		}
+		if stage != "complete" && pcts[i] >= progressComplete {
+			t.Fatalf("stage %s reached 100 before completion: %v", stage, pcts)
+		}
+	}
+	// Each stage reports its start, then one step per chapter or per output.
+	if counts["chapters"] < 2 || counts["write"] != 4 {
+		t.Fatalf("expected chapter and write steps, got %v", counts)
+	}
+}
+
 func TestConvertEPUBTrimsTOCResidualAndLinksCrossFileFootnotes(t *testing.T) {
 	workDir := testOutputDir(t, "toc-footnotes")
 	input := filepath.Join(workDir, "toc-footnotes.epub")
@@ -174,3 +267,117 @@ func createRAGTestEPUB(t *testing.T, output string) {
 		t.Fatalf("close epub file: %v", err)
 	}
 }
+
+func TestConvertEPUBWarningPolicy(t *testing.T) {
+	workDir := testOutputDir(t, "warning-policy")
+	input := filepath.Join(workDir, "warned.epub")
+	writeTestEPUB(t, input, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf": `<?xml version="1.0" encoding="UTF-8"?>
+<package version="2.0" xmlns="http://www.idpf.org/2007/opf">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Warned Book</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="chap1" href="Text/chap1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="chap1"/>
+    <itemref idref="missing"/>
+  </spine>
+</package>`,
+		"OEBPS/Text/chap1.xhtml": `<html><body><h1>Chapter One</h1><p>Some text to convert.</p></body></html>`,
+	})
+
+	result, err := ConvertEPUB(context.Background(), input, Options{OutputRootDir: workDir, BaseName: "report"})
+	if err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+	if len(result.Warnings) == 0 || result.Warnings[0].Code != "spine:unknown_idref" || result.Warnings[0].Detail != "missing" {
+		t.Fatalf("expected the spine warning in the result, got %v", result.Warnings)
+	}
+
+	result, err = ConvertEPUB(context.Background(), input, Options{OutputRootDir: workDir, BaseName: "ignore", OnWarning: WarningsIgnore})
+	if err != nil || result.Warnings != nil {
+		t.Fatalf("ignore should convert without warnings, got %v (%v)", result.Warnings, err)
+	}
+
+	_, err = ConvertEPUB(context.Background(), input, Options{OutputRootDir: workDir, BaseName: "fail", OnWarning: WarningsFail})
+	if !errors.Is(err, ErrWarnings) || !strings.Contains(err.Error(), "spine:unknown_idref:missing") {
+		t.Fatalf("expected ErrWarnings naming the spine warning, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "fail")); !os.IsNotExist(err) {
+		t.Fatalf("a failed conversion should not write outputs, stat err: %v", err)
+	}
+}
+
+func TestConvertEPUBTurnsNoterefTargetsIntoFootnotes(t *testing.T) {
+	workDir := testOutputDir(t, "noteref")
+	input := filepath.Join(workDir, "noteref.epub")
+	writeTestEPUB(t, input, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf": `<?xml version="1.0" encoding="UTF-8"?>
+<package version="3.0" xmlns="http://www.idpf.org/2007/opf">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Popup Notes</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="chap1" href="chap1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="notes.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="chap1"/>
+    <itemref idref="notes" linear="no"/>
+  </spine>
+</package>`,
+		"OEBPS/chap1.xhtml": `<html xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<h1>Chapter One</h1>
+<p>A popup note.<a epub:type="noteref" href="#p1">1</a> A note kept elsewhere.<a role="doc-noteref" href="notes.xhtml#c1">2</a></p>
+<aside id="p1"><p>Inline popup text.</p></aside>
+</body></html>`,
+		"OEBPS/notes.xhtml": `<html><body><div id="c1"><p>Text from the notes file.</p></div></body></html>`,
+	})
+
+	result, err := ConvertEPUB(context.Background(), input, Options{OutputRootDir: workDir, BaseName: "noteref"})
+	if err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+	data, err := os.ReadFile(result.MainMarkdownPath)
+	if err != nil {
+		t.Fatalf("read main markdown: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		"A popup note.[^1] A note kept elsewhere.[^2]",
+		"[^1]: Inline popup text.",
+		"[^2]: Text from the notes file.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in markdown:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "Inline popup text." || line == "Text from the notes file." {
+			t.Fatalf("popup notes should not also appear as body text:\n%s", out)
+		}
+	}
+}
+
+func TestISBNFromIdentifiers(t *testing.T) {
+	for _, tt := range []struct {
+		identifiers []string
+		want        string
+	}{
+		{[]string{"urn:uuid:5a1e3b7c-0000-4000-8000-000000000000", "urn:isbn:978-0-306-40615-7"}, "9780306406157"},
+		{[]string{"ISBN 0-306-40615-2"}, "0306406152"},
+		{[]string{"080442957X"}, "080442957X"},
+		{[]string{"9780306406158"}, ""},
+		{[]string{"calibre:1234"}, ""},
+	} {
+		if got := isbnFromIdentifiers(tt.identifiers); got != tt.want {
+			t.Errorf("isbnFromIdentifiers(%q) = %q, want %q", tt.identifiers, got, tt.want)
+		}
+	}
+}
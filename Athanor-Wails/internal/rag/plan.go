@@ -0,0 +1,177 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Plan reports what a conversion would do without writing anything.
+type Plan struct {
+	Input    string          `json:"input"`
+	Title    string          `json:"title"`
+	Outputs  []PlannedOutput `json:"outputs"`
+	Chapters int             `json:"chapters"`
+	Chunks   int             `json:"chunks"`
+	Images   int             `json:"images"`
+	Issues   []string        `json:"issues,omitempty"`
+	// EstimatedMillis is how long the in-memory part of the conversion took
+	// during planning. Only writing the files is left out.
+	EstimatedMillis int64 `json:"estimatedMillis"`
+}
+
+// PlannedOutput is one file or directory a conversion would write. Exists
+// means it is already there and would be replaced.
+type PlannedOutput struct {
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+// PlanConversion runs the conversion pipeline in memory with the given
+// options, renders every selected format and reports the outputs, counts
+// and problems a real conversion would produce.
+func PlanConversion(ctx context.Context, inputPath string, options Options) (Plan, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	noLog := func(string) {}
+	noProgress := func(string, float64, string) {}
+
+	started := time.Now()
+	book, err := prepareBook(ctx, inputPath, &options, noLog, noProgress)
+	if err != nil {
+		return Plan{}, err
+	}
+	RenderBookMarkdown(book)
+	RenderChapterMarkdown(book)
+	chunks := BuildChunks(book, options.ChunkConfig)
+	book.Stats.ChunkCount = len(chunks)
+	diagnostics := BuildDiagnostics(book, chunks, options.ChunkConfig)
+	if options.PlainText {
+		RenderBookText(book, options.TextWrap)
+	}
+	if options.AsciiDoc {
+		RenderBookAsciiDoc(book)
+	}
+	if options.DocBook {
+		RenderBookDocBook(book)
+	}
+	if options.SQLScript {
+		RenderBookSQL(book)
+	}
+	if options.DOCX {
+		var styles []byte
+		if options.DOCXReference != "" {
+			if styles, err = ReadDOCXReferenceStyles(options.DOCXReference); err != nil {
+				return Plan{}, err
+			}
+		}
+		if _, err := RenderBookDOCX(book, styles); err != nil {
+			return Plan{}, err
+		}
+	}
+	if options.ODT {
+		if _, err := RenderBookODT(book); err != nil {
+			return Plan{}, err
+		}
+	}
+	if options.RTF {
+		RenderBookRTF(book)
+	}
+	if options.HTML {
+		book.applyHTMLOptions(options)
+		RenderBookHTML(book)
+	}
+	if err := ctx.Err(); err != nil {
+		return Plan{}, err
+	}
+
+	plan := Plan{
+		Input:           inputPath,
+		Title:           book.Metadata.Title,
+		Chapters:        len(book.Main) + len(book.Back),
+		Chunks:          len(chunks),
+		Images:          len(book.images.files),
+		EstimatedMillis: time.Since(started).Milliseconds(),
+	}
+
+	artifactDir := filepath.Join(options.OutputRootDir, options.BaseName)
+	outputs := []string{
+		filepath.Join(options.OutputRootDir, options.BaseName+".md"),
+		filepath.Join(artifactDir, "chapters"),
+		filepath.Join(artifactDir, "debug.md"),
+		filepath.Join(artifactDir, "metadata.json"),
+		filepath.Join(artifactDir, "toc.json"),
+		filepath.Join(artifactDir, "stats.json"),
+		filepath.Join(artifactDir, "diagnostics.json"),
+		filepath.Join(artifactDir, "chunks.jsonl"),
+	}
+	if len(book.images.files) > 0 {
+		outputs = append(outputs, filepath.Join(artifactDir, "images"))
+	}
+	if options.Readability {
+		outputs = append(outputs, filepath.Join(artifactDir, "readability.json"))
+	}
+	outputs = append(outputs, exportPaths(options)...)
+	if options.HTML {
+		outputs = append(outputs, filepath.Join(artifactDir, "html"))
+	}
+	if options.Cover == CoverModeExtract && book.Metadata.CoverImage != "" {
+		outputs = append(outputs, filepath.Join(artifactDir, "cover"+strings.ToLower(path.Ext(book.Metadata.CoverImage))))
+	}
+	for _, output := range outputs {
+		_, err := os.Stat(output)
+		plan.Outputs = append(plan.Outputs, PlannedOutput{Path: output, Exists: err == nil})
+	}
+
+	for _, warning := range conversionWarnings(book) {
+		plan.Issues = append(plan.Issues, warning.String())
+	}
+	for _, resource := range book.encrypted {
+		plan.Issues = append(plan.Issues, fmt.Sprintf("加密资源: %s (%s)", resource.Path, resource.Kind))
+	}
+	for _, duplicate := range book.duplicates {
+		if duplicate.Dropped {
+			plan.Issues = append(plan.Issues, fmt.Sprintf("章节 %s (%s) 与 %s 内容重复", duplicate.ID, duplicate.Title, duplicate.DuplicateOf))
+		}
+	}
+	if diagnostics.Summary.OversizeChunkCount > 0 {
+		plan.Issues = append(plan.Issues, fmt.Sprintf("%d 个超长 chunk", diagnostics.Summary.OversizeChunkCount))
+	}
+	return plan, nil
+}
+
+// TopLevelOutputs lists what a conversion with options writes directly into
+// its output directory: the main Markdown, the artifact directory and each
+// enabled export. Everything else goes inside the artifact directory.
+func TopLevelOutputs(options Options) []string {
+	return append([]string{
+		filepath.Join(options.OutputRootDir, options.BaseName+".md"),
+		filepath.Join(options.OutputRootDir, options.BaseName),
+	}, exportPaths(options)...)
+}
+
+func exportPaths(options Options) []string {
+	var paths []string
+	for _, export := range []struct {
+		enabled bool
+		suffix  string
+	}{
+		{options.PlainText, ".txt"},
+		{options.AsciiDoc, ".adoc"},
+		{options.DocBook, ".docbook.xml"},
+		{options.SQLScript, ".sql"},
+		{options.DOCX, ".docx"},
+		{options.ODT, ".odt"},
+		{options.RTF, ".rtf"},
+	} {
+		if export.enabled {
+			paths = append(paths, filepath.Join(options.OutputRootDir, options.BaseName+export.suffix))
+		}
+	}
+	return paths
+}
@@ -0,0 +1,51 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanConversionWritesNothing(t *testing.T) {
+	workDir := testOutputDir(t, "plan")
+	input := filepath.Join(workDir, "sample.epub")
+	createRAGTestEPUB(t, input)
+	options := Options{OutputRootDir: workDir, BaseName: "sample", PlainText: true}
+
+	plan, err := PlanConversion(context.Background(), input, options)
+	if err != nil {
+		t.Fatalf("PlanConversion failed: %v", err)
+	}
+	entries, err := os.ReadDir(workDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("planning should only leave the input behind, got %d entries (%v)", len(entries), err)
+	}
+	if plan.Chapters == 0 || plan.Chunks == 0 {
+		t.Fatalf("expected chapter and chunk counts, got %+v", plan)
+	}
+	wantText := filepath.Join(workDir, "sample.txt")
+	found := false
+	for _, output := range plan.Outputs {
+		if output.Exists {
+			t.Fatalf("nothing should exist yet: %+v", output)
+		}
+		found = found || output.Path == wantText
+	}
+	if !found {
+		t.Fatalf("expected %s in planned outputs: %+v", wantText, plan.Outputs)
+	}
+
+	if _, err := ConvertEPUB(context.Background(), input, options); err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+	plan, err = PlanConversion(context.Background(), input, options)
+	if err != nil {
+		t.Fatalf("PlanConversion failed: %v", err)
+	}
+	for _, output := range plan.Outputs {
+		if !output.Exists {
+			t.Fatalf("expected %s to be reported as existing", output.Path)
+		}
+	}
+}
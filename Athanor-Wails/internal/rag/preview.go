@@ -0,0 +1,55 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+)
+
+// Preview is the first chapter of a book rendered with the current options,
+// for checking cleanup and formatting settings before a full conversion.
+type Preview struct {
+	Title    string `json:"title"`
+	Chapter  string `json:"chapter"`
+	Chapters int    `json:"chapters"`
+	Markdown string `json:"markdown"`
+	// HTML is the chapter body as the HTML reader renders it, without the
+	// surrounding page.
+	HTML string `json:"html"`
+}
+
+// PreviewEPUB renders only the first chapter of a book and writes nothing.
+// Images are embedded as data URIs unless they are stripped, since the
+// files they would point at do not exist yet.
+func PreviewEPUB(ctx context.Context, inputPath string, options Options) (Preview, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if options.Styles.Images != ImageModeStrip {
+		options.Styles.Images = ImageModeEmbed
+	}
+	book, err := prepareBook(ctx, inputPath, &options, func(string) {}, func(string, float64, string) {})
+	if err != nil {
+		return Preview{}, err
+	}
+	chapters := append(append([]Chapter(nil), book.Main...), book.Back...)
+	if len(chapters) == 0 {
+		return Preview{}, fmt.Errorf("没有可预览的章节: %s", inputPath)
+	}
+	// Front matter such as a copyright page says little about the settings,
+	// so the preview starts at the first body chapter when there is one.
+	chapter := chapters[0]
+	for _, candidate := range chapters {
+		if candidate.Kind == ChapterKindMain {
+			chapter = candidate
+			break
+		}
+	}
+
+	return Preview{
+		Title:    bookTitle(book),
+		Chapter:  displayChapterTitle(chapter),
+		Chapters: len(chapters),
+		Markdown: WrapMarkdown(renderChapterDoc(book, chapter, anchorChapters(book)), options.Wrap, options.Columns),
+		HTML:     resolveHTMLImages(renderHTMLChapter(book, chapter), book.images.nested),
+	}, nil
+}
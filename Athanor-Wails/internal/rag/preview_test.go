@@ -0,0 +1,32 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviewEPUBRendersFirstChapter(t *testing.T) {
+	workDir := testOutputDir(t, "preview")
+	input := filepath.Join(workDir, "sample.epub")
+	createRAGTestEPUB(t, input)
+
+	preview, err := PreviewEPUB(context.Background(), input, Options{OutputRootDir: workDir, BaseName: "sample"})
+	if err != nil {
+		t.Fatalf("PreviewEPUB failed: %v", err)
+	}
+	if preview.Chapters == 0 || preview.Chapter == "" {
+		t.Fatalf("expected a chapter, got %+v", preview)
+	}
+	if !strings.HasPrefix(preview.Markdown, "# "+preview.Chapter) {
+		t.Fatalf("markdown should start with the chapter title:\n%s", preview.Markdown)
+	}
+	if !strings.Contains(preview.HTML, "<p>") {
+		t.Fatalf("expected an HTML body:\n%s", preview.HTML)
+	}
+	if entries, err := os.ReadDir(workDir); err != nil || len(entries) != 1 {
+		t.Fatalf("preview should not write outputs, got %d entries (%v)", len(entries), err)
+	}
+}
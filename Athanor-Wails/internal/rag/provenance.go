@@ -0,0 +1,93 @@
+package rag
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+// provenanceOptions is the part of Options that shapes the output. Paths,
+// callbacks and the context are left out so two runs with the same settings
+// stamp the same footer on any machine.
+type provenanceOptions struct {
+	ChunkConfig     ChunkConfig       `json:"chunkConfig"`
+	Readability     bool              `json:"readability"`
+	Cover           CoverMode         `json:"cover"`
+	Styles          StyleConfig       `json:"styles"`
+	Headings        HeadingConfig     `json:"headings"`
+	IncludeOrphans  bool              `json:"includeOrphans"`
+	DropDuplicates  bool              `json:"dropDuplicates"`
+	SeriesNumbering bool              `json:"seriesNumbering"`
+	PlainText       bool              `json:"plainText"`
+	TextWrap        int               `json:"textWrap"`
+	Wrap            WrapMode          `json:"wrap"`
+	Columns         int               `json:"columns"`
+	AsciiDoc        bool              `json:"asciiDoc"`
+	DocBook         bool              `json:"docBook"`
+	SQLScript       bool              `json:"sqlScript"`
+	DOCX            bool              `json:"docx"`
+	ODT             bool              `json:"odt"`
+	RTF             bool              `json:"rtf"`
+	HTML            bool              `json:"html"`
+	VerticalHTML    bool              `json:"verticalHtml"`
+	ReadableHTML    bool              `json:"readableHtml"`
+	DarkHTML        bool              `json:"darkHtml"`
+	Direction       TextDirection     `json:"direction"`
+	Chinese         ChineseConversion `json:"chinese"`
+	TOCDepth        int               `json:"tocDepth"`
+	NoTOC           bool              `json:"noToc"`
+	Notes           NotePlacement     `json:"notes"`
+	Labels          LabelLanguage     `json:"labels"`
+}
+
+// provenanceFooter returns an HTML comment recording the source EPUB, its
+// SHA-256, the pipeline version and the effective options, so anyone can
+// later check which input and settings produced a Markdown file.
+func provenanceFooter(metadata Metadata, options Options) string {
+	effective := provenanceOptions{
+		ChunkConfig:     normalizeChunkConfig(options.ChunkConfig),
+		Readability:     options.Readability,
+		Cover:           options.Cover,
+		Styles:          options.Styles,
+		Headings:        options.Headings,
+		IncludeOrphans:  options.IncludeOrphans,
+		DropDuplicates:  options.DropDuplicates,
+		SeriesNumbering: options.SeriesNumbering,
+		PlainText:       options.PlainText,
+		TextWrap:        options.TextWrap,
+		Wrap:            options.Wrap,
+		Columns:         options.Columns,
+		AsciiDoc:        options.AsciiDoc,
+		DocBook:         options.DocBook,
+		SQLScript:       options.SQLScript,
+		DOCX:            options.DOCX,
+		ODT:             options.ODT,
+		RTF:             options.RTF,
+		HTML:            options.HTML,
+		VerticalHTML:    options.VerticalHTML,
+		ReadableHTML:    options.ReadableHTML,
+		DarkHTML:        options.DarkHTML,
+		Direction:       options.Direction,
+		Chinese:         options.Chinese,
+		TOCDepth:        options.TOCDepth,
+		NoTOC:           options.NoTOC,
+		Notes:           options.Notes,
+		Labels:          options.Labels,
+	}
+	if effective.Wrap == WrapAuto && effective.Columns <= 0 {
+		effective.Columns = DefaultWrapColumns
+	}
+	data, _ := json.Marshal(effective)
+	source, _ := json.Marshal(filepath.Base(metadata.SourcePath))
+
+	footer := strings.Join([]string{
+		"source: " + string(source),
+		"sha256: " + metadata.SourceSHA256,
+		"version: " + PipelineVersion,
+		"options: " + string(data),
+	}, "\n")
+	// "--" must not appear inside an HTML comment. It can only occur inside
+	// the JSON strings, where the escaped form decodes to the same text.
+	footer = strings.ReplaceAll(footer, "--", `-\u002d`)
+	return "<!-- athanor provenance\n" + footer + "\n-->\n"
+}
@@ -0,0 +1,71 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertEPUBStampsProvenance(t *testing.T) {
+	workDir := testOutputDir(t, "provenance")
+	input := filepath.Join(workDir, "stamped.epub")
+	writeImageTestEPUB(t, input)
+	hash, err := fileSHA256(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, enabled := range []bool{false, true} {
+		result, err := ConvertEPUB(context.Background(), input, Options{
+			OutputRootDir: workDir,
+			BaseName:      "stamped",
+			Wrap:          WrapAuto,
+			Provenance:    enabled,
+		})
+		if err != nil {
+			t.Fatalf("ConvertEPUB failed: %v", err)
+		}
+		data, err := os.ReadFile(result.MainMarkdownPath)
+		if err != nil {
+			t.Fatalf("read main markdown: %v", err)
+		}
+		main := string(data)
+		if !enabled {
+			if strings.Contains(main, hash) || strings.Contains(main, "<!--") {
+				t.Fatalf("expected no provenance by default:\n%s", main)
+			}
+			continue
+		}
+		for _, want := range []string{
+			"\n<!-- athanor provenance\n",
+			"source: \"stamped.epub\"\n",
+			"sha256: " + hash + "\n",
+			"version: " + PipelineVersion + "\n",
+			`"wrap":"auto","columns":72,`,
+			"\n-->\n",
+		} {
+			if !strings.Contains(main, want) {
+				t.Fatalf("expected %q in:\n%s", want, main)
+			}
+		}
+	}
+}
+
+func TestProvenanceFooterStaysInsideComment(t *testing.T) {
+	footer := provenanceFooter(Metadata{SourcePath: "a-->b.epub"}, Options{})
+	body := strings.TrimSuffix(strings.TrimPrefix(footer, "<!--"), "-->\n")
+	if strings.Contains(body, "--") {
+		t.Fatalf("footer body must not contain \"--\":\n%s", footer)
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if name, ok := strings.CutPrefix(line, "source: "); ok {
+			var source string
+			if err := json.Unmarshal([]byte(name), &source); err != nil || source != "a-->b.epub" {
+				t.Fatalf("source line %q decodes to %q (%v)", name, source, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package rag
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	readabilityScriptLatin = "latin"
+	readabilityScriptCJK   = "cjk"
+)
+
+func BuildReadabilityReport(book Book) ReadabilityReport {
+	all := append(append([]Chapter(nil), book.Main...), book.Back...)
+	report := ReadabilityReport{
+		Chapters: make([]ChapterReadability, 0, len(all)),
+	}
+
+	var mainTexts []string
+	for _, chapter := range all {
+		texts := readabilityTexts(chapter)
+		if chapter.Kind == ChapterKindMain {
+			mainTexts = append(mainTexts, texts...)
+		}
+		report.Chapters = append(report.Chapters, ChapterReadability{
+			ID:      chapter.ID,
+			Title:   chapter.Title,
+			Order:   chapter.Order,
+			Kind:    chapter.Kind,
+			Metrics: measureReadability(texts),
+		})
+	}
+	report.Summary = measureReadability(mainTexts)
+	return report
+}
+
+func readabilityTexts(chapter Chapter) []string {
+	texts := make([]string, 0, len(chapter.Blocks))
+	for _, block := range chapter.Blocks {
+		switch block.Kind {
+		case BlockKindParagraph, BlockKindBlockquote, BlockKindCallout:
+			texts = append(texts, footnoteRefRe.ReplaceAllString(stripImages(unlinkText(block.Text)), ""))
+		case BlockKindList:
+			for _, item := range block.Items {
+				texts = append(texts, footnoteRefRe.ReplaceAllString(item, ""))
+			}
+		}
+	}
+	return texts
+}
+
+func measureReadability(texts []string) ReadabilityMetrics {
+	script := detectReadabilityScript(texts)
+	metrics := ReadabilityMetrics{Script: script}
+
+	var lengths []int
+	for _, text := range texts {
+		for _, sentence := range splitBySentence(text) {
+			length := 0
+			if script == readabilityScriptCJK {
+				length = countReadableRunes(sentence)
+				metrics.Characters += length
+			} else {
+				words := readabilityWords(sentence)
+				length = len(words)
+				metrics.Words += length
+				for _, word := range words {
+					metrics.Characters += len([]rune(word))
+					metrics.Syllables += countSyllables(word)
+				}
+			}
+			if length == 0 {
+				continue
+			}
+			lengths = append(lengths, length)
+		}
+	}
+
+	metrics.Sentences = len(lengths)
+	if metrics.Sentences == 0 {
+		return metrics
+	}
+
+	total := 0
+	for _, length := range lengths {
+		total += length
+		if length > metrics.MaxSentenceLength {
+			metrics.MaxSentenceLength = length
+		}
+	}
+	metrics.AverageSentenceLength = roundReadability(float64(total) / float64(metrics.Sentences))
+	metrics.P90SentenceLength = percentile(lengths, 90)
+
+	if script == readabilityScriptLatin && metrics.Words > 0 {
+		wordsPerSentence := float64(metrics.Words) / float64(metrics.Sentences)
+		syllablesPerWord := float64(metrics.Syllables) / float64(metrics.Words)
+		ease := roundReadability(206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord)
+		grade := roundReadability(0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59)
+		metrics.FleschReadingEase, metrics.FleschKincaidGrade = &ease, &grade
+	}
+	return metrics
+}
+
+func detectReadabilityScript(texts []string) string {
+	cjk := 0
+	letters := 0
+	for _, text := range texts {
+		for _, r := range text {
+			switch {
+			case isCJKRune(r):
+				cjk++
+			case unicode.IsLetter(r):
+				letters++
+			}
+		}
+	}
+	if cjk > 0 && cjk*2 >= letters {
+		return readabilityScriptCJK
+	}
+	return readabilityScriptLatin
+}
+
+func readabilityWords(sentence string) []string {
+	fields := strings.FieldsFunc(sentence, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+	words := make([]string, 0, len(fields))
+	for _, field := range fields {
+		for _, r := range field {
+			if unicode.IsLetter(r) {
+				words = append(words, field)
+				break
+			}
+		}
+	}
+	return words
+}
+
+func countReadableRunes(sentence string) int {
+	count := 0
+	for _, r := range sentence {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			count++
+		}
+	}
+	return count
+}
+
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+	if count > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+func roundReadability(value float64) float64 {
+	return math.Round(value*100) / 100
+}
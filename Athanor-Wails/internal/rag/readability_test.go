@@ -0,0 +1,94 @@
+package rag
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBuildReadabilityReportScoresEnglish(t *testing.T) {
+	book := Book{
+		Main: []Chapter{
+			{
+				ID:    "chapter-001",
+				Title: "One",
+				Order: 1,
+				Kind:  ChapterKindMain,
+				Blocks: []Block{
+					{Kind: BlockKindHeading, Text: "A heading that should be ignored", Level: 1},
+					{Kind: BlockKindParagraph, Text: "The cat sat on the mat.[^1] The dog ran to the park."},
+				},
+			},
+		},
+	}
+
+	report := BuildReadabilityReport(book)
+	if len(report.Chapters) != 1 {
+		t.Fatalf("expected one chapter, got %d", len(report.Chapters))
+	}
+	metrics := report.Chapters[0].Metrics
+	if metrics.Script != readabilityScriptLatin {
+		t.Fatalf("expected latin script, got %q", metrics.Script)
+	}
+	if metrics.Sentences != 2 || metrics.Words != 12 {
+		t.Fatalf("unexpected counts: %+v", metrics)
+	}
+	if metrics.AverageSentenceLength != 6 {
+		t.Fatalf("expected 6 words per sentence, got %v", metrics.AverageSentenceLength)
+	}
+	if metrics.FleschReadingEase == nil || *metrics.FleschReadingEase < 90 || *metrics.FleschKincaidGrade > 2 {
+		t.Fatalf("expected easy text scores, got %+v", metrics)
+	}
+	if report.Summary.Sentences != 2 {
+		t.Fatalf("expected summary over main chapters, got %+v", report.Summary)
+	}
+}
+
+func TestBuildReadabilityReportUsesSentenceStatsForCJK(t *testing.T) {
+	book := Book{
+		Main: []Chapter{
+			{
+				ID:     "chapter-001",
+				Title:  "第一章",
+				Order:  1,
+				Kind:   ChapterKindMain,
+				Blocks: []Block{{Kind: BlockKindParagraph, Text: "这是第一句。这是更长的第二句话！"}},
+			},
+		},
+		Back: []Chapter{
+			{
+				ID:     "chapter-002",
+				Title:  "附录",
+				Order:  2,
+				Kind:   ChapterKindBackMatter,
+				Blocks: []Block{{Kind: BlockKindParagraph, Text: "附录内容。"}},
+			},
+		},
+	}
+
+	report := BuildReadabilityReport(book)
+	metrics := report.Chapters[0].Metrics
+	if metrics.Script != readabilityScriptCJK {
+		t.Fatalf("expected cjk script, got %q", metrics.Script)
+	}
+	if metrics.Sentences != 2 || metrics.MaxSentenceLength != 9 {
+		t.Fatalf("unexpected sentence stats: %+v", metrics)
+	}
+	if metrics.FleschReadingEase != nil || metrics.FleschKincaidGrade != nil {
+		t.Fatalf("flesch scores should be omitted for cjk: %+v", metrics)
+	}
+	if report.Summary.Sentences != 2 {
+		t.Fatalf("summary should exclude backmatter, got %+v", report.Summary)
+	}
+}
+
+func TestReadabilityKeepsZeroFleschScore(t *testing.T) {
+	zero := 0.0
+	data, err := json.Marshal(ReadabilityMetrics{Script: readabilityScriptLatin, FleschReadingEase: &zero, FleschKincaidGrade: &zero})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"fleschReadingEase":0`) || !strings.Contains(string(data), `"fleschKincaidGrade":0`) {
+		t.Fatalf("a score of 0 should still be written: %s", data)
+	}
+}
@@ -5,51 +5,83 @@ import (
 	"strings"
 )
 
+const maxChapterHeadingLevel = 4
+
 type blockRenderOptions struct {
 	headingBase      int
 	includeSeparator bool
+	includeAnchor    bool
 }
 
 func RenderBookMarkdown(book Book) string {
 	var parts []string
-	parts = append(parts, "# "+safeTitle(book.Metadata.Title), "")
+	parts = append(parts, "# "+bookTitle(book), "")
+	if label := seriesLabel(book); label != "" {
+		parts = append(parts, label, "")
+	}
 
+	var endnotes []string
+	addChapter := func(chapter Chapter, forceTitle bool) {
+		doc := renderChapter(book, chapter, chapterHeadingLevel(chapter), forceTitle)
+		if book.notes == NotesBook && len(chapter.Footnotes) > 0 {
+			// Chapters number their notes from 1, so the labels get the
+			// chapter ID in front to stay unique in one notes section.
+			doc = prefixFootnoteRefs(doc, chapter, chapter.ID+"-")
+			endnotes = append(endnotes, "### "+displayChapterTitle(chapter), "")
+			for _, note := range chapter.Footnotes {
+				endnotes = append(endnotes, fmt.Sprintf("[^%s-%s]: %s", chapter.ID, note.Label, note.Content))
+			}
+			endnotes = append(endnotes, "")
+		}
+		parts = append(parts, doc)
+	}
 	for _, chapter := range book.Main {
-		parts = append(parts, renderChapter(chapter, 2, false))
+		addChapter(chapter, false)
 	}
 	for _, chapter := range book.Back {
-		parts = append(parts, renderChapter(chapter, 2, true))
+		addChapter(chapter, true)
+	}
+	if len(endnotes) > 0 {
+		parts = append(parts, "## "+book.text().Footnotes, "")
+		parts = append(parts, endnotes...)
 	}
-	return strings.TrimSpace(strings.Join(parts, "\n")) + "\n"
+	return resolveImages(strings.TrimSpace(strings.Join(parts, "\n")), book.images.main) + "\n"
 }
 
 func RenderChapterMarkdown(book Book) map[string]string {
 	out := map[string]string{}
-	all := append(append([]Chapter(nil), book.Main...), book.Back...)
-	for _, chapter := range all {
-		var parts []string
-		parts = append(parts, "# "+displayChapterTitle(chapter), "")
-		parts = append(parts, renderBlocks(chapter.Blocks, 2))
-		if len(chapter.Footnotes) > 0 {
-			parts = append(parts, "", "## 脚注", "")
-			for _, note := range chapter.Footnotes {
-				parts = append(parts, fmt.Sprintf("[^%s]: %s", note.Label, note.Content))
-			}
-		}
-		out[chapter.ID] = strings.TrimSpace(strings.Join(parts, "\n")) + "\n"
+	owners := anchorChapters(book)
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		out[chapter.ID] = renderChapterDoc(book, chapter, owners)
 	}
 	return out
 }
 
-func renderChapter(chapter Chapter, topLevel int, forceTitle bool) string {
+// renderChapterDoc renders one chapter as a standalone file. owners maps each
+// heading anchor to the chapter that holds it, see anchorChapters.
+func renderChapterDoc(book Book, chapter Chapter, owners map[string]string) string {
+	var parts []string
+	parts = append(parts, "# "+displayChapterTitle(chapter)+anchorTag(chapter.anchor), "")
+	parts = append(parts, renderBlocks(chapter.Blocks, 2))
+	if len(chapter.Footnotes) > 0 {
+		parts = append(parts, "", "## "+book.text().Footnotes, "")
+		for _, note := range chapter.Footnotes {
+			parts = append(parts, fmt.Sprintf("[^%s]: %s", note.Label, note.Content))
+		}
+	}
+	doc := resolveImages(strings.TrimSpace(strings.Join(parts, "\n")), book.images.nested) + "\n"
+	return relinkAnchors(doc, chapter.ID, owners, ".md", mdAnchorHrefRe)
+}
+
+func renderChapter(book Book, chapter Chapter, topLevel int, forceTitle bool) string {
 	var parts []string
 	title := displayChapterTitle(chapter)
 	if forceTitle || !sameMeaningfulTitle(chapter, title) {
-		parts = append(parts, strings.Repeat("#", topLevel)+" "+title, "")
+		parts = append(parts, strings.Repeat("#", topLevel)+" "+title+anchorTag(chapter.anchor), "")
 	}
 	parts = append(parts, renderBlocks(chapter.Blocks, topLevel+1))
-	if len(chapter.Footnotes) > 0 {
-		parts = append(parts, "", strings.Repeat("#", topLevel+1)+" 脚注", "")
+	if len(chapter.Footnotes) > 0 && book.notes != NotesBook {
+		parts = append(parts, "", strings.Repeat("#", topLevel+1)+" "+book.text().Footnotes, "")
 		for _, note := range chapter.Footnotes {
 			parts = append(parts, fmt.Sprintf("[^%s]: %s", note.Label, note.Content))
 		}
@@ -58,12 +90,41 @@ func renderChapter(chapter Chapter, topLevel int, forceTitle bool) string {
 	return strings.Join(parts, "\n")
 }
 
+// prefixFootnoteRefs puts prefix in front of every reference to one of the
+// chapter's footnotes. Code blocks are left alone, since [^n] there is code.
+func prefixFootnoteRefs(doc string, chapter Chapter, prefix string) string {
+	labels := make(map[string]bool, len(chapter.Footnotes))
+	for _, note := range chapter.Footnotes {
+		labels[note.Label] = true
+	}
+	lines := strings.Split(doc, "\n")
+	fenced := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		lines[i] = footnoteRefRe.ReplaceAllStringFunc(line, func(ref string) string {
+			label := ref[2 : len(ref)-1]
+			if !labels[label] {
+				return ref
+			}
+			return "[^" + prefix + label + "]"
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
 func renderBlocks(blocks []Block, headingBase int) string {
 	var parts []string
 	for _, block := range blocks {
 		lines := renderBlockLines(block, blockRenderOptions{
 			headingBase:      headingBase,
 			includeSeparator: true,
+			includeAnchor:    true,
 		})
 		if len(lines) == 0 {
 			continue
@@ -81,11 +142,21 @@ func renderBlockLines(block Block, opts blockRenderOptions) []string {
 		if level < opts.headingBase {
 			level = opts.headingBase
 		}
-		return []string{strings.Repeat("#", level) + " " + block.Text}
+		line := strings.Repeat("#", level) + " " + block.Text
+		if opts.includeAnchor {
+			line += anchorTag(block.Anchor)
+		}
+		return []string{line}
 	case BlockKindParagraph:
 		return []string{block.Text}
 	case BlockKindBlockquote:
 		return []string{"> " + block.Text}
+	case BlockKindCallout:
+		label := block.Label
+		if label == "" {
+			label = "NOTE"
+		}
+		return []string{"> [!" + label + "]", "> " + block.Text}
 	case BlockKindList:
 		lines := make([]string, 0, len(block.Items))
 		for index, item := range block.Items {
@@ -97,7 +168,7 @@ func renderBlockLines(block Block, opts blockRenderOptions) []string {
 		}
 		return lines
 	case BlockKindCode:
-		return []string{"```", block.Text, "```"}
+		return []string{"```" + block.Label, block.Text, "```"}
 	case BlockKindTable:
 		return renderTable(block.Rows)
 	case BlockKindSeparator:
@@ -129,10 +200,30 @@ func renderTable(rows [][]string) []string {
 	return lines
 }
 
-func safeTitle(title string) string {
-	title = strings.TrimSpace(title)
+// chapterHeadingLevel nests chapters by their NCX/nav depth so sections that
+// the TOC lists under a part stay navigable even when the XHTML itself uses
+// flat or missing headings.
+func chapterHeadingLevel(chapter Chapter) int {
+	level := 2
+	if chapter.Depth > 1 {
+		level += chapter.Depth - 1
+	}
+	return min(level, maxChapterHeadingLevel)
+}
+
+// anchorTag renders an explicit HTML anchor after a heading. Only headings
+// that a cross-reference points at carry one.
+func anchorTag(anchor string) string {
+	if anchor == "" {
+		return ""
+	}
+	return ` <a id="` + anchor + `"></a>`
+}
+
+func bookTitle(book Book) string {
+	title := strings.TrimSpace(book.Metadata.Title)
 	if title == "" {
-		return "未命名图书"
+		return book.text().UntitledBook
 	}
 	return title
 }
@@ -146,11 +237,20 @@ func displayChapterTitle(chapter Chapter) string {
 }
 
 func sameMeaningfulTitle(chapter Chapter, title string) bool {
-	for _, block := range chapter.Blocks {
+	return titleHeadingIndex(chapter, title) >= 0
+}
+
+// titleHeadingIndex returns the index of the first heading block when it
+// repeats the chapter title, or -1.
+func titleHeadingIndex(chapter Chapter, title string) int {
+	for index, block := range chapter.Blocks {
 		if block.Kind != BlockKindHeading {
 			continue
 		}
-		return normalizeInlineText(block.Text) == normalizeInlineText(title)
+		if normalizeInlineText(block.Text) == normalizeInlineText(title) {
+			return index
+		}
+		return -1
 	}
-	return false
+	return -1
 }
@@ -0,0 +1,114 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderBookAsciiDoc renders the book as a single AsciiDoc document.
+// Footnotes are inlined with the footnote:[] macro and callouts map onto the
+// matching admonition (NOTE, TIP, WARNING).
+func RenderBookAsciiDoc(book Book) string {
+	var parts []string
+	parts = append(parts, "= "+bookTitle(book))
+	if len(book.Metadata.Authors) > 0 {
+		parts = append(parts, strings.Join(book.Metadata.Authors, "; "))
+	}
+	if book.Metadata.Language != "" {
+		parts = append(parts, ":lang: "+book.Metadata.Language)
+	}
+	parts = append(parts, ":doctype: book", "")
+
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		level := chapterHeadingLevel(chapter)
+		notes := footnoteIndex(chapter)
+		title := displayChapterTitle(chapter)
+		if chapter.Kind == ChapterKindBackMatter {
+			parts = append(parts, "[appendix]")
+		}
+		parts = append(parts, strings.Repeat("=", level)+" "+asciiDocInline(title, nil), "")
+		skipTitle := sameMeaningfulTitle(chapter, title)
+		for _, block := range chapter.Blocks {
+			if skipTitle && block.Kind == BlockKindHeading {
+				skipTitle = false
+				continue
+			}
+			lines := renderAsciiDocBlock(block, level+1, notes)
+			if len(lines) == 0 {
+				continue
+			}
+			parts = append(parts, lines...)
+			parts = append(parts, "")
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n")) + "\n"
+}
+
+func renderAsciiDocBlock(block Block, headingBase int, notes map[string]string) []string {
+	switch block.Kind {
+	case BlockKindHeading:
+		level := min(max(headingBase+block.Level-1, headingBase), 6)
+		return []string{"[discrete]", strings.Repeat("=", level) + " " + asciiDocInline(block.Text, nil)}
+	case BlockKindParagraph:
+		return []string{asciiDocInline(block.Text, notes)}
+	case BlockKindBlockquote:
+		return []string{"____", asciiDocInline(block.Text, notes), "____"}
+	case BlockKindCallout:
+		label := block.Label
+		if label == "" {
+			label = "NOTE"
+		}
+		return []string{label + ": " + asciiDocInline(block.Text, notes)}
+	case BlockKindList:
+		marker := "*"
+		if block.Ordered {
+			marker = "."
+		}
+		lines := make([]string, 0, len(block.Items))
+		for _, item := range block.Items {
+			lines = append(lines, marker+" "+asciiDocInline(item, notes))
+		}
+		return lines
+	case BlockKindCode:
+		return []string{"----", block.Text, "----"}
+	case BlockKindTable:
+		if len(block.Rows) == 0 {
+			return nil
+		}
+		lines := []string{fmt.Sprintf("[cols=%d, options=header]", len(block.Rows[0])), "|==="}
+		for _, row := range block.Rows {
+			cells := make([]string, 0, len(row))
+			for _, cell := range row {
+				cells = append(cells, "| "+asciiDocInline(strings.ReplaceAll(cell, "|", "\\|"), notes))
+			}
+			lines = append(lines, strings.Join(cells, " "))
+		}
+		return append(lines, "|===")
+	case BlockKindSeparator:
+		return []string{"'''"}
+	default:
+		return nil
+	}
+}
+
+func asciiDocInline(text string, notes map[string]string) string {
+	var out strings.Builder
+	for _, span := range parseInline(text) {
+		switch span.kind {
+		case inlineStrongEmphasis:
+			out.WriteString("*_" + span.text + "_*")
+		case inlineStrong:
+			out.WriteString("*" + span.text + "*")
+		case inlineEmphasis:
+			out.WriteString("_" + span.text + "_")
+		case inlineFootnote:
+			if content, ok := notes[span.text]; ok {
+				out.WriteString("footnote:[" + strings.ReplaceAll(content, "]", "\\]") + "]")
+			}
+		case inlineImage:
+		default:
+			out.WriteString(span.text)
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
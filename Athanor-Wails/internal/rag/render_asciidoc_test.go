@@ -0,0 +1,50 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func docExportTestBook() Book {
+	return Book{
+		Metadata: Metadata{Title: "Export & Co", Authors: []string{"Ada"}, Language: "en", Publisher: "Pub", ISBN: "9780306406157"},
+		Main: []Chapter{{
+			ID:    "chapter-001",
+			Title: "One",
+			Kind:  ChapterKindMain,
+			Blocks: []Block{
+				{Kind: BlockKindHeading, Text: "One", Level: 1},
+				{Kind: BlockKindParagraph, Text: "Some **bold** and *italic* <text>.[^1]"},
+				{Kind: BlockKindList, Items: []string{"first", "second"}, Ordered: true},
+				{Kind: BlockKindCallout, Label: "TIP", Text: "Aside."},
+				{Kind: BlockKindTable, Rows: [][]string{{"A", "B"}, {"1", "2"}}},
+			},
+			Footnotes: []Footnote{{Label: "1", Content: "A note."}},
+		}},
+		Back: []Chapter{{
+			ID:     "chapter-002",
+			Title:  "Notes",
+			Kind:   ChapterKindBackMatter,
+			Blocks: []Block{{Kind: BlockKindParagraph, Text: "Back matter.", Align: AlignCenter}},
+		}},
+	}
+}
+
+func TestRenderBookAsciiDoc(t *testing.T) {
+	doc := RenderBookAsciiDoc(docExportTestBook())
+	for _, want := range []string{
+		"= Export & Co\nAda\n:lang: en",
+		"== One\n\nSome *bold* and _italic_ <text>.footnote:[A note.]",
+		". first\n. second",
+		"TIP: Aside.",
+		"|===",
+		"[appendix]\n== Notes",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Fatalf("expected %q in asciidoc:\n%s", want, doc)
+		}
+	}
+	if strings.Count(doc, "One") != 1 {
+		t.Fatalf("chapter title should not be repeated:\n%s", doc)
+	}
+}
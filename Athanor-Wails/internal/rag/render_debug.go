@@ -7,7 +7,7 @@ import (
 
 func RenderDebugMarkdown(book Book) string {
 	var parts []string
-	parts = append(parts, "# "+safeTitle(book.Metadata.Title), "")
+	parts = append(parts, "# "+bookTitle(book), "")
 	parts = append(parts, "## Debug", "")
 	parts = append(parts, fmt.Sprintf("- source_path: %s", book.Metadata.SourcePath))
 	parts = append(parts, fmt.Sprintf("- source_sha256: %s", book.Metadata.SourceSHA256))
@@ -22,7 +22,7 @@ func RenderDebugMarkdown(book Book) string {
 	for _, chapter := range book.Back {
 		parts = append(parts, renderDebugChapter(chapter, 2))
 	}
-	return strings.TrimSpace(strings.Join(parts, "\n")) + "\n"
+	return stripImages(strings.TrimSpace(strings.Join(parts, "\n"))) + "\n"
 }
 
 func renderDebugChapter(chapter Chapter, topLevel int) string {
@@ -32,6 +32,9 @@ func renderDebugChapter(chapter Chapter, topLevel int) string {
 	parts = append(parts, fmt.Sprintf("- order: %d", chapter.Order))
 	parts = append(parts, fmt.Sprintf("- kind: %s", chapter.Kind))
 	parts = append(parts, fmt.Sprintf("- source_ref: %s", chapter.SourceRef))
+	if chapter.Language != "" {
+		parts = append(parts, fmt.Sprintf("- language: %s", chapter.Language))
+	}
 	if chapter.ClassifyReason != "" {
 		parts = append(parts, fmt.Sprintf("- classify_reason: %s", chapter.ClassifyReason))
 	}
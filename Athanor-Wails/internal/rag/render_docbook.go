@@ -0,0 +1,144 @@
+package rag
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// RenderBookDocBook renders the book as a DocBook 5 document. Main chapters
+// become <chapter>, back matter becomes <appendix>; headings inside a
+// chapter are emitted as bridgeheads since the source rarely nests cleanly
+// enough to build real <section> trees.
+func RenderBookDocBook(book Book) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	lang := ""
+	if book.Metadata.Language != "" {
+		lang = fmt.Sprintf(` xml:lang="%s"`, xmlEscape(book.Metadata.Language))
+	}
+	fmt.Fprintf(&b, `<book xmlns="http://docbook.org/ns/docbook" version="5.0"%s>`+"\n", lang)
+	b.WriteString("  <info>\n")
+	fmt.Fprintf(&b, "    <title>%s</title>\n", xmlEscape(bookTitle(book)))
+	for _, author := range book.Metadata.Authors {
+		fmt.Fprintf(&b, "    <author><personname>%s</personname></author>\n", xmlEscape(author))
+	}
+	if book.Metadata.Publisher != "" {
+		fmt.Fprintf(&b, "    <publisher><publishername>%s</publishername></publisher>\n", xmlEscape(book.Metadata.Publisher))
+	}
+	if book.Metadata.ISBN != "" {
+		fmt.Fprintf(&b, "    <biblioid class=\"isbn\">%s</biblioid>\n", book.Metadata.ISBN)
+	}
+	b.WriteString("  </info>\n")
+
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		element := "chapter"
+		if chapter.Kind == ChapterKindBackMatter {
+			element = "appendix"
+		}
+		notes := footnoteIndex(chapter)
+		title := displayChapterTitle(chapter)
+		fmt.Fprintf(&b, "  <%s xml:id=\"%s\">\n", element, xmlEscape(chapter.ID))
+		fmt.Fprintf(&b, "    <title>%s</title>\n", docBookInline(title, nil))
+		skipTitle := sameMeaningfulTitle(chapter, title)
+		for _, block := range chapter.Blocks {
+			if skipTitle && block.Kind == BlockKindHeading {
+				skipTitle = false
+				continue
+			}
+			for _, line := range renderDocBookBlock(block, notes) {
+				b.WriteString("    " + line + "\n")
+			}
+		}
+		fmt.Fprintf(&b, "  </%s>\n", element)
+	}
+	b.WriteString("</book>\n")
+	return b.String()
+}
+
+func renderDocBookBlock(block Block, notes map[string]string) []string {
+	switch block.Kind {
+	case BlockKindHeading:
+		level := min(max(block.Level, 1), 5)
+		return []string{fmt.Sprintf(`<bridgehead renderas="sect%d">%s</bridgehead>`, level, docBookInline(block.Text, nil))}
+	case BlockKindParagraph:
+		return []string{"<para>" + docBookInline(block.Text, notes) + "</para>"}
+	case BlockKindBlockquote:
+		return []string{"<blockquote><para>" + docBookInline(block.Text, notes) + "</para></blockquote>"}
+	case BlockKindCallout:
+		element := "note"
+		switch block.Label {
+		case "TIP":
+			element = "tip"
+		case "WARNING":
+			element = "warning"
+		}
+		return []string{fmt.Sprintf("<%s><para>%s</para></%s>", element, docBookInline(block.Text, notes), element)}
+	case BlockKindList:
+		element := "itemizedlist"
+		if block.Ordered {
+			element = "orderedlist"
+		}
+		lines := []string{"<" + element + ">"}
+		for _, item := range block.Items {
+			lines = append(lines, "  <listitem><para>"+docBookInline(item, notes)+"</para></listitem>")
+		}
+		return append(lines, "</"+element+">")
+	case BlockKindCode:
+		return []string{"<programlisting>" + xmlEscape(block.Text) + "</programlisting>"}
+	case BlockKindTable:
+		if len(block.Rows) == 0 {
+			return nil
+		}
+		columns := len(block.Rows[0])
+		lines := []string{"<informaltable>", fmt.Sprintf(`  <tgroup cols="%d">`, columns)}
+		for index, row := range block.Rows {
+			if index == 0 {
+				lines = append(lines, "    <thead>")
+			} else if index == 1 {
+				lines = append(lines, "    <tbody>")
+			}
+			entries := make([]string, 0, columns)
+			for _, cell := range row {
+				entries = append(entries, "<entry>"+docBookInline(cell, notes)+"</entry>")
+			}
+			lines = append(lines, "      <row>"+strings.Join(entries, "")+"</row>")
+			if index == 0 {
+				lines = append(lines, "    </thead>")
+			}
+		}
+		if len(block.Rows) > 1 {
+			lines = append(lines, "    </tbody>")
+		} else {
+			lines = append(lines, "    <tbody><row>"+strings.Repeat("<entry/>", columns)+"</row></tbody>")
+		}
+		return append(lines, "  </tgroup>", "</informaltable>")
+	default:
+		return nil
+	}
+}
+
+func docBookInline(text string, notes map[string]string) string {
+	var out strings.Builder
+	for _, span := range parseInline(text) {
+		escaped := xmlEscape(span.text)
+		switch span.kind {
+		case inlineStrongEmphasis, inlineStrong:
+			out.WriteString(`<emphasis role="strong">` + escaped + "</emphasis>")
+		case inlineEmphasis:
+			out.WriteString("<emphasis>" + escaped + "</emphasis>")
+		case inlineFootnote:
+			if content, ok := notes[span.text]; ok {
+				out.WriteString("<footnote><para>" + xmlEscape(content) + "</para></footnote>")
+			}
+		case inlineImage:
+		default:
+			out.WriteString(escaped)
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func xmlEscape(s string) string {
+	return html.EscapeString(s)
+}
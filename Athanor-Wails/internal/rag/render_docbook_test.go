@@ -0,0 +1,37 @@
+package rag
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenderBookDocBook(t *testing.T) {
+	doc := RenderBookDocBook(docExportTestBook())
+
+	decoder := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			t.Fatalf("docbook is not well-formed: %v\n%s", err, doc)
+		}
+	}
+	for _, want := range []string{
+		"<title>Export &amp; Co</title>",
+		`<biblioid class="isbn">9780306406157</biblioid>`,
+		`<chapter xml:id="chapter-001">`,
+		`Some <emphasis role="strong">bold</emphasis> and <emphasis>italic</emphasis> &lt;text&gt;.<footnote><para>A note.</para></footnote>`,
+		"<orderedlist>",
+		"<tip><para>Aside.</para></tip>",
+		"<thead>",
+		`<appendix xml:id="chapter-002">`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Fatalf("expected %q in docbook:\n%s", want, doc)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package rag
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const docxMainNS = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// RenderBookDOCX renders the book as a Word document. The package uses the
+// paragraph style names pandoc writes (Title, Author, Heading1-6, BodyText,
+// BlockText, SourceCode, Compact, FootnoteText), so styles taken from a
+// pandoc reference.docx apply unchanged. A nil styles uses the built-in
+// style sheet. Footnotes become Word footnotes, lists use Word numbering and
+// images are left out, as in the other document exports.
+func RenderBookDOCX(book Book, styles []byte) ([]byte, error) {
+	w := &docxWriter{}
+	w.paragraph("Title", docxRuns(bookTitle(book), nil, nil))
+	if len(book.Metadata.Authors) > 0 {
+		w.paragraph("Author", docxRuns(strings.Join(book.Metadata.Authors, "; "), nil, nil))
+	}
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		level := chapterHeadingLevel(chapter) - 1
+		notes := footnoteIndex(chapter)
+		title := displayChapterTitle(chapter)
+		w.paragraph(fmt.Sprintf("Heading%d", level), docxRuns(title, nil, nil))
+		skipTitle := sameMeaningfulTitle(chapter, title)
+		for _, block := range chapter.Blocks {
+			if skipTitle && block.Kind == BlockKindHeading {
+				skipTitle = false
+				continue
+			}
+			w.block(block, level+1, notes)
+		}
+	}
+	if styles == nil {
+		styles = []byte(docxDefaultStyles())
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, part := range []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(docxContentTypes)},
+		{"_rels/.rels", []byte(docxPackageRels)},
+		{"docProps/core.xml", []byte(docxCoreProperties(book.Metadata, bookTitle(book)))},
+		{"docProps/app.xml", []byte(docxAppProperties(book.Metadata))},
+		{"word/_rels/document.xml.rels", []byte(docxDocumentRels)},
+		{"word/document.xml", []byte(w.document())},
+		{"word/styles.xml", styles},
+		{"word/numbering.xml", []byte(w.numbering())},
+		{"word/footnotes.xml", []byte(w.footnotesPart())},
+	} {
+		entry, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(part.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadDOCXReferenceStyles returns the style sheet of a reference .docx, such
+// as one made with "pandoc -o custom-reference.docx --print-default-data-file
+// reference.docx" and restyled in Word.
+func ReadDOCXReferenceStyles(path string) ([]byte, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 DOCX 参考文档失败: %w", err)
+	}
+	defer reader.Close()
+	for _, file := range reader.File {
+		if file.Name != "word/styles.xml" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("读取 DOCX 参考文档样式失败: %w", err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("读取 DOCX 参考文档样式失败: %w", err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("DOCX 参考文档缺少 word/styles.xml: %s", path)
+}
+
+type docxWriter struct {
+	body strings.Builder
+	// footnotes holds the footnote bodies in ID order; Word reserves IDs
+	// -1 and 0 for the separators, so the first note is 1.
+	footnotes []string
+	// orderedLists counts numbered lists, each of which gets its own
+	// numbering instance so it starts again at 1.
+	orderedLists int
+}
+
+func (w *docxWriter) paragraph(style, runs string) {
+	w.alignedParagraph(style, AlignDefault, runs)
+}
+
+func (w *docxWriter) alignedParagraph(style string, align TextAlign, runs string) {
+	w.body.WriteString("<w:p>")
+	if style != "" || align != AlignDefault {
+		w.body.WriteString("<w:pPr>")
+		if style != "" {
+			w.body.WriteString(`<w:pStyle w:val="` + style + `"/>`)
+		}
+		if align != AlignDefault {
+			w.body.WriteString(`<w:jc w:val="` + string(align) + `"/>`)
+		}
+		w.body.WriteString("</w:pPr>")
+	}
+	w.body.WriteString(runs + "</w:p>")
+}
+
+func (w *docxWriter) block(block Block, headingBase int, notes map[string]string) {
+	switch block.Kind {
+	case BlockKindHeading:
+		level := min(max(headingBase+block.Level-1, headingBase), 6)
+		w.paragraph(fmt.Sprintf("Heading%d", level), docxRuns(block.Text, nil, nil))
+	case BlockKindParagraph:
+		w.alignedParagraph("BodyText", block.Align, docxRuns(block.Text, notes, w))
+	case BlockKindBlockquote:
+		w.paragraph("BlockText", docxRuns(block.Text, notes, w))
+	case BlockKindCallout:
+		label := block.Label
+		if label == "" {
+			label = "NOTE"
+		}
+		w.paragraph("BlockText", docxRun(label+": ", "<w:b/>")+docxRuns(block.Text, notes, w))
+	case BlockKindList:
+		numID := 1
+		if block.Ordered {
+			w.orderedLists++
+			numID = w.orderedLists + 1
+		}
+		for _, item := range block.Items {
+			fmt.Fprintf(&w.body, `<w:p><w:pPr><w:pStyle w:val="Compact"/><w:numPr><w:ilvl w:val="0"/><w:numId w:val="%d"/></w:numPr></w:pPr>%s</w:p>`, numID, docxRuns(item, notes, w))
+		}
+	case BlockKindCode:
+		w.paragraph("SourceCode", docxRun(block.Text, ""))
+	case BlockKindTable:
+		w.table(block.Rows, notes)
+	}
+}
+
+func (w *docxWriter) table(rows [][]string, notes map[string]string) {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return
+	}
+	w.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="Table"/><w:tblW w:w="0" w:type="auto"/><w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		w.body.WriteString(`<w:` + side + ` w:val="single" w:sz="4" w:space="0" w:color="auto"/>`)
+	}
+	w.body.WriteString(`</w:tblBorders></w:tblPr><w:tblGrid>` + strings.Repeat("<w:gridCol/>", columns) + "</w:tblGrid>")
+	for index, row := range rows {
+		w.body.WriteString("<w:tr>")
+		if index == 0 {
+			w.body.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		for column := range columns {
+			cell := ""
+			if column < len(row) {
+				cell = row[column]
+			}
+			w.body.WriteString(`<w:tc><w:p><w:pPr><w:pStyle w:val="Compact"/></w:pPr>`)
+			for _, span := range parseInline(cell) {
+				w.body.WriteString(docxSpan(span, index == 0, notes, w))
+			}
+			w.body.WriteString("</w:p></w:tc>")
+		}
+		w.body.WriteString("</w:tr>")
+	}
+	w.body.WriteString("</w:tbl>")
+}
+
+// footnote stores a note body and returns the run that refers to it.
+func (w *docxWriter) footnote(content string) string {
+	w.footnotes = append(w.footnotes, content)
+	return fmt.Sprintf(`<w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteReference w:id="%d"/></w:r>`, len(w.footnotes))
+}
+
+func (w *docxWriter) document() string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<w:document xmlns:w="` + docxMainNS + `"><w:body>` + w.body.String() + "</w:body></w:document>"
+}
+
+func (w *docxWriter) footnotesPart() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<w:footnotes xmlns:w="` + docxMainNS + `">`)
+	b.WriteString(`<w:footnote w:type="separator" w:id="-1"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>`)
+	b.WriteString(`<w:footnote w:type="continuationSeparator" w:id="0"><w:p><w:r><w:continuationSeparator/></w:r></w:p></w:footnote>`)
+	for index, content := range w.footnotes {
+		fmt.Fprintf(&b, `<w:footnote w:id="%d"><w:p><w:pPr><w:pStyle w:val="FootnoteText"/></w:pPr>`, index+1)
+		b.WriteString(`<w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteRef/></w:r>`)
+		b.WriteString(docxRun(" ", "") + docxRuns(content, nil, nil) + "</w:p></w:footnote>")
+	}
+	b.WriteString("</w:footnotes>")
+	return b.String()
+}
+
+// numbering defines one bullet list shared by every unordered list and one
+// decimal instance per ordered list.
+func (w *docxWriter) numbering() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<w:numbering xmlns:w="` + docxMainNS + `">`)
+	for id, format := range []struct{ numFmt, text string }{{"bullet", "•"}, {"decimal", "%1."}} {
+		fmt.Fprintf(&b, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="singleLevel"/>`, id)
+		fmt.Fprintf(&b, `<w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/>`, format.numFmt, format.text)
+		b.WriteString(`<w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:lvl></w:abstractNum>`)
+	}
+	b.WriteString(`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>`)
+	for list := range w.orderedLists {
+		fmt.Fprintf(&b, `<w:num w:numId="%d"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="0"><w:startOverride w:val="1"/></w:lvlOverride></w:num>`, list+2)
+	}
+	b.WriteString("</w:numbering>")
+	return b.String()
+}
+
+// docxRuns turns block text into runs, translating the inline Markdown the
+// chapter builder emits. Footnote references become Word footnotes when w
+// is set and the chapter has the note.
+func docxRuns(text string, notes map[string]string, w *docxWriter) string {
+	var out strings.Builder
+	for _, span := range parseInline(text) {
+		out.WriteString(docxSpan(span, false, notes, w))
+	}
+	return out.String()
+}
+
+// docxSpan writes one inline span; bold sets the whole span in bold, as in
+// table header cells.
+func docxSpan(span inlineSpan, bold bool, notes map[string]string, w *docxWriter) string {
+	italic := false
+	switch span.kind {
+	case inlineStrongEmphasis:
+		bold, italic = true, true
+	case inlineStrong:
+		bold = true
+	case inlineEmphasis:
+		italic = true
+	case inlineFootnote:
+		if content, ok := notes[span.text]; ok && w != nil {
+			return w.footnote(content)
+		}
+		return ""
+	case inlineImage:
+		return ""
+	}
+	rPr := ""
+	if bold {
+		rPr += "<w:b/>"
+	}
+	if italic {
+		rPr += "<w:i/>"
+	}
+	return docxRun(span.text, rPr)
+}
+
+// docxRun writes text as one run, turning line breaks into <w:br/>.
+func docxRun(text, rPr string) string {
+	if text == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<w:r>")
+	if rPr != "" {
+		b.WriteString("<w:rPr>" + rPr + "</w:rPr>")
+	}
+	for index, line := range strings.Split(text, "\n") {
+		if index > 0 {
+			b.WriteString("<w:br/>")
+		}
+		if line != "" {
+			b.WriteString(`<w:t xml:space="preserve">` + xmlEscape(line) + "</w:t>")
+		}
+	}
+	b.WriteString("</w:r>")
+	return b.String()
+}
+
+func docxCoreProperties(metadata Metadata, title string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">`)
+	b.WriteString("<dc:title>" + xmlEscape(title) + "</dc:title>")
+	if len(metadata.Authors) > 0 {
+		b.WriteString("<dc:creator>" + xmlEscape(strings.Join(metadata.Authors, "; ")) + "</dc:creator>")
+	}
+	if identifier := firstNonEmpty(isbnURN(metadata.ISBN), metadata.Identifier); identifier != "" {
+		b.WriteString("<dc:identifier>" + xmlEscape(identifier) + "</dc:identifier>")
+	}
+	if metadata.Language != "" {
+		b.WriteString("<dc:language>" + xmlEscape(metadata.Language) + "</dc:language>")
+	}
+	b.WriteString("</cp:coreProperties>")
+	return b.String()
+}
+
+// docxAppProperties records the publisher as the document's company, the
+// closest field Word shows; the core properties have none.
+func docxAppProperties(metadata Metadata) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"><Application>Athanor</Application>`)
+	if metadata.Publisher != "" {
+		b.WriteString("<Company>" + xmlEscape(metadata.Publisher) + "</Company>")
+	}
+	b.WriteString("</Properties>")
+	return b.String()
+}
+
+// docxDefaultStyles is the built-in style sheet, a plain version of the
+// styles pandoc's default reference.docx defines.
+func docxDefaultStyles() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<w:styles xmlns:w="` + docxMainNS + `">`)
+	b.WriteString(`<w:docDefaults><w:rPrDefault><w:rPr><w:sz w:val="24"/></w:rPr></w:rPrDefault>`)
+	b.WriteString(`<w:pPrDefault><w:pPr><w:spacing w:after="120"/></w:pPr></w:pPrDefault></w:docDefaults>`)
+	b.WriteString(`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>`)
+	for _, style := range []struct{ id, name, pPr, rPr string }{
+		{"BodyText", "Body Text", `<w:spacing w:before="180" w:after="180"/>`, ""},
+		{"Title", "Title", `<w:spacing w:before="480" w:after="240"/><w:jc w:val="center"/>`, `<w:b/><w:sz w:val="48"/>`},
+		{"Author", "Author", `<w:jc w:val="center"/>`, ""},
+		{"BlockText", "Block Text", `<w:ind w:left="720" w:right="720"/>`, `<w:i/>`},
+		{"SourceCode", "Source Code", `<w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/>`, `<w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:sz w:val="20"/>`},
+		{"Compact", "Compact", `<w:spacing w:before="36" w:after="36"/>`, ""},
+		{"FootnoteText", "Footnote Text", "", `<w:sz w:val="20"/>`},
+	} {
+		fmt.Fprintf(&b, `<w:style w:type="paragraph" w:customStyle="1" w:styleId="%s"><w:name w:val="%s"/><w:basedOn w:val="Normal"/><w:qFormat/>`, style.id, style.name)
+		b.WriteString("<w:pPr>" + style.pPr + "</w:pPr><w:rPr>" + style.rPr + "</w:rPr></w:style>")
+	}
+	for level := 1; level <= 6; level++ {
+		fmt.Fprintf(&b, `<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/><w:next w:val="BodyText"/><w:qFormat/>`, level, level)
+		fmt.Fprintf(&b, `<w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="%d"/></w:pPr>`, level-1)
+		fmt.Fprintf(&b, `<w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>`, max(40-4*level, 24))
+	}
+	b.WriteString(`<w:style w:type="character" w:styleId="FootnoteReference"><w:name w:val="footnote reference"/><w:rPr><w:vertAlign w:val="superscript"/></w:rPr></w:style>`)
+	b.WriteString(`<w:style w:type="table" w:default="1" w:styleId="Table"><w:name w:val="Table"/><w:tblPr><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>`)
+	b.WriteString("</w:styles>")
+	return b.String()
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+	`<Override PartName="/word/footnotes.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footnotes+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+	`<Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/>` +
+	`</Types>`
+
+const docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+	`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/>` +
+	`</Relationships>`
+
+const docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>` +
+	`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footnotes" Target="footnotes.xml"/>` +
+	`</Relationships>`
@@ -0,0 +1,117 @@
+package rag
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readDOCXParts(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("docx is not a zip: %v", err)
+	}
+	parts := map[string]string{}
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				t.Fatalf("%s is not well-formed: %v\n%s", file.Name, err, content)
+			}
+		}
+		parts[file.Name] = string(content)
+	}
+	return parts
+}
+
+func TestRenderBookDOCX(t *testing.T) {
+	data, err := RenderBookDOCX(docExportTestBook(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := readDOCXParts(t, data)
+	for part, wants := range map[string][]string{
+		"word/document.xml": {
+			`<w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">Export &amp; Co</w:t></w:r>`,
+			`<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">One</w:t>`,
+			`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">bold</w:t></w:r>`,
+			`&lt;text&gt;.</w:t></w:r><w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteReference w:id="1"/></w:r>`,
+			`<w:numId w:val="2"/>`,
+			`<w:t xml:space="preserve">TIP: </w:t>`,
+			`<w:tblHeader/>`,
+			`<w:pStyle w:val="BodyText"/><w:jc w:val="center"/></w:pPr><w:r><w:t xml:space="preserve">Back matter.</w:t>`,
+		},
+		"word/footnotes.xml": {`<w:footnote w:id="1">`, "A note."},
+		"word/numbering.xml": {`<w:num w:numId="2"><w:abstractNumId w:val="1"/>`},
+		"word/styles.xml":    {`w:styleId="Heading1"`, `w:styleId="SourceCode"`},
+		"docProps/core.xml":  {"<dc:creator>Ada</dc:creator>", "<dc:identifier>urn:isbn:9780306406157</dc:identifier>", "<dc:language>en</dc:language>"},
+		"docProps/app.xml":   {"<Company>Pub</Company>"},
+	} {
+		for _, want := range wants {
+			if !strings.Contains(parts[part], want) {
+				t.Fatalf("expected %q in %s:\n%s", want, part, parts[part])
+			}
+		}
+	}
+	if strings.Count(parts["word/document.xml"], ">One<") != 1 {
+		t.Fatalf("chapter title should not be repeated:\n%s", parts["word/document.xml"])
+	}
+}
+
+func TestRenderBookDOCXUsesReferenceStyles(t *testing.T) {
+	dir := testOutputDir(t, "docx-reference")
+	reference := filepath.Join(dir, "reference.docx")
+	styles := `<?xml version="1.0" encoding="UTF-8"?><w:styles xmlns:w="` + docxMainNS + `"><w:style w:type="paragraph" w:styleId="BodyText"><w:name w:val="Body Text"/></w:style></w:styles>`
+	file, err := os.Create(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(file)
+	entry, err := archive.Create("word/styles.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := entry.Write([]byte(styles)); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := ReadDOCXReferenceStyles(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := RenderBookDOCX(docExportTestBook(), loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readDOCXParts(t, data)["word/styles.xml"]; got != styles {
+		t.Fatalf("expected the reference styles, got:\n%s", got)
+	}
+	if _, err := ReadDOCXReferenceStyles(filepath.Join(dir, "missing.docx")); err == nil {
+		t.Fatal("expected a missing reference document to fail")
+	}
+}
@@ -0,0 +1,396 @@
+package rag
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const htmlReaderCSS = `:root { --bg: #fdfcf8; --fg: #1f1f1f; --muted: #6b6b6b; --accent: #8a5a00; --rule: #e4e0d6; --size: 18px; }
+:root[data-theme="dark"] { --bg: #121214; --fg: #e6e3dc; --muted: #9a968e; --accent: #e0b060; --rule: #2c2c30; }
+:root[data-theme="dark"] img { filter: brightness(.8) contrast(1.1); }
+* { box-sizing: border-box; }
+body { margin: 0; background: var(--bg); color: var(--fg); font: var(--size)/1.75 Georgia, "Noto Serif CJK SC", "Source Han Serif SC", serif; }
+header, nav.pager { display: flex; gap: .75rem; align-items: center; justify-content: space-between; max-width: 46rem; margin: 0 auto; padding: 1rem 1.25rem; font-size: .85rem; color: var(--muted); }
+header .controls button { background: none; border: 1px solid var(--rule); color: var(--fg); border-radius: 4px; padding: .2rem .55rem; cursor: pointer; }
+main { max-width: 46rem; margin: 0 auto; padding: 0 1.25rem 2rem; }
+a { color: var(--accent); }
+blockquote, aside.callout { margin-block: 1.25rem; margin-inline: 0; padding-block: .25rem; padding-inline: 1rem; border-inline-start: 3px solid var(--rule); color: var(--muted); }
+aside.callout strong { display: block; font-size: .8rem; letter-spacing: .05em; }
+pre { overflow-x: auto; padding: .75rem; border: 1px solid var(--rule); font-size: .85em; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid var(--rule); padding: .3rem .6rem; }
+hr { border: 0; border-block-start: 1px solid var(--rule); margin-block: 2rem; margin-inline: 0; }
+ol.toc { padding-inline-start: 1.25rem; }
+ol.toc li.depth-2 { margin-inline-start: 1.25rem; }
+ol.toc li.depth-3 { margin-inline-start: 2.5rem; }
+p.align-center { text-align: center; }
+p.align-right { text-align: right; }
+figure.cover { margin: 1rem 0 2rem; text-align: center; }
+figure.cover img { max-width: 100%; max-height: 85vh; }
+section.footnotes { margin-block-start: 2.5rem; border-block-start: 1px solid var(--rule); font-size: .85em; }
+:root[data-writing="vertical"] main { writing-mode: vertical-rl; max-width: none; height: calc(100vh - 9rem); overflow-x: auto; padding: 0 1.25rem; }
+:root[data-writing="vertical"] pre, :root[data-writing="vertical"] table { writing-mode: horizontal-tb; }
+:root[data-font="readable"] body { font-family: "Atkinson Hyperlegible", OpenDyslexic, Verdana, "Noto Sans CJK SC", "Source Han Sans SC", sans-serif; line-height: 1.9; letter-spacing: .04em; word-spacing: .16em; }
+:root[data-font="readable"] main { text-align: start; hyphens: none; }
+`
+
+const htmlReaderJS = `(function () {
+  var root = document.documentElement;
+  var theme = localStorage.getItem("athanor-theme");
+  var size = parseInt(localStorage.getItem("athanor-size") || "18", 10);
+  var writing = localStorage.getItem("athanor-writing");
+  var font = localStorage.getItem("athanor-font");
+  if (theme) root.dataset.theme = theme;
+  if (writing) root.dataset.writing = writing;
+  if (font) root.dataset.font = font;
+  root.style.setProperty("--size", size + "px");
+  document.addEventListener("click", function (event) {
+    var action = event.target && event.target.dataset && event.target.dataset.action;
+    if (action === "theme") {
+      root.dataset.theme = root.dataset.theme === "dark" ? "light" : "dark";
+      localStorage.setItem("athanor-theme", root.dataset.theme);
+    } else if (action === "writing") {
+      root.dataset.writing = root.dataset.writing === "vertical" ? "horizontal" : "vertical";
+      localStorage.setItem("athanor-writing", root.dataset.writing);
+    } else if (action === "font") {
+      root.dataset.font = root.dataset.font === "readable" ? "default" : "readable";
+      localStorage.setItem("athanor-font", root.dataset.font);
+    } else if (action === "smaller" || action === "larger") {
+      size = Math.min(28, Math.max(12, size + (action === "larger" ? 2 : -2)));
+      root.style.setProperty("--size", size + "px");
+      localStorage.setItem("athanor-size", String(size));
+    }
+  });
+  document.addEventListener("keydown", function (event) {
+    var link = event.key === "ArrowLeft" ? document.querySelector("a[rel=prev]") : event.key === "ArrowRight" ? document.querySelector("a[rel=next]") : null;
+    if (link) location.href = link.href;
+  });
+})();
+`
+
+// MaxTOCDepth is the deepest navigation level Options.TOCDepth can select.
+const MaxTOCDepth = 4
+
+// applyHTMLOptions copies the options that only affect the HTML reader onto
+// the book before it is rendered.
+func (book *Book) applyHTMLOptions(options Options) {
+	book.verticalText = options.VerticalHTML
+	book.readableFont = options.ReadableHTML
+	book.darkTheme = options.DarkHTML
+	book.direction = options.Direction
+	book.tocDepth = options.TOCDepth
+	if options.NoTOC {
+		book.tocDepth = -1
+	}
+}
+
+// RenderBookHTML renders a self-contained, multi-page HTML reader: an index
+// page with the cover and the table of contents, one page per chapter with previous/next
+// navigation, and a shared stylesheet and script for the light/dark toggle,
+// font size controls and the vertical (tategaki) writing toggle. The result
+// maps file names to contents.
+func RenderBookHTML(book Book) map[string]string {
+	chapters := append(append([]Chapter(nil), book.Main...), book.Back...)
+	title := bookTitle(book)
+	pages := map[string]string{
+		"style.css": htmlReaderCSS,
+		"reader.js": htmlReaderJS,
+	}
+	owners := anchorChapters(book)
+
+	var toc strings.Builder
+	if len(book.coverData) > 0 {
+		name := coverFileName(book.Metadata.CoverImage)
+		pages[name] = string(book.coverData)
+		fmt.Fprintf(&toc, "<figure class=\"cover\"><img src=\"%s\" alt=\"%s\"></figure>\n", name, book.text().Cover)
+	}
+	fmt.Fprintf(&toc, "<h1>%s</h1>\n", html.EscapeString(title))
+	if len(book.Metadata.Authors) > 0 {
+		fmt.Fprintf(&toc, "<p>%s</p>\n", html.EscapeString(strings.Join(book.Metadata.Authors, " / ")))
+	}
+	if label := seriesLabel(book); label != "" {
+		fmt.Fprintf(&toc, "<p>%s</p>\n", html.EscapeString(label))
+	}
+	if book.tocDepth >= 0 {
+		fmt.Fprintf(&toc, "<nav role=\"doc-toc\" aria-label=\"%s\">\n<ol class=\"toc\">\n", book.text().Contents)
+		for _, chapter := range chapters {
+			depth := max(chapter.Depth, 1)
+			if book.tocDepth > 0 && depth > book.tocDepth {
+				continue
+			}
+			fmt.Fprintf(&toc, "<li class=\"depth-%d\"><a href=\"%s\">%s</a></li>\n",
+				depth, htmlChapterFile(chapter), html.EscapeString(displayChapterTitle(chapter)))
+		}
+		toc.WriteString("</ol>\n</nav>\n")
+	}
+	next := ""
+	if len(chapters) > 0 {
+		next = htmlChapterFile(chapters[0])
+	}
+	pages["index.html"] = htmlPage(book, title, toc.String(), "", next)
+
+	for index, chapter := range chapters {
+		prev, next := "index.html", ""
+		if index > 0 {
+			prev = htmlChapterFile(chapters[index-1])
+		}
+		if index+1 < len(chapters) {
+			next = htmlChapterFile(chapters[index+1])
+		}
+		body := relinkAnchors(renderHTMLChapter(book, chapter), chapter.ID, owners, ".html", htmlAnchorHrefRe)
+		body = resolveHTMLImages(body, book.images.nested)
+		pages[htmlChapterFile(chapter)] = htmlPage(book, displayChapterTitle(chapter), body, prev, next)
+	}
+	return pages
+}
+
+var (
+	htmlAnchorHrefRe = regexp.MustCompile(`href="#([^"]+)"`)
+	htmlImageRe      = regexp.MustCompile(`<img src="epub-image:([^"]+)" alt="[^"]*">`)
+)
+
+// resolveHTMLImages points image tags at their resolved targets and drops
+// the ones without a target.
+func resolveHTMLImages(body string, targets map[string]string) string {
+	return htmlImageRe.ReplaceAllStringFunc(body, func(match string) string {
+		source := html.UnescapeString(htmlImageRe.FindStringSubmatch(match)[1])
+		target, ok := targets[source]
+		if !ok {
+			return ""
+		}
+		target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+		return strings.Replace(match, imageScheme+html.EscapeString(source), html.EscapeString(target), 1)
+	})
+}
+
+func htmlChapterFile(chapter Chapter) string {
+	return sanitizePathComponent(chapter.ID) + ".html"
+}
+
+func htmlPage(book Book, title, body, prev, next string) string {
+	lang := book.Metadata.Language
+	if lang == "" {
+		lang = "und"
+	}
+	writing := "horizontal"
+	if book.verticalText {
+		writing = "vertical"
+	}
+	var modes string
+	if book.readableFont {
+		modes += ` data-font="readable"`
+	}
+	if book.darkTheme {
+		modes += ` data-theme="dark"`
+	}
+	dir := book.direction
+	if dir == DirectionAuto {
+		dir = DirectionLTR
+		if rtlLanguage(book.Metadata.Language) {
+			dir = DirectionRTL
+		}
+	}
+	labels := book.text()
+	var pager strings.Builder
+	fmt.Fprintf(&pager, "<nav class=\"pager\" aria-label=\"%s\">", labels.Navigation)
+	if prev != "" {
+		fmt.Fprintf(&pager, "<a rel=\"prev\" href=\"%s\">%s</a>", prev, labels.Previous)
+	} else {
+		pager.WriteString("<span></span>")
+	}
+	fmt.Fprintf(&pager, "<a href=\"index.html\">%s</a>", labels.Contents)
+	if next != "" {
+		fmt.Fprintf(&pager, "<a rel=\"next\" href=\"%s\">%s</a>", next, labels.Next)
+	} else {
+		pager.WriteString("<span></span>")
+	}
+	pager.WriteString("</nav>")
+
+	// The buttons show symbols, so each carries a label for screen readers
+	// and a tooltip.
+	var controls []string
+	for _, control := range []struct{ action, symbol, label string }{
+		{"smaller", "A-", labels.Smaller},
+		{"larger", "A+", labels.Larger},
+		{"writing", "縦", labels.Writing},
+		{"font", "Aa", labels.Font},
+		{"theme", "◐", labels.Theme},
+	} {
+		controls = append(controls, fmt.Sprintf("<button data-action=\"%s\" aria-label=\"%s\" title=\"%s\">%s</button>",
+			control.action, control.label, control.label, control.symbol))
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="%s" dir="%s" data-writing="%s"%s>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+%s<link rel="stylesheet" href="style.css">
+<script src="reader.js" defer></script>
+</head>
+<body>
+<header><span>%s</span><span class="controls">%s</span></header>
+<main>
+%s</main>
+%s
+</body>
+</html>
+`, html.EscapeString(lang), dir, writing, modes, html.EscapeString(title), htmlMetaTags(book.Metadata), html.EscapeString(bookTitle(book)), strings.Join(controls, " "), body, pager.String())
+}
+
+// htmlMetaTags carries the book's author, publisher and ISBN as document
+// properties, with Dublin Core names where HTML has no standard one.
+func htmlMetaTags(metadata Metadata) string {
+	var b strings.Builder
+	for _, meta := range []struct{ name, content string }{
+		{"author", strings.Join(metadata.Authors, ", ")},
+		{"DC.publisher", metadata.Publisher},
+		{"DC.identifier", isbnURN(metadata.ISBN)},
+	} {
+		if meta.content != "" {
+			fmt.Fprintf(&b, "<meta name=\"%s\" content=\"%s\">\n", meta.name, html.EscapeString(meta.content))
+		}
+	}
+	return b.String()
+}
+
+// isbnURN writes an ISBN as the URN the EPUB and Dublin Core identifiers
+// use; an empty ISBN stays empty.
+func isbnURN(isbn string) string {
+	if isbn == "" {
+		return ""
+	}
+	return "urn:isbn:" + isbn
+}
+
+// renderHTMLChapter renders a chapter body. A chapter in another language
+// than the book is wrapped in an element carrying its lang, so screen
+// readers switch voices.
+func renderHTMLChapter(book Book, chapter Chapter) string {
+	var b strings.Builder
+	foreign := chapter.Language != "" && chapter.Language != book.Metadata.Language
+	if foreign {
+		fmt.Fprintf(&b, "<article lang=\"%s\">\n", html.EscapeString(chapter.Language))
+	}
+	title := displayChapterTitle(chapter)
+	titleIndex := titleHeadingIndex(chapter, title)
+	skipTitle := titleIndex >= 0
+	anchor := chapter.anchor
+	if skipTitle {
+		anchor = chapter.Blocks[titleIndex].Anchor
+	}
+	fmt.Fprintf(&b, "<h1%s>%s</h1>\n", htmlID(anchor), htmlInline(title))
+	for _, block := range chapter.Blocks {
+		if skipTitle && block.Kind == BlockKindHeading {
+			skipTitle = false
+			continue
+		}
+		b.WriteString(renderHTMLBlock(block))
+	}
+	if len(chapter.Footnotes) > 0 {
+		fmt.Fprintf(&b, "<section class=\"footnotes\" role=\"doc-endnotes\" aria-label=\"%s\">\n", book.text().Footnotes)
+		for _, note := range chapter.Footnotes {
+			label := html.EscapeString(note.Label)
+			fmt.Fprintf(&b, "<p id=\"fn-%s\"><sup>%s</sup> %s <a href=\"#fnref-%s\" role=\"doc-backlink\" aria-label=\"%s\">↩</a></p>\n",
+				label, label, htmlInline(note.Content), label, book.text().BackToText)
+		}
+		b.WriteString("</section>\n")
+	}
+	if foreign {
+		b.WriteString("</article>\n")
+	}
+	return b.String()
+}
+
+func renderHTMLBlock(block Block) string {
+	switch block.Kind {
+	case BlockKindHeading:
+		level := min(max(block.Level+1, 2), 6)
+		return fmt.Sprintf("<h%d%s>%s</h%d>\n", level, htmlID(block.Anchor), htmlInline(block.Text), level)
+	case BlockKindParagraph:
+		if block.Align != AlignDefault {
+			return "<p class=\"align-" + string(block.Align) + "\">" + htmlInline(block.Text) + "</p>\n"
+		}
+		return "<p>" + htmlInline(block.Text) + "</p>\n"
+	case BlockKindBlockquote:
+		return "<blockquote><p>" + htmlInline(block.Text) + "</p></blockquote>\n"
+	case BlockKindCallout:
+		label := block.Label
+		if label == "" {
+			label = "NOTE"
+		}
+		return fmt.Sprintf("<aside class=\"callout %s\"><strong>%s</strong>%s</aside>\n",
+			strings.ToLower(label), label, htmlInline(block.Text))
+	case BlockKindList:
+		element := "ul"
+		if block.Ordered {
+			element = "ol"
+		}
+		var b strings.Builder
+		b.WriteString("<" + element + ">\n")
+		for _, item := range block.Items {
+			b.WriteString("<li>" + htmlInline(item) + "</li>\n")
+		}
+		b.WriteString("</" + element + ">\n")
+		return b.String()
+	case BlockKindCode:
+		return "<pre><code>" + html.EscapeString(block.Text) + "</code></pre>\n"
+	case BlockKindTable:
+		if len(block.Rows) == 0 {
+			return ""
+		}
+		var b strings.Builder
+		b.WriteString("<table>\n")
+		for index, row := range block.Rows {
+			cell := "td"
+			if index == 0 {
+				cell = "th"
+			}
+			b.WriteString("<tr>")
+			for _, value := range row {
+				fmt.Fprintf(&b, "<%s>%s</%s>", cell, htmlInline(value), cell)
+			}
+			b.WriteString("</tr>\n")
+		}
+		b.WriteString("</table>\n")
+		return b.String()
+	case BlockKindSeparator:
+		return "<hr>\n"
+	default:
+		return ""
+	}
+}
+
+func htmlInline(text string) string {
+	var out strings.Builder
+	for _, span := range parseInline(text) {
+		escaped := html.EscapeString(span.text)
+		switch span.kind {
+		case inlineStrongEmphasis:
+			out.WriteString("<strong><em>" + escaped + "</em></strong>")
+		case inlineStrong:
+			out.WriteString("<strong>" + escaped + "</strong>")
+		case inlineEmphasis:
+			out.WriteString("<em>" + escaped + "</em>")
+		case inlineFootnote:
+			fmt.Fprintf(&out, "<sup><a id=\"fnref-%s\" href=\"#fn-%s\" role=\"doc-noteref\">%s</a></sup>", escaped, escaped, escaped)
+		case inlineImage:
+			fmt.Fprintf(&out, "<img src=\"%s%s\" alt=\"%s\">", imageScheme, html.EscapeString(span.target), escaped)
+		case inlineLink:
+			fmt.Fprintf(&out, "<a href=\"#%s\">%s</a>", html.EscapeString(span.target), escaped)
+		default:
+			out.WriteString(escaped)
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func htmlID(anchor string) string {
+	if anchor == "" {
+		return ""
+	}
+	return ` id="` + html.EscapeString(anchor) + `"`
+}
@@ -0,0 +1,144 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderBookHTML(t *testing.T) {
+	pages := RenderBookHTML(docExportTestBook())
+
+	for _, name := range []string{"index.html", "style.css", "reader.js", "chapter-001.html", "chapter-002.html"} {
+		if _, ok := pages[name]; !ok {
+			t.Fatalf("missing page %s", name)
+		}
+	}
+	if !strings.Contains(pages["index.html"], `<a href="chapter-001.html">One</a>`) {
+		t.Fatalf("index should link chapters:\n%s", pages["index.html"])
+	}
+
+	chapter := pages["chapter-001.html"]
+	for _, want := range []string{
+		`<html lang="en" dir="ltr" data-writing="horizontal">`,
+		`<meta name="author" content="Ada">`,
+		`<meta name="DC.publisher" content="Pub">`,
+		`<meta name="DC.identifier" content="urn:isbn:9780306406157">`,
+		"<p>Some <strong>bold</strong> and <em>italic</em> &lt;text&gt;.<sup><a id=\"fnref-1\" href=\"#fn-1\" role=\"doc-noteref\">1</a></sup></p>",
+		`<aside class="callout tip"><strong>TIP</strong>Aside.</aside>`,
+		`<p id="fn-1"><sup>1</sup> A note.`,
+		`<a rel="prev" href="index.html">`,
+		`<a rel="next" href="chapter-002.html">`,
+		`data-action="theme"`,
+		`data-action="writing"`,
+	} {
+		if !strings.Contains(chapter, want) {
+			t.Fatalf("expected %q in chapter page:\n%s", want, chapter)
+		}
+	}
+	if strings.Count(chapter, "<h1>One</h1>") != 1 || strings.Contains(chapter, "<h2>One</h2>") {
+		t.Fatalf("chapter title should appear once:\n%s", chapter)
+	}
+	if strings.Contains(pages["chapter-002.html"], `rel="next"`) {
+		t.Fatal("last chapter should not link forward")
+	}
+}
+
+func TestRenderBookHTMLVertical(t *testing.T) {
+	book := docExportTestBook()
+	book.verticalText = true
+	pages := RenderBookHTML(book)
+	for _, name := range []string{"index.html", "chapter-001.html"} {
+		if !strings.Contains(pages[name], `<html lang="en" dir="ltr" data-writing="vertical">`) {
+			t.Fatalf("expected vertical writing on %s:\n%s", name, pages[name])
+		}
+	}
+	if !strings.Contains(pages["style.css"], `:root[data-writing="vertical"] main { writing-mode: vertical-rl;`) {
+		t.Fatal("stylesheet should lay out vertical pages right to left")
+	}
+}
+
+func TestRenderBookHTMLReadableFont(t *testing.T) {
+	book := docExportTestBook()
+	if page := RenderBookHTML(book)["index.html"]; strings.Contains(page, "data-font") {
+		t.Fatalf("default reader should not force a font:\n%s", page)
+	}
+	book.readableFont = true
+	pages := RenderBookHTML(book)
+	if !strings.Contains(pages["chapter-001.html"], `data-writing="horizontal" data-font="readable">`) {
+		t.Fatalf("expected readable font mode:\n%s", pages["chapter-001.html"])
+	}
+	if !strings.Contains(pages["style.css"], `:root[data-font="readable"] body { font-family: "Atkinson Hyperlegible", OpenDyslexic`) {
+		t.Fatal("stylesheet should switch to the dyslexia-friendly font stack")
+	}
+}
+
+func TestRenderBookHTMLDark(t *testing.T) {
+	book := docExportTestBook()
+	book.darkTheme = true
+	pages := RenderBookHTML(book)
+	if !strings.Contains(pages["index.html"], `data-writing="horizontal" data-theme="dark">`) {
+		t.Fatalf("expected the dark theme:\n%s", pages["index.html"])
+	}
+	if !strings.Contains(pages["style.css"], `:root[data-theme="dark"] img { filter: brightness(.8)`) {
+		t.Fatal("dark theme should dim images")
+	}
+}
+
+func TestRenderBookHTMLAccessibility(t *testing.T) {
+	book := docExportTestBook()
+	book.Back[0].Language = "zh-CN"
+	book.labels = LabelsEnglish
+	pages := RenderBookHTML(book)
+
+	for name, wants := range map[string][]string{
+		"index.html": {`<nav role="doc-toc" aria-label="Contents">`},
+		"chapter-001.html": {
+			`<nav class="pager" aria-label="Chapter navigation">`,
+			`<button data-action="theme" aria-label="Toggle dark theme" title="Toggle dark theme">◐</button>`,
+			`<section class="footnotes" role="doc-endnotes" aria-label="Footnotes">`,
+			`<a href="#fnref-1" role="doc-backlink" aria-label="Back to text">↩</a>`,
+		},
+		"chapter-002.html": {"<main>\n<article lang=\"zh-CN\">\n<h1>Notes</h1>"},
+	} {
+		for _, want := range wants {
+			if !strings.Contains(pages[name], want) {
+				t.Fatalf("expected %q in %s:\n%s", want, name, pages[name])
+			}
+		}
+	}
+	if strings.Contains(pages["chapter-001.html"], "<article") {
+		t.Fatal("chapters in the book's language should not be wrapped")
+	}
+}
+
+func TestRenderBookHTMLDirection(t *testing.T) {
+	book := docExportTestBook()
+	book.Metadata.Language = "ar-EG"
+	if page := RenderBookHTML(book)["index.html"]; !strings.Contains(page, `<html lang="ar-EG" dir="rtl"`) {
+		t.Fatalf("expected Arabic books to read right to left:\n%s", page)
+	}
+	book.direction = DirectionLTR
+	if page := RenderBookHTML(book)["index.html"]; !strings.Contains(page, `dir="ltr"`) {
+		t.Fatalf("expected the override to win:\n%s", page)
+	}
+}
+
+func TestRenderBookHTMLTOCDepth(t *testing.T) {
+	book := docExportTestBook()
+	book.Back[0].Depth = 2
+
+	book.applyHTMLOptions(Options{TOCDepth: 1})
+	index := RenderBookHTML(book)["index.html"]
+	if !strings.Contains(index, `href="chapter-001.html"`) || strings.Contains(index, `href="chapter-002.html">Notes`) {
+		t.Fatalf("depth 1 should only list top-level chapters:\n%s", index)
+	}
+
+	book.applyHTMLOptions(Options{TOCDepth: 2, NoTOC: true})
+	pages := RenderBookHTML(book)
+	if strings.Contains(pages["index.html"], `class="toc"`) {
+		t.Fatalf("NoTOC should leave the contents list out:\n%s", pages["index.html"])
+	}
+	if !strings.Contains(pages["index.html"], `<a rel="next" href="chapter-001.html">`) || pages["chapter-002.html"] == "" {
+		t.Fatal("chapters should stay reachable without a contents list")
+	}
+}
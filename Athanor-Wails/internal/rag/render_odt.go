@@ -0,0 +1,257 @@
+package rag
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const odtNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/" ` +
+	`xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" office:version="1.2"`
+
+// RenderBookODT renders the book as an OpenDocument text file for
+// LibreOffice. Headings use the standard "Heading N" styles, so the
+// navigator and a generated table of contents pick them up; footnotes
+// become real notes and images are left out, as in the other document
+// exports.
+func RenderBookODT(book Book) ([]byte, error) {
+	var body strings.Builder
+	body.WriteString(`<text:p text:style-name="Title">` + odtInline(bookTitle(book), nil) + "</text:p>")
+	if len(book.Metadata.Authors) > 0 {
+		body.WriteString(`<text:p text:style-name="Subtitle">` + xmlEscape(strings.Join(book.Metadata.Authors, "; ")) + "</text:p>")
+	}
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		level := chapterHeadingLevel(chapter) - 1
+		notes := footnoteIndex(chapter)
+		title := displayChapterTitle(chapter)
+		body.WriteString(odtHeading(level, odtInline(title, nil)))
+		skipTitle := sameMeaningfulTitle(chapter, title)
+		for _, block := range chapter.Blocks {
+			if skipTitle && block.Kind == BlockKindHeading {
+				skipTitle = false
+				continue
+			}
+			body.WriteString(renderODTBlock(block, level+1, notes))
+		}
+	}
+
+	content := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<office:document-content ` + odtNamespaces + `><office:automatic-styles>` +
+		`<style:style style:name="Strong" style:family="text"><style:text-properties fo:font-weight="bold"/></style:style>` +
+		`<style:style style:name="Emphasis" style:family="text"><style:text-properties fo:font-style="italic"/></style:style>` +
+		`<style:style style:name="StrongEmphasis" style:family="text"><style:text-properties fo:font-weight="bold" fo:font-style="italic"/></style:style>` +
+		`<style:style style:name="BodyCenter" style:family="paragraph" style:parent-style-name="Text_20_body"><style:paragraph-properties fo:text-align="center"/></style:style>` +
+		`<style:style style:name="BodyRight" style:family="paragraph" style:parent-style-name="Text_20_body"><style:paragraph-properties fo:text-align="right"/></style:style>` +
+		`</office:automatic-styles><office:body><office:text>` + body.String() + `</office:text></office:body></office:document-content>`
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	// The mimetype entry has to come first and stay uncompressed, so tools
+	// can identify the file from its first bytes.
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := entry.Write([]byte("application/vnd.oasis.opendocument.text")); err != nil {
+		return nil, err
+	}
+	for _, part := range []struct{ name, data string }{
+		{"META-INF/manifest.xml", odtManifest},
+		{"meta.xml", odtMeta(book.Metadata, bookTitle(book))},
+		{"styles.xml", odtStyles()},
+		{"content.xml", content},
+	} {
+		entry, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write([]byte(part.data)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func odtHeading(level int, text string) string {
+	return fmt.Sprintf(`<text:h text:style-name="Heading_20_%d" text:outline-level="%d">%s</text:h>`, level, level, text)
+}
+
+func renderODTBlock(block Block, headingBase int, notes map[string]string) string {
+	switch block.Kind {
+	case BlockKindHeading:
+		return odtHeading(min(max(headingBase+block.Level-1, headingBase), 6), odtInline(block.Text, nil))
+	case BlockKindParagraph:
+		style := "Text_20_body"
+		switch block.Align {
+		case AlignCenter:
+			style = "BodyCenter"
+		case AlignRight:
+			style = "BodyRight"
+		}
+		return `<text:p text:style-name="` + style + `">` + odtInline(block.Text, notes) + "</text:p>"
+	case BlockKindBlockquote:
+		return `<text:p text:style-name="Quotations">` + odtInline(block.Text, notes) + "</text:p>"
+	case BlockKindCallout:
+		label := block.Label
+		if label == "" {
+			label = "NOTE"
+		}
+		return `<text:p text:style-name="Quotations"><text:span text:style-name="Strong">` + xmlEscape(label) + ":</text:span> " + odtInline(block.Text, notes) + "</text:p>"
+	case BlockKindList:
+		style := "List_20_1"
+		if block.Ordered {
+			style = "Numbering_20_1"
+		}
+		var b strings.Builder
+		b.WriteString(`<text:list text:style-name="` + style + `">`)
+		for _, item := range block.Items {
+			b.WriteString(`<text:list-item><text:p text:style-name="Text_20_body">` + odtInline(item, notes) + "</text:p></text:list-item>")
+		}
+		b.WriteString("</text:list>")
+		return b.String()
+	case BlockKindCode:
+		var b strings.Builder
+		for _, line := range strings.Split(block.Text, "\n") {
+			b.WriteString(`<text:p text:style-name="Preformatted_20_Text">` + odtSpaces(xmlEscape(line)) + "</text:p>")
+		}
+		return b.String()
+	case BlockKindTable:
+		columns := 0
+		for _, row := range block.Rows {
+			columns = max(columns, len(row))
+		}
+		if columns == 0 {
+			return ""
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, `<table:table><table:table-column table:number-columns-repeated="%d"/>`, columns)
+		for index, row := range block.Rows {
+			if index == 0 {
+				b.WriteString("<table:table-header-rows>")
+			}
+			b.WriteString("<table:table-row>")
+			for column := range columns {
+				cell := ""
+				if column < len(row) {
+					cell = row[column]
+				}
+				style := "Table_20_Contents"
+				if index == 0 {
+					style = "Table_20_Heading"
+				}
+				b.WriteString(`<table:table-cell office:value-type="string"><text:p text:style-name="` + style + `">` + odtInline(cell, notes) + "</text:p></table:table-cell>")
+			}
+			b.WriteString("</table:table-row>")
+			if index == 0 {
+				b.WriteString("</table:table-header-rows>")
+			}
+		}
+		b.WriteString("</table:table>")
+		return b.String()
+	default:
+		return ""
+	}
+}
+
+func odtInline(text string, notes map[string]string) string {
+	var out strings.Builder
+	for _, span := range parseInline(text) {
+		escaped := xmlEscape(span.text)
+		switch span.kind {
+		case inlineStrongEmphasis:
+			out.WriteString(`<text:span text:style-name="StrongEmphasis">` + escaped + "</text:span>")
+		case inlineStrong:
+			out.WriteString(`<text:span text:style-name="Strong">` + escaped + "</text:span>")
+		case inlineEmphasis:
+			out.WriteString(`<text:span text:style-name="Emphasis">` + escaped + "</text:span>")
+		case inlineFootnote:
+			if content, ok := notes[span.text]; ok {
+				out.WriteString(`<text:note text:note-class="footnote"><text:note-citation>` + escaped + `</text:note-citation>` +
+					`<text:note-body><text:p text:style-name="Footnote">` + odtInline(content, nil) + "</text:p></text:note-body></text:note>")
+			}
+		case inlineImage:
+		default:
+			out.WriteString(escaped)
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// odtSpaces keeps runs of spaces in code, which ODF collapses otherwise.
+func odtSpaces(s string) string {
+	return strings.ReplaceAll(s, "  ", ` <text:s/>`)
+}
+
+func odtMeta(metadata Metadata, title string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<office:document-meta ` + odtNamespaces + `><office:meta>`)
+	b.WriteString("<dc:title>" + xmlEscape(title) + "</dc:title>")
+	if len(metadata.Authors) > 0 {
+		b.WriteString("<dc:creator>" + xmlEscape(strings.Join(metadata.Authors, "; ")) + "</dc:creator>")
+	}
+	if metadata.Language != "" {
+		b.WriteString("<dc:language>" + xmlEscape(metadata.Language) + "</dc:language>")
+	}
+	for _, property := range []struct{ name, value string }{
+		{"Publisher", metadata.Publisher},
+		{"ISBN", metadata.ISBN},
+	} {
+		if property.value != "" {
+			fmt.Fprintf(&b, `<meta:user-defined meta:name="%s">%s</meta:user-defined>`, property.name, xmlEscape(property.value))
+		}
+	}
+	b.WriteString("</office:meta></office:document-meta>")
+	return b.String()
+}
+
+// odtStyles defines the paragraph and list styles content.xml refers to,
+// named after LibreOffice's defaults so its own templates can restyle them.
+func odtStyles() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<office:document-styles ` + odtNamespaces + `><office:styles>`)
+	b.WriteString(`<style:style style:name="Standard" style:family="paragraph"/>`)
+	for _, style := range []struct{ name, display, paragraph, text string }{
+		{"Text_20_body", "Text body", `fo:margin-bottom="0.25cm"`, ""},
+		{"Title", "Title", `fo:text-align="center" fo:margin-bottom="0.4cm"`, `fo:font-size="24pt" fo:font-weight="bold"`},
+		{"Subtitle", "Subtitle", `fo:text-align="center" fo:margin-bottom="0.6cm"`, `fo:font-size="14pt"`},
+		{"Quotations", "Quotations", `fo:margin-left="1cm" fo:margin-right="1cm" fo:margin-bottom="0.25cm"`, ""},
+		{"Preformatted_20_Text", "Preformatted Text", `fo:background-color="#f2f2f2"`, `style:font-name="Liberation Mono" fo:font-family="'Liberation Mono', monospace" fo:font-size="10pt"`},
+		{"Footnote", "Footnote", "", `fo:font-size="10pt"`},
+		{"Table_20_Contents", "Table Contents", "", ""},
+		{"Table_20_Heading", "Table Heading", "", `fo:font-weight="bold"`},
+	} {
+		fmt.Fprintf(&b, `<style:style style:name="%s" style:display-name="%s" style:family="paragraph" style:parent-style-name="Standard">`, style.name, style.display)
+		fmt.Fprintf(&b, `<style:paragraph-properties %s/><style:text-properties %s/></style:style>`, style.paragraph, style.text)
+	}
+	for level := 1; level <= 6; level++ {
+		fmt.Fprintf(&b, `<style:style style:name="Heading_20_%d" style:display-name="Heading %d" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Text_20_body" style:default-outline-level="%d">`, level, level, level)
+		fmt.Fprintf(&b, `<style:paragraph-properties fo:margin-top="0.4cm" fo:margin-bottom="0.2cm" fo:keep-with-next="always"/><style:text-properties fo:font-size="%dpt" fo:font-weight="bold"/></style:style>`, max(20-2*level, 11))
+	}
+	for _, list := range []struct{ name, display, level string }{
+		{"List_20_1", "List 1", `<text:list-level-style-bullet text:level="1" text:bullet-char="•"><style:list-level-properties text:space-before="0.4cm" text:min-label-width="0.6cm"/></text:list-level-style-bullet>`},
+		{"Numbering_20_1", "Numbering 123", `<text:list-level-style-number text:level="1" style:num-format="1" style:num-suffix="."><style:list-level-properties text:space-before="0.4cm" text:min-label-width="0.6cm"/></text:list-level-style-number>`},
+	} {
+		fmt.Fprintf(&b, `<text:list-style style:name="%s" style:display-name="%s">%s</text:list-style>`, list.name, list.display, list.level)
+	}
+	b.WriteString("</office:styles></office:document-styles>")
+	return b.String()
+}
+
+const odtManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">` +
+	`<manifest:file-entry manifest:full-path="/" manifest:media-type="application/vnd.oasis.opendocument.text"/>` +
+	`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+	`<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>` +
+	`<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>` +
+	`</manifest:manifest>`
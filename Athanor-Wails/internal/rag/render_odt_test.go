@@ -0,0 +1,41 @@
+package rag
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderBookODT(t *testing.T) {
+	data, err := RenderBookODT(docExportTestBook())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("odt is not a zip: %v", err)
+	}
+	if first := reader.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Fatalf("mimetype must be the first, uncompressed entry, got %s (method %d)", first.Name, first.Method)
+	}
+	parts := readDOCXParts(t, data)
+	for part, wants := range map[string][]string{
+		"content.xml": {
+			`<text:p text:style-name="Title">Export &amp; Co</text:p>`,
+			`<text:h text:style-name="Heading_20_1" text:outline-level="1">One</text:h>`,
+			`Some <text:span text:style-name="Strong">bold</text:span> and <text:span text:style-name="Emphasis">italic</text:span> &lt;text&gt;.<text:note text:note-class="footnote">`,
+			`<text:list text:style-name="Numbering_20_1">`,
+			"<table:table-header-rows>",
+			`<text:p text:style-name="BodyCenter">Back matter.</text:p>`,
+		},
+		"styles.xml": {`style:name="Heading_20_1"`, `style:name="Preformatted_20_Text"`},
+		"meta.xml":   {"<dc:creator>Ada</dc:creator>", `<meta:user-defined meta:name="ISBN">9780306406157</meta:user-defined>`},
+	} {
+		for _, want := range wants {
+			if !strings.Contains(parts[part], want) {
+				t.Fatalf("expected %q in %s:\n%s", want, part, parts[part])
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// RenderBookRTF renders the book as an RTF document for word processors
+// that read neither DOCX nor ODT. Text outside ASCII is written as \u
+// escapes, so the file itself is plain ASCII and survives legacy
+// pipelines; footnotes become RTF footnotes and images are left out.
+func RenderBookRTF(book Book) string {
+	var b strings.Builder
+	b.WriteString(`{\rtf1\ansi\ansicpg1252\deff0` + "\n")
+	b.WriteString(`{\fonttbl{\f0\froman Times New Roman;}{\f1\fmodern Courier New;}}` + "\n")
+	b.WriteString(`{\info{\title ` + rtfEscape(bookTitle(book)) + `}`)
+	if len(book.Metadata.Authors) > 0 {
+		b.WriteString(`{\author ` + rtfEscape(strings.Join(book.Metadata.Authors, "; ")) + `}`)
+	}
+	if book.Metadata.Publisher != "" {
+		b.WriteString(`{\*\company ` + rtfEscape(book.Metadata.Publisher) + `}`)
+	}
+	if book.Metadata.ISBN != "" {
+		b.WriteString(`{\doccomm ISBN ` + book.Metadata.ISBN + `}`)
+	}
+	b.WriteString("}\n")
+	b.WriteString(`\pard\qc\sb480\sa240\b\fs48 ` + rtfInline(bookTitle(book), nil) + `\b0\fs24\par` + "\n")
+	if len(book.Metadata.Authors) > 0 {
+		b.WriteString(`\pard\qc\sa480 ` + rtfEscape(strings.Join(book.Metadata.Authors, "; ")) + `\par` + "\n")
+	}
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		level := chapterHeadingLevel(chapter) - 1
+		notes := footnoteIndex(chapter)
+		title := displayChapterTitle(chapter)
+		b.WriteString(rtfHeading(level, rtfInline(title, nil)))
+		skipTitle := sameMeaningfulTitle(chapter, title)
+		for _, block := range chapter.Blocks {
+			if skipTitle && block.Kind == BlockKindHeading {
+				skipTitle = false
+				continue
+			}
+			b.WriteString(renderRTFBlock(block, level+1, notes))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func rtfHeading(level int, text string) string {
+	return fmt.Sprintf(`\pard\sb360\sa120\keepn\outlinelevel%d\b\fs%d %s\b0\fs24\par`+"\n", level-1, max(40-4*level, 24), text)
+}
+
+func renderRTFBlock(block Block, headingBase int, notes map[string]string) string {
+	switch block.Kind {
+	case BlockKindHeading:
+		return rtfHeading(min(max(headingBase+block.Level-1, headingBase), 6), rtfInline(block.Text, nil))
+	case BlockKindParagraph:
+		align := ""
+		switch block.Align {
+		case AlignCenter:
+			align = `\qc`
+		case AlignRight:
+			align = `\qr`
+		}
+		return `\pard` + align + `\sa180 ` + rtfInline(block.Text, notes) + `\par` + "\n"
+	case BlockKindBlockquote:
+		return `\pard\li720\ri720\sa180\i ` + rtfInline(block.Text, notes) + `\i0\par` + "\n"
+	case BlockKindCallout:
+		label := block.Label
+		if label == "" {
+			label = "NOTE"
+		}
+		return `\pard\li720\ri720\sa180{\b ` + rtfEscape(label) + `:} ` + rtfInline(block.Text, notes) + `\par` + "\n"
+	case BlockKindList:
+		var b strings.Builder
+		for index, item := range block.Items {
+			marker := `\bullet`
+			if block.Ordered {
+				marker = fmt.Sprintf("%d.", index+1)
+			}
+			fmt.Fprintf(&b, `\pard\li720\fi-360\sa60 %s\tab %s\par`+"\n", marker, rtfInline(item, notes))
+		}
+		return b.String()
+	case BlockKindCode:
+		lines := strings.Split(block.Text, "\n")
+		for i, line := range lines {
+			lines[i] = rtfEscape(line)
+		}
+		return `\pard\sa180\f1\fs20 ` + strings.Join(lines, `\line `) + `\f0\fs24\par` + "\n"
+	case BlockKindTable:
+		columns := 0
+		for _, row := range block.Rows {
+			columns = max(columns, len(row))
+		}
+		if columns == 0 {
+			return ""
+		}
+		var b strings.Builder
+		for index, row := range block.Rows {
+			b.WriteString(`\trowd\trgaph108`)
+			if index == 0 {
+				b.WriteString(`\trhdr`)
+			}
+			for column := range columns {
+				fmt.Fprintf(&b, `\clbrdrt\brdrs\clbrdrl\brdrs\clbrdrb\brdrs\clbrdrr\brdrs\cellx%d`, (column+1)*9000/columns)
+			}
+			b.WriteString("\n")
+			for column := range columns {
+				cell := ""
+				if column < len(row) {
+					cell = row[column]
+				}
+				if index == 0 {
+					fmt.Fprintf(&b, `\pard\intbl{\b %s}\cell`, rtfInline(cell, notes))
+				} else {
+					fmt.Fprintf(&b, `\pard\intbl %s\cell`, rtfInline(cell, notes))
+				}
+			}
+			b.WriteString(`\row` + "\n")
+		}
+		b.WriteString(`\pard\par` + "\n")
+		return b.String()
+	default:
+		return ""
+	}
+}
+
+func rtfInline(text string, notes map[string]string) string {
+	var out strings.Builder
+	for _, span := range parseInline(text) {
+		escaped := rtfEscape(span.text)
+		switch span.kind {
+		case inlineStrongEmphasis:
+			out.WriteString(`{\b\i ` + escaped + `}`)
+		case inlineStrong:
+			out.WriteString(`{\b ` + escaped + `}`)
+		case inlineEmphasis:
+			out.WriteString(`{\i ` + escaped + `}`)
+		case inlineFootnote:
+			if content, ok := notes[span.text]; ok {
+				out.WriteString(`{\super\chftn}{\footnote\pard\plain\fs20{\super\chftn} ` + rtfInline(content, nil) + `}`)
+			}
+		case inlineImage:
+		default:
+			out.WriteString(escaped)
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// rtfEscape escapes RTF's control characters and writes everything outside
+// ASCII as \uN? escapes in UTF-16 code units, with "?" as the fallback for
+// readers that do not know \u.
+func rtfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString(`\tab `)
+		case r == '\n':
+			b.WriteString(`\line `)
+		case r < 0x80:
+			b.WriteRune(r)
+		default:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%d?`, int16(unit))
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderBookRTF(t *testing.T) {
+	doc := RenderBookRTF(docExportTestBook())
+	if !strings.HasPrefix(doc, `{\rtf1`) || strings.Count(doc, "{") != strings.Count(doc, "}") {
+		t.Fatalf("rtf groups are unbalanced:\n%s", doc)
+	}
+	for _, want := range []string{
+		`{\title Export & Co}{\author Ada}{\*\company Pub}{\doccomm ISBN 9780306406157}`,
+		`\outlinelevel0\b\fs36 One\b0`,
+		`Some {\b bold} and {\i italic} <text>.{\super\chftn}{\footnote\pard\plain\fs20{\super\chftn} A note.}`,
+		`2.\tab second\par`,
+		`\trhdr`,
+		`\pard\qc\sa180 Back matter.\par`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Fatalf("expected %q in rtf:\n%s", want, doc)
+		}
+	}
+}
+
+func TestRTFEscape(t *testing.T) {
+	if got := rtfEscape(`a{b}\ 中😀`); got != `a\{b\}\\ \u20013?\u-10179?\u-8704?` {
+		t.Fatalf("unexpected escape: %s", got)
+	}
+}
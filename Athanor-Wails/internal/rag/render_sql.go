@@ -0,0 +1,92 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const sqlSchema = `CREATE TABLE IF NOT EXISTS metadata (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS chapters (
+  id TEXT PRIMARY KEY,
+  ord INTEGER NOT NULL,
+  title TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  source_ref TEXT NOT NULL,
+  language TEXT
+);
+CREATE TABLE IF NOT EXISTS paragraphs (
+  id INTEGER PRIMARY KEY,
+  chapter_id TEXT NOT NULL REFERENCES chapters(id),
+  ord INTEGER NOT NULL,
+  kind TEXT NOT NULL,
+  text TEXT NOT NULL,
+  start_offset INTEGER NOT NULL,
+  end_offset INTEGER NOT NULL
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS paragraphs_fts USING fts5(text, content='paragraphs', content_rowid='id');`
+
+// RenderBookSQL renders the book as a SQLite script that creates chapter and
+// paragraph tables plus an FTS5 index over the paragraphs. Load it with
+// `sqlite3 book.db < book.sql`; keeping it a script avoids linking a SQLite
+// driver into the converter. Rows are written with INSERT OR REPLACE, so
+// loading the script into the same database again updates it in place.
+// Paragraph text matches chunks.jsonl, and the
+// offsets are rune offsets into the chapter text with paragraphs joined by
+// blank lines. Headings are stored without their Markdown markers.
+func RenderBookSQL(book Book) string {
+	var b strings.Builder
+	b.WriteString("BEGIN;\n")
+	b.WriteString(sqlSchema + "\n")
+
+	for _, field := range [][2]string{
+		{"title", book.Metadata.Title},
+		{"authors", strings.Join(book.Metadata.Authors, "; ")},
+		{"language", book.Metadata.Language},
+		{"identifier", book.Metadata.Identifier},
+		{"series", book.Metadata.Series},
+		{"series_index", book.Metadata.SeriesIndex},
+		{"source_sha256", book.Metadata.SourceSHA256},
+	} {
+		if field[1] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "INSERT OR REPLACE INTO metadata VALUES (%s, %s);\n", sqlQuote(field[0]), sqlQuote(field[1]))
+	}
+
+	id := 0
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		fmt.Fprintf(&b, "INSERT OR REPLACE INTO chapters VALUES (%s, %d, %s, %s, %s, %s);\n",
+			sqlQuote(chapter.ID), chapter.Order, sqlQuote(displayChapterTitle(chapter)),
+			sqlQuote(string(chapter.Kind)), sqlQuote(chapter.SourceRef), sqlQuote(chapterLanguage(chapter, book)))
+
+		offset := 0
+		ord := 0
+		for _, block := range chapter.Blocks {
+			text := chunkText(block)
+			if block.Kind == BlockKindHeading {
+				text = strings.TrimSpace(block.Text)
+			}
+			if text == "" {
+				continue
+			}
+			if ord > 0 {
+				offset += 2
+			}
+			end := offset + utf8.RuneCountInString(text)
+			id++
+			ord++
+			fmt.Fprintf(&b, "INSERT OR REPLACE INTO paragraphs VALUES (%d, %s, %d, %s, %s, %d, %d);\n",
+				id, sqlQuote(chapter.ID), ord, sqlQuote(string(block.Kind)), sqlQuote(text), offset, end)
+			offset = end
+		}
+	}
+
+	b.WriteString("INSERT INTO paragraphs_fts(paragraphs_fts) VALUES ('rebuild');\n")
+	b.WriteString("COMMIT;\n")
+	return b.String()
+}
+
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
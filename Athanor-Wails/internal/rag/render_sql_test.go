@@ -0,0 +1,40 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderBookSQL(t *testing.T) {
+	book := Book{
+		Metadata: Metadata{Title: "It's a Book"},
+		Main: []Chapter{{
+			ID:    "chapter-001",
+			Title: "One",
+			Order: 1,
+			Kind:  ChapterKindMain,
+			Blocks: []Block{
+				{Kind: BlockKindHeading, Text: "One", Level: 1},
+				{Kind: BlockKindParagraph, Text: "第一段"},
+				{Kind: BlockKindSeparator},
+				{Kind: BlockKindParagraph, Text: "Second"},
+			},
+		}},
+	}
+
+	script := RenderBookSQL(book)
+	for _, want := range []string{
+		"BEGIN;\n",
+		"USING fts5(text, content='paragraphs', content_rowid='id')",
+		"INSERT OR REPLACE INTO metadata VALUES ('title', 'It''s a Book');",
+		"INSERT OR REPLACE INTO chapters VALUES ('chapter-001', 1, 'One', 'main', '',",
+		"INSERT OR REPLACE INTO paragraphs VALUES (1, 'chapter-001', 1, 'heading', 'One', 0, 3);",
+		"INSERT OR REPLACE INTO paragraphs VALUES (2, 'chapter-001', 2, 'paragraph', '第一段', 5, 8);",
+		"INSERT OR REPLACE INTO paragraphs VALUES (3, 'chapter-001', 3, 'paragraph', 'Second', 10, 16);",
+		"VALUES ('rebuild');\nCOMMIT;\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("expected %q in script:\n%s", want, script)
+		}
+	}
+}
@@ -42,4 +42,65 @@ func TestRenderChapterMarkdownIncludesFootnotes(t *testing.T) {
 	if !strings.Contains(out, "[^1]: Note body") {
 		t.Fatalf("expected rendered footnote, got %q", out)
 	}
-}
\ No newline at end of file
+}
+
+func TestCalloutsRenderAsMarkdownAlerts(t *testing.T) {
+	body := parseBodyFromHTML(t, `
+		<p>Running text.</p>
+		<aside epub:type="sidebar"><p>Sidebar text.</p></aside>
+		<div class="box" epub:type="tip">Try this.</div>
+		<aside id="fn1" epub:type="footnote">A real footnote.</aside>`)
+
+	builder := newChapterBuilder("one.xhtml", 1, "", nil, noteRegistry{})
+	builder.styles = styleMapper{config: StyleConfig{Callouts: true}}
+	builder.consumeNode(body)
+	chapter := builder.build()
+
+	out := renderBlocks(chapter.Blocks, 2)
+	if !strings.Contains(out, "> [!NOTE]\n> Sidebar text.") {
+		t.Fatalf("expected sidebar callout, got %q", out)
+	}
+	if !strings.Contains(out, "> [!TIP]\n> Try this.") {
+		t.Fatalf("expected tip callout, got %q", out)
+	}
+	if strings.Contains(out, "A real footnote.") || len(chapter.Footnotes) != 1 {
+		t.Fatalf("footnote asides should stay footnotes, got %q", out)
+	}
+}
+
+func TestAsidesFlattenWithoutCalloutToggle(t *testing.T) {
+	body := parseBodyFromHTML(t, `<aside epub:type="sidebar"><p>Sidebar text.</p></aside>`)
+
+	builder := newChapterBuilder("one.xhtml", 1, "", nil, noteRegistry{})
+	builder.consumeNode(body)
+	chapter := builder.build()
+
+	if len(chapter.Blocks) != 1 || chapter.Blocks[0].Kind != BlockKindParagraph {
+		t.Fatalf("expected plain paragraph, got %+v", chapter.Blocks)
+	}
+}
+
+func TestRenderBookMarkdownCollectsNotesAtEnd(t *testing.T) {
+	book := docExportTestBook()
+	book.Back[0].Blocks[0].Text = "Back matter.[^1]"
+	book.Back[0].Footnotes = []Footnote{{Label: "1", Content: "Back note."}}
+	book.Back[0].Blocks = append(book.Back[0].Blocks, Block{Kind: BlockKindCode, Text: "pattern := `[^1]`"})
+	book.notes = NotesBook
+
+	out := RenderBookMarkdown(book)
+	want := "## 脚注\n\n### One\n\n[^chapter-001-1]: A note.\n\n### Notes\n\n[^chapter-002-1]: Back note.\n"
+	if !strings.HasSuffix(out, want) {
+		t.Fatalf("expected one notes section at the end:\n%s", out)
+	}
+	for _, ref := range []string{"<text>.[^chapter-001-1]", "Back matter.[^chapter-002-1]"} {
+		if !strings.Contains(out, ref) {
+			t.Fatalf("expected relabelled reference %q:\n%s", ref, out)
+		}
+	}
+	if !strings.Contains(out, "pattern := `[^1]`") {
+		t.Fatalf("code blocks should keep their text:\n%s", out)
+	}
+	if strings.Count(out, "脚注") != 1 || strings.Count(out, "[^1]") != 1 {
+		t.Fatalf("chapters should not keep their own notes:\n%s", out)
+	}
+}
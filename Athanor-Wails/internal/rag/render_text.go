@@ -0,0 +1,176 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	textEmphasisRe    = regexp.MustCompile(`\*{1,3}([^*\n]+?)\*{1,3}`)
+	textFootnoteRefRe = regexp.MustCompile(`\[\^([^\]]+)\]`)
+	textBlankRunRe    = regexp.MustCompile(`\n{3,}`)
+)
+
+// RenderBookText renders the book as plain text for corpus building: no
+// Markdown syntax, footnote references kept as [n], and prose wrapped at
+// width display columns (CJK characters count as two). A width of zero or
+// less disables wrapping.
+func RenderBookText(book Book, width int) string {
+	var parts []string
+	parts = append(parts, bookTitle(book), "")
+	if label := seriesLabel(book); label != "" {
+		parts = append(parts, label, "")
+	}
+
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		title := displayChapterTitle(chapter)
+		if !sameMeaningfulTitle(chapter, title) {
+			parts = append(parts, title, "")
+		}
+		for _, block := range chapter.Blocks {
+			lines := renderTextBlock(block, width)
+			if len(lines) == 0 {
+				continue
+			}
+			parts = append(parts, lines...)
+			parts = append(parts, "")
+		}
+		for _, note := range chapter.Footnotes {
+			parts = append(parts, wrapText(fmt.Sprintf("[%s] %s", note.Label, plainInline(note.Content)), width, "")...)
+		}
+		parts = append(parts, "")
+	}
+
+	text := strings.Join(parts, "\n")
+	text = textBlankRunRe.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
+
+func renderTextBlock(block Block, width int) []string {
+	switch block.Kind {
+	case BlockKindHeading:
+		return []string{plainInline(block.Text)}
+	case BlockKindParagraph:
+		return wrapText(plainInline(block.Text), width, "")
+	case BlockKindBlockquote, BlockKindCallout:
+		return wrapText(plainInline(block.Text), width, "    ")
+	case BlockKindList:
+		var lines []string
+		for index, item := range block.Items {
+			prefix := "- "
+			if block.Ordered {
+				prefix = fmt.Sprintf("%d. ", index+1)
+			}
+			lines = append(lines, wrapText(prefix+plainInline(item), width, "")...)
+		}
+		return lines
+	case BlockKindCode:
+		return strings.Split(block.Text, "\n")
+	case BlockKindTable:
+		lines := make([]string, 0, len(block.Rows))
+		for _, row := range block.Rows {
+			cells := make([]string, 0, len(row))
+			for _, cell := range row {
+				cells = append(cells, plainInline(cell))
+			}
+			lines = append(lines, strings.Join(cells, "\t"))
+		}
+		return lines
+	default:
+		return nil
+	}
+}
+
+// plainInline strips the inline Markdown the chapter builder emits.
+func plainInline(text string) string {
+	text = stripImages(unlinkText(text))
+	text = textEmphasisRe.ReplaceAllString(text, "$1")
+	text = textFootnoteRefRe.ReplaceAllString(text, "[$1]")
+	return strings.TrimSpace(text)
+}
+
+func wrapText(text string, width int, indent string) []string {
+	if text == "" {
+		return nil
+	}
+	if width <= 0 {
+		return []string{indent + text}
+	}
+
+	var lines []string
+	var line strings.Builder
+	column := 0
+	flush := func() {
+		if line.Len() > 0 {
+			lines = append(lines, indent+strings.TrimRight(line.String(), " "))
+		}
+		line.Reset()
+		column = 0
+	}
+	limit := max(width-displayWidth(indent), 1)
+	for _, word := range splitWrapUnits(text) {
+		wordWidth := displayWidth(word)
+		if word == " " {
+			if column > 0 && column < limit {
+				line.WriteString(word)
+				column++
+			}
+			continue
+		}
+		if column > 0 && column+wordWidth > limit {
+			flush()
+		}
+		line.WriteString(word)
+		column += wordWidth
+	}
+	flush()
+	return lines
+}
+
+// splitWrapUnits splits text into break opportunities: runs of non-space
+// Latin text, single wide characters (which may break anywhere), and single
+// spaces.
+func splitWrapUnits(text string) []string {
+	var units []string
+	var word strings.Builder
+	emit := func() {
+		if word.Len() > 0 {
+			units = append(units, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			emit()
+			units = append(units, " ")
+		case isWideRune(r):
+			emit()
+			units = append(units, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	emit()
+	return units
+}
+
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if isWideRune(r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) ||
+		(r >= 0xFF00 && r <= 0xFF60)
+}
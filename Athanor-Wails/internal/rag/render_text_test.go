@@ -0,0 +1,54 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderBookTextStripsMarkdown(t *testing.T) {
+	book := Book{
+		Metadata: Metadata{Title: "Plain"},
+		Main: []Chapter{{
+			ID:    "chapter-001",
+			Title: "One",
+			Kind:  ChapterKindMain,
+			Blocks: []Block{
+				{Kind: BlockKindHeading, Text: "One", Level: 1},
+				{Kind: BlockKindParagraph, Text: "Some **bold** and *italic* text.[^1]"},
+				{Kind: BlockKindList, Items: []string{"first", "second"}, Ordered: true},
+				{Kind: BlockKindCallout, Label: "TIP", Text: "Aside."},
+			},
+			Footnotes: []Footnote{{Label: "1", Content: "A note."}},
+		}},
+	}
+
+	text := RenderBookText(book, 0)
+	for _, want := range []string{"Plain\n", "Some bold and italic text.[1]", "1. first", "    Aside.", "[1] A note."} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in text:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"#", "**", "[^", "[!TIP]"} {
+		if strings.Contains(text, unwanted) {
+			t.Fatalf("unexpected %q in text:\n%s", unwanted, text)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("the quick brown fox jumps over", 10, "")
+	expected := []string{"the quick", "brown fox", "jumps over"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %v, got %v", expected, lines)
+	}
+
+	lines = wrapText("这是一段需要折行的中文内容", 10, "")
+	for _, line := range lines {
+		if displayWidth(line) > 10 {
+			t.Fatalf("line %q exceeds width", line)
+		}
+	}
+	if strings.Join(lines, "") != "这是一段需要折行的中文内容" {
+		t.Fatalf("wrapping lost characters: %v", lines)
+	}
+}
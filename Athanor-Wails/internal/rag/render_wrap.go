@@ -0,0 +1,98 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultWrapColumns is the wrap width when WrapAuto has no column count.
+const DefaultWrapColumns = 72
+
+var (
+	wrapListItemRe   = regexp.MustCompile(`^(- |\d+\. )`)
+	wrapFootnoteRe   = regexp.MustCompile(`^\[\^[^\]]+\]: `)
+	wrapAngleDestRe  = regexp.MustCompile(`\]\(<[^>\n]*>\)`)
+	wrapBlockStartRe = regexp.MustCompile("^(#{1,6}|[-+*]|\\d{1,9}[.)]|=+|-+|>.*|<.*|`{3}.*|~{3}.*|\\[\\^[^\\]]*\\]:.*|\\$\\$.*)$")
+)
+
+// WrapMarkdown wraps the prose lines of Markdown produced by this package at
+// columns display columns. Headings, tables, code and math blocks are left
+// alone; list items, quotes and footnotes keep their markers on every
+// continuation line. Lines only break at spaces, because renderers turn a
+// line break between CJK characters into a visible space, and a line never
+// starts with a token that would turn it into a heading, list item, quote or
+// HTML block. Under WrapNone the text is returned as is, keeping explicit
+// <br> breaks.
+func WrapMarkdown(markdown string, mode WrapMode, columns int) string {
+	if mode != WrapAuto {
+		return markdown
+	}
+	if columns <= 0 {
+		columns = DefaultWrapColumns
+	}
+
+	var out []string
+	fenced := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+		}
+		if fenced || strings.HasPrefix(line, "```") || displayWidth(line) <= columns {
+			out = append(out, line)
+			continue
+		}
+		first, rest, ok := wrapPrefixes(line)
+		if !ok {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, wrapMarkdownLine(strings.TrimPrefix(line, first), first, rest, columns)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrapPrefixes returns the marker that starts a prose line and the prefix
+// its continuation lines need, or false for lines that must not be wrapped.
+func wrapPrefixes(line string) (string, string, bool) {
+	switch {
+	case strings.HasPrefix(line, "#"), strings.HasPrefix(line, "|"),
+		strings.HasPrefix(line, "---"), strings.HasPrefix(line, "> [!"),
+		strings.HasPrefix(line, "$$"), strings.HasPrefix(line, `\[`),
+		strings.HasPrefix(line, "<"):
+		return "", "", false
+	case strings.HasPrefix(line, "> "):
+		return "> ", "> ", true
+	}
+	if marker := wrapListItemRe.FindString(line); marker != "" {
+		return marker, strings.Repeat(" ", len(marker)), true
+	}
+	if marker := wrapFootnoteRe.FindString(line); marker != "" {
+		return marker, "    ", true
+	}
+	return "", "", true
+}
+
+func wrapMarkdownLine(text, first, rest string, columns int) []string {
+	// Angle-bracket destinations may contain spaces but no line breaks.
+	text = wrapAngleDestRe.ReplaceAllStringFunc(text, func(dest string) string {
+		return strings.ReplaceAll(dest, " ", "\x00")
+	})
+
+	var lines []string
+	line := first
+	empty := true
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, "\x00", " ")
+		switch {
+		case empty:
+			line += word
+		case displayWidth(line)+1+displayWidth(word) > columns && !wrapBlockStartRe.MatchString(word):
+			lines = append(lines, line)
+			line = rest + word
+		default:
+			line += " " + word
+		}
+		empty = false
+	}
+	return append(lines, line)
+}
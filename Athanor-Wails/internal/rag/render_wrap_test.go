@@ -0,0 +1,61 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrapMarkdownKeepsBlockStructure(t *testing.T) {
+	markdown := strings.Join([]string{
+		"## A heading that is much longer than the wrap width allows",
+		"",
+		"one two three four five six",
+		"",
+		"> quoted words that need wrapping",
+		"",
+		"- list item text that wraps",
+		"",
+		"```",
+		"code line that is long and must stay",
+		"```",
+		"",
+		"| a cell | another cell | third |",
+	}, "\n")
+
+	got := WrapMarkdown(markdown, WrapAuto, 14)
+	for _, want := range []string{
+		"## A heading that is much longer than the wrap width allows\n",
+		"one two three\nfour five six\n",
+		"> quoted words\n> that need\n> wrapping\n",
+		"- list item\n  text that\n  wraps\n",
+		"code line that is long and must stay\n",
+		"| a cell | another cell | third |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in:\n%s", want, got)
+		}
+	}
+	if WrapMarkdown(markdown, WrapNone, 14) != markdown {
+		t.Fatal("expected none to leave the text alone")
+	}
+}
+
+func TestWrapMarkdownNeverStartsLineWithBlockSyntax(t *testing.T) {
+	got := WrapMarkdown("see chapter - 12. # > done", WrapAuto, 10)
+	for _, line := range strings.Split(got, "\n")[1:] {
+		if wrapBlockStartRe.MatchString(strings.Fields(line)[0]) {
+			t.Fatalf("continuation line %q starts with block syntax:\n%s", line, got)
+		}
+	}
+}
+
+func TestWrapMarkdownKeepsCJKAndDestinationsIntact(t *testing.T) {
+	cjk := "这是一段很长的中文段落没有任何空格所以不应该被折行"
+	if got := WrapMarkdown(cjk, WrapAuto, 10); got != cjk {
+		t.Fatalf("expected CJK paragraph unchanged, got:\n%s", got)
+	}
+	got := WrapMarkdown("look ![a](<my book/images/a b.png>) here", WrapAuto, 8)
+	if !strings.Contains(got, "![a](<my book/images/a b.png>)") {
+		t.Fatalf("expected angle destination kept on one line:\n%s", got)
+	}
+}
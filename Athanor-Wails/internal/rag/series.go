@@ -0,0 +1,141 @@
+package rag
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// seriesFromPackage reads series membership from the OPF. Calibre's
+// calibre:series / calibre:series_index meta tags are preferred since they
+// are by far the most common; EPUB 3 belongs-to-collection refinements are
+// used as a fallback.
+func seriesFromPackage(pkg packageXML) (string, string) {
+	var series, index string
+	for _, meta := range pkg.Metadata.Meta {
+		switch strings.ToLower(strings.TrimSpace(meta.Name)) {
+		case "calibre:series":
+			series = firstNonEmpty(series, strings.TrimSpace(meta.Content))
+		case "calibre:series_index":
+			index = firstNonEmpty(index, strings.TrimSpace(meta.Content))
+		}
+	}
+	if series != "" {
+		return series, normalizeSeriesIndex(index)
+	}
+
+	collectionID := ""
+	for _, meta := range pkg.Metadata.Meta {
+		if strings.TrimSpace(meta.Property) == "belongs-to-collection" && strings.TrimSpace(meta.Text) != "" {
+			series = strings.TrimSpace(meta.Text)
+			collectionID = strings.TrimSpace(meta.ID)
+			break
+		}
+	}
+	if series == "" || collectionID == "" {
+		return series, ""
+	}
+	for _, meta := range pkg.Metadata.Meta {
+		if strings.TrimSpace(meta.Property) == "group-position" && strings.TrimPrefix(strings.TrimSpace(meta.Refines), "#") == collectionID {
+			index = strings.TrimSpace(meta.Text)
+			break
+		}
+	}
+	return series, normalizeSeriesIndex(index)
+}
+
+// normalizeSeriesIndex drops the trailing ".0" calibre writes for whole
+// volumes so "3.0" and "3" name the same volume.
+func normalizeSeriesIndex(index string) string {
+	value, err := strconv.ParseFloat(strings.TrimSpace(index), 64)
+	if err != nil || value < 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// ReadSeries reads only the container and package documents of the EPUB at
+// inputPath and returns its series name and index, so callers can work out
+// the SeriesBaseName of a book before converting it.
+func ReadSeries(inputPath string) (Metadata, error) {
+	reader, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("打开 EPUB 失败: %w", err)
+	}
+	defer reader.Close()
+
+	entries := map[string]zipEntry{}
+	readEntry := func(name string) error {
+		for _, file := range reader.File {
+			if file.Name != name {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return fmt.Errorf("读取 EPUB 条目失败: %w", err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("读取 EPUB 条目失败: %w", err)
+			}
+			entries[name] = zipEntry{name: name, data: data}
+		}
+		return nil
+	}
+
+	if err := readEntry("META-INF/container.xml"); err != nil {
+		return Metadata{}, err
+	}
+	var container containerXML
+	if entry, ok := entries["META-INF/container.xml"]; ok && decodeXML(entry.data, &container) == nil && len(container.Rootfiles) > 0 {
+		if err := readEntry(container.Rootfiles[0].FullPath); err != nil {
+			return Metadata{}, err
+		}
+	}
+	_, pkg, err := loadPackageDocument(entries)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	var metadata Metadata
+	metadata.Series, metadata.SeriesIndex = seriesFromPackage(pkg)
+	return metadata, nil
+}
+
+// SeriesBaseName prefixes base with the series name and a zero-padded volume
+// number, so the outputs of a whole series sort in reading order. Books
+// without series metadata keep base unchanged.
+func SeriesBaseName(metadata Metadata, base string) string {
+	series := sanitizePathComponent(metadata.Series)
+	if series == "" {
+		return base
+	}
+	index := metadata.SeriesIndex
+	if index == "" {
+		return series + "_" + base
+	}
+	whole, fraction, _ := strings.Cut(index, ".")
+	number, err := strconv.Atoi(whole)
+	if err != nil {
+		return series + "_" + base
+	}
+	padded := fmt.Sprintf("%02d", number)
+	if fraction != "" {
+		padded += "." + fraction
+	}
+	return series + "_" + padded + "_" + base
+}
+
+func seriesLabel(book Book) string {
+	metadata := book.Metadata
+	if metadata.Series == "" {
+		return ""
+	}
+	if metadata.SeriesIndex == "" {
+		return fmt.Sprintf(book.text().Series, metadata.Series)
+	}
+	return fmt.Sprintf(book.text().SeriesVolume, metadata.Series, metadata.SeriesIndex)
+}
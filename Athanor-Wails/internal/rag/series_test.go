@@ -0,0 +1,65 @@
+package rag
+
+import "testing"
+
+func TestSeriesFromPackage(t *testing.T) {
+	cases := []struct {
+		name   string
+		opf    string
+		series string
+		index  string
+	}{
+		{
+			name: "calibre",
+			opf: `<package><metadata>
+<meta name="calibre:series" content="The Expanse"/>
+<meta name="calibre:series_index" content="3.0"/>
+</metadata></package>`,
+			series: "The Expanse",
+			index:  "3",
+		},
+		{
+			name: "epub3 collection",
+			opf: `<package><metadata>
+<meta property="belongs-to-collection" id="c01">三体</meta>
+<meta refines="#c01" property="collection-type">series</meta>
+<meta refines="#c01" property="group-position">2</meta>
+</metadata></package>`,
+			series: "三体",
+			index:  "2",
+		},
+		{
+			name:   "none",
+			opf:    `<package><metadata><meta name="cover" content="cover-image"/></metadata></package>`,
+			series: "",
+			index:  "",
+		},
+	}
+	for _, tc := range cases {
+		var pkg packageXML
+		if err := decodeXML([]byte(tc.opf), &pkg); err != nil {
+			t.Fatalf("%s: decode opf: %v", tc.name, err)
+		}
+		series, index := seriesFromPackage(pkg)
+		if series != tc.series || index != tc.index {
+			t.Fatalf("%s: expected %q #%q, got %q #%q", tc.name, tc.series, tc.index, series, index)
+		}
+	}
+}
+
+func TestSeriesBaseName(t *testing.T) {
+	cases := []struct {
+		metadata Metadata
+		expected string
+	}{
+		{Metadata{Series: "The Expanse", SeriesIndex: "3"}, "The Expanse_03_book_athanor"},
+		{Metadata{Series: "The Expanse", SeriesIndex: "3.5"}, "The Expanse_03.5_book_athanor"},
+		{Metadata{Series: "A/B"}, "A_B_book_athanor"},
+		{Metadata{}, "book_athanor"},
+	}
+	for _, tc := range cases {
+		if got := SeriesBaseName(tc.metadata, "book_athanor"); got != tc.expected {
+			t.Fatalf("expected %q, got %q", tc.expected, got)
+		}
+	}
+}
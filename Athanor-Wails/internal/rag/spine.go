@@ -0,0 +1,75 @@
+package rag
+
+type spineReport struct {
+	documents []string
+	orphans   []string
+	warnings  []string
+}
+
+// checkSpine resolves the spine into document hrefs and reports content the
+// spine does not cover: XHTML documents that are only reachable from the
+// manifest or the TOC, spine entries with no document behind them, and TOC
+// entries that point backwards in reading order.
+func checkSpine(entries map[string]zipEntry, pkg packageXML, manifest map[string]manifestItem, targets []tocTarget) spineReport {
+	var report spineReport
+
+	inSpine := map[string]int{}
+	for _, itemref := range pkg.Spine.Itemrefs {
+		item, ok := manifest[itemref.IDRef]
+		if !ok {
+			report.warnings = append(report.warnings, "spine:unknown_idref:"+itemref.IDRef)
+			continue
+		}
+		if _, ok := entries[item.Href]; !ok {
+			report.warnings = append(report.warnings, "spine:missing_document:"+item.Href)
+			continue
+		}
+		if _, ok := inSpine[item.Href]; ok {
+			report.warnings = append(report.warnings, "spine:duplicate:"+item.Href)
+			continue
+		}
+		inSpine[item.Href] = len(report.documents)
+		report.documents = append(report.documents, item.Href)
+	}
+
+	inTOC := map[string]struct{}{}
+	for _, target := range targets {
+		inTOC[target.HrefBase] = struct{}{}
+	}
+	for _, item := range pkg.Manifest.Items {
+		if !isXHTMLItem(item.MediaType, item.Href) || hasProperty(item.Properties, "nav") {
+			continue
+		}
+		href := manifest[item.ID].Href
+		if _, ok := inSpine[href]; ok {
+			continue
+		}
+		if _, ok := entries[href]; !ok {
+			continue
+		}
+		if _, ok := inTOC[href]; ok {
+			report.warnings = append(report.warnings, "spine:orphan_in_toc:"+href)
+		} else {
+			report.warnings = append(report.warnings, "spine:orphan:"+href)
+		}
+		report.orphans = append(report.orphans, href)
+	}
+
+	last := -1
+	reported := map[string]struct{}{}
+	for _, target := range targets {
+		index, ok := inSpine[target.HrefBase]
+		if !ok {
+			continue
+		}
+		if index < last {
+			if _, done := reported[target.HrefBase]; !done {
+				reported[target.HrefBase] = struct{}{}
+				report.warnings = append(report.warnings, "spine:toc_order:"+target.HrefBase)
+			}
+			continue
+		}
+		last = index
+	}
+	return report
+}
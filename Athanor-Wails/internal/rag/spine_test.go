@@ -0,0 +1,107 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSpineReportsOrphansAndOrder(t *testing.T) {
+	var pkg packageXML
+	opf := `<package>
+<manifest>
+  <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+  <item id="a" href="a.xhtml" media-type="application/xhtml+xml"/>
+  <item id="b" href="b.xhtml" media-type="application/xhtml+xml"/>
+  <item id="lost" href="lost.xhtml" media-type="application/xhtml+xml"/>
+  <item id="gone" href="gone.xhtml" media-type="application/xhtml+xml"/>
+</manifest>
+<spine><itemref idref="a"/><itemref idref="b"/><itemref idref="gone"/><itemref idref="nope"/></spine>
+</package>`
+	if err := decodeXML([]byte(opf), &pkg); err != nil {
+		t.Fatalf("decode opf: %v", err)
+	}
+	entries := map[string]zipEntry{
+		"nav.xhtml":  {name: "nav.xhtml"},
+		"a.xhtml":    {name: "a.xhtml"},
+		"b.xhtml":    {name: "b.xhtml"},
+		"lost.xhtml": {name: "lost.xhtml"},
+	}
+	targets := []tocTarget{
+		{HrefBase: "b.xhtml", PlayOrder: 1},
+		{HrefBase: "a.xhtml", PlayOrder: 2},
+		{HrefBase: "lost.xhtml", PlayOrder: 3},
+	}
+
+	report := checkSpine(entries, pkg, buildManifestIndex(".", pkg), targets)
+
+	if len(report.documents) != 2 || report.documents[0] != "a.xhtml" {
+		t.Fatalf("unexpected spine documents: %v", report.documents)
+	}
+	if len(report.orphans) != 1 || report.orphans[0] != "lost.xhtml" {
+		t.Fatalf("unexpected orphans: %v", report.orphans)
+	}
+	expected := []string{
+		"spine:missing_document:gone.xhtml",
+		"spine:unknown_idref:nope",
+		"spine:orphan_in_toc:lost.xhtml",
+		"spine:toc_order:a.xhtml",
+	}
+	if len(report.warnings) != len(expected) {
+		t.Fatalf("expected warnings %v, got %v", expected, report.warnings)
+	}
+	for i := range expected {
+		if report.warnings[i] != expected[i] {
+			t.Fatalf("expected warnings %v, got %v", expected, report.warnings)
+		}
+	}
+}
+
+func TestConvertEPUBIncludesOrphansWhenRequested(t *testing.T) {
+	workDir := testOutputDir(t, "spine-orphans")
+	input := filepath.Join(workDir, "orphans.epub")
+	writeTestEPUB(t, input, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf": `<?xml version="1.0" encoding="UTF-8"?>
+<package version="2.0" xmlns="http://www.idpf.org/2007/opf">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Orphans</dc:title></metadata>
+  <manifest>
+    <item id="chap1" href="chap1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="extra" href="extra.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="chap1"/></spine>
+</package>`,
+		"OEBPS/chap1.xhtml": `<html><body><h1>Chapter One</h1><p>Body text.</p></body></html>`,
+		"OEBPS/extra.xhtml": `<html><body><h1>Lost Chapter</h1><p>Content the spine forgot.</p></body></html>`,
+	})
+
+	result, err := ConvertEPUB(context.Background(), input, Options{
+		OutputRootDir:  workDir,
+		BaseName:       "orphans",
+		IncludeOrphans: true,
+	})
+	if err != nil {
+		t.Fatalf("ConvertEPUB failed: %v", err)
+	}
+	if result.Stats.BackMatterCount != 1 {
+		t.Fatalf("expected orphan appended as backmatter, got %+v", result.Stats)
+	}
+
+	data, err := os.ReadFile(result.DiagnosticsPath)
+	if err != nil {
+		t.Fatalf("read diagnostics: %v", err)
+	}
+	var diagnostics Diagnostics
+	if err := json.Unmarshal(data, &diagnostics); err != nil {
+		t.Fatalf("unmarshal diagnostics: %v", err)
+	}
+	if len(diagnostics.Summary.Warnings) != 1 || diagnostics.Summary.Warnings[0] != "spine:orphan:OEBPS/extra.xhtml" {
+		t.Fatalf("expected orphan warning, got %v", diagnostics.Summary.Warnings)
+	}
+	last := diagnostics.Chapters[len(diagnostics.Chapters)-1]
+	if last.ClassifyReason != "spine:orphan" || last.Title != "Lost Chapter" {
+		t.Fatalf("unexpected orphan chapter: %+v", last)
+	}
+}
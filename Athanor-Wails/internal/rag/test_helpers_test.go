@@ -1,8 +1,10 @@
 package rag
 
 import (
+	"archive/zip"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 )
 
@@ -16,3 +18,51 @@ func testOutputDir(t *testing.T, name string) string {
 	}
 	return dir
 }
+
+func writeTestEPUB(t *testing.T, output string, files map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(output)
+	if err != nil {
+		t.Fatalf("create epub: %v", err)
+	}
+
+	writer := zip.NewWriter(file)
+	header := &zip.FileHeader{Name: "mimetype", Method: zip.Store}
+	entry, err := writer.CreateHeader(header)
+	if err != nil {
+		t.Fatalf("create mimetype entry: %v", err)
+	}
+	if _, err := entry.Write([]byte("application/epub+zip")); err != nil {
+		t.Fatalf("write mimetype entry: %v", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("create entry %s: %v", name, err)
+		}
+		if _, err := entry.Write([]byte(files[name])); err != nil {
+			t.Fatalf("write entry %s: %v", name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close epub writer: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("close epub file: %v", err)
+	}
+}
+
+const testContainerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
@@ -3,12 +3,81 @@ package rag
 import "context"
 
 type Options struct {
-	OutputRootDir string
-	BaseName      string
-	Logger        func(string)
-	Progress      func(stage string, pct float64, message string)
-	Context       context.Context
-	ChunkConfig   ChunkConfig
+	OutputRootDir   string
+	BaseName        string
+	Logger          func(string)
+	Progress        func(stage string, pct float64, message string)
+	Context         context.Context
+	ChunkConfig     ChunkConfig
+	Readability     bool
+	Cover           CoverMode
+	Styles          StyleConfig
+	Headings        HeadingConfig
+	IncludeOrphans  bool
+	DropDuplicates  bool
+	SeriesNumbering bool
+	PlainText       bool
+	TextWrap        int
+	Wrap            WrapMode
+	Columns         int
+	Provenance      bool
+	AsciiDoc        bool
+	DocBook         bool
+	SQLScript       bool
+	DOCX            bool
+	ODT             bool
+	RTF             bool
+	HTML            bool
+	VerticalHTML    bool
+	Direction       TextDirection
+	Chinese         ChineseConversion
+	// DOCXReference is a .docx whose styles the DOCX output takes, as
+	// pandoc's --reference-doc does; empty uses the built-in styles.
+	DOCXReference string
+	// ReadableHTML opens the HTML reader in its dyslexia-friendly font mode.
+	ReadableHTML bool
+	// DarkHTML opens the HTML reader in its dark theme, with dimmed images.
+	DarkHTML bool
+	// TOCDepth limits the HTML reader's contents list to chapters at most
+	// this deep in the navigation tree; 0 lists every level.
+	TOCDepth int
+	// NoTOC leaves the contents list off the HTML reader's index page.
+	NoTOC bool
+	// OnWarning reports warnings in the result (the default), fails the
+	// conversion before anything is written, or ignores them.
+	OnWarning WarningPolicy
+	// Notes moves the main Markdown's footnotes into one section at the end
+	// of the book. The per-chapter files always keep their own notes.
+	Notes NotePlacement
+	// Labels sets the language of footnote headings, the HTML pager and the
+	// other text the outputs carry that does not come from the book.
+	Labels LabelLanguage
+	// ChapterWritten is called as soon as each per-chapter Markdown file is
+	// on disk, before the rest of the outputs are rendered.
+	ChapterWritten func(ChapterOutput)
+}
+
+// ChapterOutput describes one per-chapter Markdown file that has been written.
+type ChapterOutput struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Path  string `json:"path"`
+	Index int    `json:"index"`
+	Total int    `json:"total"`
+}
+
+type HeadingConfig struct {
+	Rule HeadingRule `json:"rule,omitempty"`
+	Map  map[int]int `json:"map,omitempty"`
+}
+
+type StyleConfig struct {
+	Emphasis    bool      `json:"emphasis,omitempty"`
+	Blockquotes bool      `json:"blockquotes,omitempty"`
+	Callouts    bool      `json:"callouts,omitempty"`
+	Alignment   bool      `json:"alignment,omitempty"`
+	Math        MathStyle `json:"math,omitempty"`
+	Images      ImageMode `json:"images,omitempty"`
 }
 
 type ChunkConfig struct {
@@ -19,14 +88,27 @@ type ChunkConfig struct {
 }
 
 type ConvertResult struct {
-	MainMarkdownPath string
+	MainMarkdownPath  string
 	DebugMarkdownPath string
-	ArtifactDir      string
-	MetadataPath     string
-	TOCPath          string
-	ChunksPath       string
-	DiagnosticsPath  string
-	Stats            Stats
+	ArtifactDir       string
+	MetadataPath      string
+	TOCPath           string
+	ChunksPath        string
+	DiagnosticsPath   string
+	ReadabilityPath   string
+	CoverPath         string
+	TextPath          string
+	AsciiDocPath      string
+	DocBookPath       string
+	SQLScriptPath     string
+	DOCXPath          string
+	ODTPath           string
+	RTFPath           string
+	HTMLPath          string
+	Stats             Stats
+	// Warnings lists the non-fatal problems found, unless OnWarning is
+	// WarningsIgnore.
+	Warnings []Warning
 }
 
 type Stats struct {
@@ -38,10 +120,31 @@ type Stats struct {
 }
 
 type Book struct {
-	Metadata Metadata  `json:"metadata"`
-	Main     []Chapter `json:"main"`
-	Back     []Chapter `json:"back"`
-	Stats    Stats     `json:"stats"`
+	Metadata   Metadata  `json:"metadata"`
+	Main       []Chapter `json:"main"`
+	Back       []Chapter `json:"back"`
+	Stats      Stats     `json:"stats"`
+	warnings   []string
+	duplicates []DuplicateChapter
+	encrypted  []EncryptedResource
+	images     imageRefs
+	// verticalText opens the HTML reader in vertical right-to-left writing.
+	verticalText bool
+	// readableFont opens the HTML reader with the dyslexia-friendly font.
+	readableFont bool
+	// darkTheme opens the HTML reader in the dark theme.
+	darkTheme bool
+	// direction overrides the text direction of the HTML reader.
+	direction TextDirection
+	// tocDepth limits the HTML contents list: 0 lists every level and a
+	// negative value leaves the list out.
+	tocDepth int
+	// coverData is the cover image shown on the HTML reader's first page.
+	coverData []byte
+	// notes is where the main Markdown puts footnotes.
+	notes NotePlacement
+	// labels is the resolved label language; it is never LabelsAuto.
+	labels LabelLanguage
 }
 
 type Metadata struct {
@@ -51,6 +154,10 @@ type Metadata struct {
 	Publisher     string   `json:"publisher,omitempty"`
 	PublishedDate string   `json:"publishedDate,omitempty"`
 	Identifier    string   `json:"identifier,omitempty"`
+	ISBN          string   `json:"isbn,omitempty"`
+	CoverImage    string   `json:"coverImage,omitempty"`
+	Series        string   `json:"series,omitempty"`
+	SeriesIndex   string   `json:"seriesIndex,omitempty"`
 	SourcePath    string   `json:"sourcePath"`
 	SourceSHA256  string   `json:"sourceSha256"`
 }
@@ -62,8 +169,12 @@ type Chapter struct {
 	Kind           ChapterKind `json:"kind"`
 	ClassifyReason string      `json:"classifyReason,omitempty"`
 	SourceRef      string      `json:"sourceRef"`
+	Language       string      `json:"language,omitempty"`
+	Depth          int         `json:"depth,omitempty"`
 	Blocks         []Block     `json:"blocks"`
 	Footnotes      []Footnote  `json:"footnotes,omitempty"`
+	anchor         string
+	anchorKeys     []string
 	tocTrimmed     int
 	crossFileNotes int
 	warnings       []string
@@ -82,6 +193,11 @@ type Block struct {
 	Items   []string   `json:"items,omitempty"`
 	Rows    [][]string `json:"rows,omitempty"`
 	Ordered bool       `json:"ordered,omitempty"`
+	Label   string     `json:"label,omitempty"`
+	Anchor  string     `json:"anchor,omitempty"`
+	Align   TextAlign  `json:"align,omitempty"`
+
+	anchorKeys []string
 }
 
 type TOCItem struct {
@@ -91,6 +207,8 @@ type TOCItem struct {
 	ClassifyReason string      `json:"classifyReason,omitempty"`
 	Order          int         `json:"order"`
 	Source         string      `json:"source"`
+	Language       string      `json:"language,omitempty"`
+	Depth          int         `json:"depth,omitempty"`
 }
 
 type Chunk struct {
@@ -113,31 +231,48 @@ type Chunk struct {
 }
 
 type Diagnostics struct {
-	Summary  DiagnosticsSummary  `json:"summary"`
-	Chapters []ChapterDiagnostic `json:"chapters"`
-	Chunks   []ChunkDiagnostic   `json:"chunks,omitempty"`
+	Summary    DiagnosticsSummary  `json:"summary"`
+	Chapters   []ChapterDiagnostic `json:"chapters"`
+	Chunks     []ChunkDiagnostic   `json:"chunks,omitempty"`
+	Duplicates []DuplicateChapter  `json:"duplicates,omitempty"`
+	Encrypted  []EncryptedResource `json:"encryptedResources,omitempty"`
+}
+
+type EncryptedResource struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	Kind      string `json:"kind"`
+}
+
+type DuplicateChapter struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	SourceRef   string `json:"sourceRef"`
+	DuplicateOf string `json:"duplicateOf"`
+	Dropped     bool   `json:"dropped,omitempty"`
 }
 
 type DiagnosticsSummary struct {
-	PipelineVersion          string `json:"pipelineVersion"`
-	GeneratedAt              string `json:"generatedAt"`
-	SourcePath               string `json:"sourcePath"`
-	SourceSHA256             string `json:"sourceSha256"`
-	Title                    string `json:"title"`
-	ChapterCount             int    `json:"chapterCount"`
-	FrontMatterCount         int    `json:"frontMatterCount"`
-	BackMatterCount          int    `json:"backMatterCount"`
-	ChunkCount               int    `json:"chunkCount"`
-	FootnoteCount            int    `json:"footnoteCount"`
-	TOCResidualBlocksRemoved int    `json:"tocResidualBlocksRemoved"`
-	CrossFileFootnotesLinked int    `json:"crossFileFootnotesLinked"`
-	ShortChunkCount          int    `json:"shortChunkCount"`
-	OversizeChunkCount       int    `json:"oversizeChunkCount"`
-	MinChunkCharacters       int    `json:"minChunkCharacters"`
-	AverageChunkCharacters   int    `json:"averageChunkCharacters"`
-	P50ChunkCharacters       int    `json:"p50ChunkCharacters"`
-	P90ChunkCharacters       int    `json:"p90ChunkCharacters"`
-	MaxChunkCharacters       int    `json:"maxChunkCharacters"`
+	PipelineVersion          string   `json:"pipelineVersion"`
+	GeneratedAt              string   `json:"generatedAt"`
+	SourcePath               string   `json:"sourcePath"`
+	SourceSHA256             string   `json:"sourceSha256"`
+	Title                    string   `json:"title"`
+	ChapterCount             int      `json:"chapterCount"`
+	FrontMatterCount         int      `json:"frontMatterCount"`
+	BackMatterCount          int      `json:"backMatterCount"`
+	ChunkCount               int      `json:"chunkCount"`
+	FootnoteCount            int      `json:"footnoteCount"`
+	TOCResidualBlocksRemoved int      `json:"tocResidualBlocksRemoved"`
+	CrossFileFootnotesLinked int      `json:"crossFileFootnotesLinked"`
+	ShortChunkCount          int      `json:"shortChunkCount"`
+	OversizeChunkCount       int      `json:"oversizeChunkCount"`
+	MinChunkCharacters       int      `json:"minChunkCharacters"`
+	AverageChunkCharacters   int      `json:"averageChunkCharacters"`
+	P50ChunkCharacters       int      `json:"p50ChunkCharacters"`
+	P90ChunkCharacters       int      `json:"p90ChunkCharacters"`
+	MaxChunkCharacters       int      `json:"maxChunkCharacters"`
+	Warnings                 []string `json:"warnings,omitempty"`
 }
 
 type ChapterDiagnostic struct {
@@ -147,6 +282,7 @@ type ChapterDiagnostic struct {
 	Kind                     ChapterKind `json:"kind"`
 	ClassifyReason           string      `json:"classifyReason,omitempty"`
 	SourceRef                string      `json:"sourceRef"`
+	Language                 string      `json:"language,omitempty"`
 	BlockCount               int         `json:"blockCount"`
 	FootnoteCount            int         `json:"footnoteCount"`
 	ChunkCount               int         `json:"chunkCount"`
@@ -174,3 +310,31 @@ type ChunkDiagnostic struct {
 	TokenEstimate int         `json:"tokenEstimate,omitempty"`
 	Warnings      []string    `json:"warnings,omitempty"`
 }
+
+type ReadabilityReport struct {
+	Summary  ReadabilityMetrics   `json:"summary"`
+	Chapters []ChapterReadability `json:"chapters"`
+}
+
+type ChapterReadability struct {
+	ID      string             `json:"id"`
+	Title   string             `json:"title"`
+	Order   int                `json:"order"`
+	Kind    ChapterKind        `json:"kind"`
+	Metrics ReadabilityMetrics `json:"metrics"`
+}
+
+type ReadabilityMetrics struct {
+	Script                string  `json:"script"`
+	Characters            int     `json:"characters"`
+	Words                 int     `json:"words,omitempty"`
+	Syllables             int     `json:"syllables,omitempty"`
+	Sentences             int     `json:"sentences"`
+	AverageSentenceLength float64 `json:"averageSentenceLength"`
+	P90SentenceLength     int     `json:"p90SentenceLength"`
+	MaxSentenceLength     int     `json:"maxSentenceLength"`
+	// The Flesch scores are only computed for Latin-script text. They are
+	// pointers so that a real score of 0 is still written.
+	FleschReadingEase  *float64 `json:"fleschReadingEase,omitempty"`
+	FleschKincaidGrade *float64 `json:"fleschKincaidGrade,omitempty"`
+}
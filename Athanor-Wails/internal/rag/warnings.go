@@ -0,0 +1,98 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrWarnings is returned, wrapped, when OnWarning is WarningsFail and the
+// book has warnings.
+var ErrWarnings = errors.New("转换存在警告")
+
+// Warning is one non-fatal problem found in a book. Code identifies the
+// kind of problem, Detail names the resource or chapter it is about, and
+// Chapter is set when the problem belongs to one chapter.
+type Warning struct {
+	Code    string `json:"code"`
+	Detail  string `json:"detail,omitempty"`
+	Chapter string `json:"chapter,omitempty"`
+	Message string `json:"message"`
+}
+
+// String returns the warning in the compact "code:detail" form used in
+// diagnostics.json.
+func (w Warning) String() string {
+	out := w.Code
+	if w.Detail != "" {
+		out += ":" + w.Detail
+	}
+	if w.Chapter != "" {
+		out = w.Chapter + ": " + out
+	}
+	return out
+}
+
+var warningMessages = map[string]string{
+	"spine:unknown_idref":                "spine 引用了清单中不存在的条目",
+	"spine:missing_document":             "spine 中的文档在 EPUB 中缺失",
+	"spine:duplicate":                    "spine 重复引用了同一文档",
+	"spine:orphan":                       "文档不在 spine 中",
+	"spine:orphan_in_toc":                "目录引用了 spine 之外的文档",
+	"spine:toc_order":                    "目录顺序与 spine 不一致",
+	"encryption:unreadable":              "encryption.xml 无法解析",
+	"encryption:drm_content":             "章节受 DRM 保护，无法转换",
+	"image:missing":                      "图片在 EPUB 中不存在，已移除引用",
+	"splitter:multi_target_unsplittable": "文档包含多个目录目标，无法拆分",
+	"splitter:multi_target_no_split":     "文档包含多个目录目标，未拆分",
+	"duplicate_of":                       "与另一章节内容重复",
+}
+
+// warningCategories have a two-part code such as "spine:orphan"; any other
+// warning has a one-part code such as "duplicate_of".
+var warningCategories = map[string]bool{
+	"spine":      true,
+	"encryption": true,
+	"image":      true,
+	"splitter":   true,
+}
+
+// parseWarning splits a raw warning such as "spine:orphan:Text/x.xhtml"
+// into its code and detail.
+func parseWarning(raw string) Warning {
+	code, detail, _ := strings.Cut(raw, ":")
+	if warningCategories[code] {
+		sub, rest, _ := strings.Cut(detail, ":")
+		code, detail = code+":"+sub, rest
+	}
+	message := warningMessages[code]
+	if message == "" {
+		message = code
+	}
+	return Warning{Code: code, Detail: detail, Message: message}
+}
+
+// conversionWarnings lists the book's non-fatal problems: parse, spine,
+// encryption and image warnings, then each chapter's own warnings.
+func conversionWarnings(book Book) []Warning {
+	var warnings []Warning
+	for _, raw := range book.warnings {
+		warnings = append(warnings, parseWarning(raw))
+	}
+	for _, chapter := range append(append([]Chapter(nil), book.Main...), book.Back...) {
+		for _, raw := range chapter.warnings {
+			warning := parseWarning(raw)
+			warning.Chapter = chapter.ID
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+func warningsError(warnings []Warning) error {
+	parts := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		parts = append(parts, warning.String())
+	}
+	return fmt.Errorf("%w（%d 个）: %s", ErrWarnings, len(warnings), strings.Join(parts, "; "))
+}
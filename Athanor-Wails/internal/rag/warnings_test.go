@@ -0,0 +1,39 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWarning(t *testing.T) {
+	cases := []struct {
+		raw    string
+		code   string
+		detail string
+	}{
+		{"spine:orphan:Text/a:b.xhtml", "spine:orphan", "Text/a:b.xhtml"},
+		{"encryption:unreadable", "encryption:unreadable", ""},
+		{"image:missing:OEBPS/img/x.png", "image:missing", "OEBPS/img/x.png"},
+		{"duplicate_of:chapter-002", "duplicate_of", "chapter-002"},
+	}
+	for _, c := range cases {
+		warning := parseWarning(c.raw)
+		if warning.Code != c.code || warning.Detail != c.detail || warning.Message == "" {
+			t.Errorf("parseWarning(%q) = %+v, want code %q detail %q", c.raw, warning, c.code, c.detail)
+		}
+		if warning.String() != c.raw {
+			t.Errorf("String() = %q, want %q", warning.String(), c.raw)
+		}
+	}
+}
+
+func TestRedactLogDetails(t *testing.T) {
+	line := "⚠️ image:missing:" + logDetail("Images/secret.png") + " " + logDetail("Images/secret.png")
+	got := RedactLogDetails(line)
+	if strings.Contains(got, "secret") || !strings.HasPrefix(got, "⚠️ image:missing:«") {
+		t.Fatalf("expected the detail to be hashed, got %q", got)
+	}
+	if first, second, _ := strings.Cut(strings.TrimPrefix(got, "⚠️ image:missing:"), " "); first != second {
+		t.Fatalf("the same detail should hash the same, got %q", got)
+	}
+}
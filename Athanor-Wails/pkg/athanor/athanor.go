@@ -0,0 +1,182 @@
+// Package athanor exposes the EPUB to RAG Markdown pipeline to other Go
+// programs. It is a thin wrapper over the internal rag package, which stays
+// free to change its helpers without breaking embedders.
+package athanor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"Athanor-Wails/internal/rag"
+)
+
+type (
+	Options           = rag.Options
+	Result            = rag.ConvertResult
+	Stats             = rag.Stats
+	ChunkConfig       = rag.ChunkConfig
+	StyleConfig       = rag.StyleConfig
+	HeadingConfig     = rag.HeadingConfig
+	CoverMode         = rag.CoverMode
+	HeadingRule       = rag.HeadingRule
+	MathStyle         = rag.MathStyle
+	ImageMode         = rag.ImageMode
+	WrapMode          = rag.WrapMode
+	TextDirection     = rag.TextDirection
+	Inspection        = rag.Inspection
+	ChineseConversion = rag.ChineseConversion
+	ChapterOutput     = rag.ChapterOutput
+	Plan              = rag.Plan
+	PlannedOutput     = rag.PlannedOutput
+	WarningPolicy     = rag.WarningPolicy
+	Warning           = rag.Warning
+	Preview           = rag.Preview
+	NotePlacement     = rag.NotePlacement
+	LabelLanguage     = rag.LabelLanguage
+)
+
+const (
+	CoverModeAuto    = rag.CoverModeAuto
+	CoverModeExtract = rag.CoverModeExtract
+	CoverModeSkip    = rag.CoverModeSkip
+
+	HeadingRuleKeep    = rag.HeadingRuleKeep
+	HeadingRuleCompact = rag.HeadingRuleCompact
+	HeadingRuleShift   = rag.HeadingRuleShift
+
+	MathStyleDollar = rag.MathStyleDollar
+	MathStyleLaTeX  = rag.MathStyleLaTeX
+	MathStyleFenced = rag.MathStyleFenced
+
+	ImageModeStrip    = rag.ImageModeStrip
+	ImageModeRelative = rag.ImageModeRelative
+	ImageModeAbsolute = rag.ImageModeAbsolute
+	ImageModeEmbed    = rag.ImageModeEmbed
+
+	WrapNone = rag.WrapNone
+	WrapAuto = rag.WrapAuto
+
+	DefaultWrapColumns = rag.DefaultWrapColumns
+	MaxTOCDepth        = rag.MaxTOCDepth
+
+	DirectionAuto = rag.DirectionAuto
+	DirectionLTR  = rag.DirectionLTR
+	DirectionRTL  = rag.DirectionRTL
+
+	ChineseConversionNone = rag.ChineseConversionNone
+	ChineseToTraditional  = rag.ChineseToTraditional
+	ChineseToSimplified   = rag.ChineseToSimplified
+
+	WarningsReport = rag.WarningsReport
+	WarningsFail   = rag.WarningsFail
+	WarningsIgnore = rag.WarningsIgnore
+
+	NotesChapter = rag.NotesChapter
+	NotesBook    = rag.NotesBook
+
+	LabelsDefault = rag.LabelsDefault
+	LabelsChinese = rag.LabelsChinese
+	LabelsEnglish = rag.LabelsEnglish
+	LabelsAuto    = rag.LabelsAuto
+)
+
+// ErrWarnings is wrapped by the error Convert returns when OnWarning is
+// WarningsFail and the book has warnings.
+var ErrWarnings = rag.ErrWarnings
+
+// Converter runs conversions with a fixed set of options. It holds no state
+// between calls and is safe for concurrent use.
+type Converter struct {
+	options Options
+}
+
+func NewConverter(options Options) *Converter {
+	return &Converter{options: options}
+}
+
+// Convert converts one EPUB. When the options leave OutputRootDir or
+// BaseName empty, the outputs go next to the input as <name>_athanor.md and
+// <name>_athanor/.
+func (c *Converter) Convert(ctx context.Context, inputPath string) (Result, error) {
+	options, err := c.optionsFor(inputPath)
+	if err != nil {
+		return Result{}, err
+	}
+	return rag.ConvertEPUB(ctx, inputPath, options)
+}
+
+// Plan reports what Convert would write and the problems it would run into,
+// without writing anything.
+func (c *Converter) Plan(ctx context.Context, inputPath string) (Plan, error) {
+	options, err := c.optionsFor(inputPath)
+	if err != nil {
+		return Plan{}, err
+	}
+	return rag.PlanConversion(ctx, inputPath, options)
+}
+
+// Preview renders the first chapter as Convert would, without writing
+// anything.
+func (c *Converter) Preview(ctx context.Context, inputPath string) (Preview, error) {
+	options, err := c.optionsFor(inputPath)
+	if err != nil {
+		return Preview{}, err
+	}
+	return rag.PreviewEPUB(ctx, inputPath, options)
+}
+
+func (c *Converter) optionsFor(inputPath string) (Options, error) {
+	if !strings.EqualFold(filepath.Ext(inputPath), ".epub") {
+		return Options{}, fmt.Errorf("仅支持 EPUB 文件: %s", inputPath)
+	}
+	options := c.options
+	if options.OutputRootDir == "" {
+		options.OutputRootDir = filepath.Dir(inputPath)
+	}
+	if options.BaseName == "" {
+		options.BaseName = DefaultBaseName(inputPath)
+	}
+	return options, nil
+}
+
+// Outputs lists what Convert would write directly into the output
+// directory: the main Markdown, the artifact directory and the enabled
+// exports, named as series numbering would name them. It only reads the
+// book's package document.
+func (c *Converter) Outputs(inputPath string) ([]string, error) {
+	options, err := c.optionsFor(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if options.SeriesNumbering {
+		series, err := rag.ReadSeries(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		options.BaseName = rag.SeriesBaseName(series, options.BaseName)
+	}
+	return rag.TopLevelOutputs(options), nil
+}
+
+// Inspect summarizes an EPUB without writing any output.
+func Inspect(ctx context.Context, inputPath string) (Inspection, error) {
+	if !strings.EqualFold(filepath.Ext(inputPath), ".epub") {
+		return Inspection{}, fmt.Errorf("仅支持 EPUB 文件: %s", inputPath)
+	}
+	return rag.InspectEPUB(ctx, inputPath)
+}
+
+// DefaultBaseName is the base name Convert uses when the options leave it
+// empty, e.g. "三体_athanor" for 三体.epub.
+func DefaultBaseName(inputPath string) string {
+	return rag.DefaultBaseName(inputPath)
+}
+
+// ApplyOutputFormat sets the output switches in options from a comma
+// separated format list such as "md,txt:80,html:dark", the syntax the CLI's
+// --format flag and the desktop app share.
+func ApplyOutputFormat(options *Options, format string) error {
+	return rag.ApplyOutputFormat(options, format)
+}
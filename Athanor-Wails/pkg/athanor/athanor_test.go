@@ -0,0 +1,156 @@
+package athanor
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultBaseName(t *testing.T) {
+	if got := DefaultBaseName(filepath.Join("books", "三体.epub")); got != "三体_athanor" {
+		t.Fatalf("unexpected base name: %s", got)
+	}
+}
+
+func TestConvertRejectsNonEPUB(t *testing.T) {
+	_, err := NewConverter(Options{}).Convert(context.Background(), "book.pdf")
+	if err == nil {
+		t.Fatal("expected non-EPUB input to be rejected")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	workDir := filepath.Join("..", "..", ".tmp", "pkg-tests", "convert")
+	_ = os.RemoveAll(workDir)
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatalf("mkdir work dir: %v", err)
+	}
+	input := filepath.Join(workDir, "sample.epub")
+	writeSampleEPUB(t, input)
+
+	options := Options{OutputRootDir: filepath.Join(workDir, "out")}
+	if err := ApplyOutputFormat(&options, "txt,html"); err != nil {
+		t.Fatalf("apply format: %v", err)
+	}
+	converter := NewConverter(options)
+	result, err := converter.Convert(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	if filepath.Base(result.MainMarkdownPath) != "sample_athanor.md" {
+		t.Fatalf("expected the default base name, got %s", result.MainMarkdownPath)
+	}
+	data, err := os.ReadFile(result.MainMarkdownPath)
+	if err != nil {
+		t.Fatalf("read main markdown: %v", err)
+	}
+	markdown := string(data)
+	for _, want := range []string{"# Sample Book", "## Chapter One", "The first paragraph of the book.", "[^1]: A short note."} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("expected %q in markdown:\n%s", want, markdown)
+		}
+	}
+	if result.Stats.ChapterCount != 2 {
+		t.Fatalf("expected 2 chapters, got %+v", result.Stats)
+	}
+	for _, path := range []string{result.ChunksPath, result.MetadataPath, result.TextPath, result.HTMLPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected output %q: %v", path, err)
+		}
+	}
+
+	outputs, err := converter.Outputs(input)
+	if err != nil {
+		t.Fatalf("Outputs failed: %v", err)
+	}
+	for _, path := range outputs {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("Outputs lists %q, which Convert did not write: %v", path, err)
+		}
+	}
+}
+
+func writeSampleEPUB(t *testing.T, output string) {
+	t.Helper()
+
+	file, err := os.Create(output)
+	if err != nil {
+		t.Fatalf("create epub: %v", err)
+	}
+	writer := zip.NewWriter(file)
+	entry, err := writer.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		t.Fatalf("create mimetype entry: %v", err)
+	}
+	if _, err := entry.Write([]byte("application/epub+zip")); err != nil {
+		t.Fatalf("write mimetype entry: %v", err)
+	}
+	for _, part := range []struct{ name, content string }{
+		{"META-INF/container.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="UTF-8"?>
+<package version="3.0" xmlns="http://www.idpf.org/2007/opf" unique-identifier="BookId">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Sample Book</dc:title>
+    <dc:creator>Test Author</dc:creator>
+    <dc:language>en</dc:language>
+    <dc:identifier id="BookId">urn:uuid:5678</dc:identifier>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="one" href="one.xhtml" media-type="application/xhtml+xml"/>
+    <item id="two" href="two.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="one"/>
+    <itemref idref="two"/>
+  </spine>
+</package>`},
+		{"OEBPS/nav.xhtml", `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+  <body>
+    <nav epub:type="toc"><ol>
+      <li><a href="one.xhtml">Chapter One</a></li>
+      <li><a href="two.xhtml">Chapter Two</a></li>
+    </ol></nav>
+  </body>
+</html>`},
+		{"OEBPS/one.xhtml", `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+  <body>
+    <h1>Chapter One</h1>
+    <p>The first paragraph of the book.<a epub:type="noteref" href="#fn1">1</a></p>
+    <aside id="fn1" epub:type="footnote">A short note.</aside>
+  </body>
+</html>`},
+		{"OEBPS/two.xhtml", `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+  <body>
+    <h1>Chapter Two</h1>
+    <p>The second chapter has a paragraph too.</p>
+  </body>
+</html>`},
+	} {
+		entry, err := writer.Create(part.name)
+		if err != nil {
+			t.Fatalf("create entry %s: %v", part.name, err)
+		}
+		if _, err := entry.Write([]byte(part.content)); err != nil {
+			t.Fatalf("write entry %s: %v", part.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close epub writer: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("close epub file: %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	defaultQueueConcurrency = 1
+	maxQueueConcurrency     = 4
+)
+
+// Small Markdown-only books go to a fast lane with its own worker, so they
+// do not wait behind large books or multi-format exports.
+const (
+	fastLaneMaxBytes = 8 << 20
+	fastLaneWorkers  = 1
+)
+
+const (
+	QueueLaneNormal = "normal"
+	QueueLaneFast   = "fast"
+)
+
+const (
+	QueueStatusQueued    = "queued"
+	QueueStatusRunning   = "running"
+	QueueStatusComplete  = "complete"
+	QueueStatusWarning   = "warning"
+	QueueStatusError     = "error"
+	QueueStatusCancelled = "cancelled"
+)
+
+// jobSeq makes job IDs unique even when the clock does not advance between
+// two jobs, which happens on Windows' coarse timer.
+var jobSeq atomic.Uint64
+
+func newJobID() string {
+	return fmt.Sprintf("job_%d_%d", time.Now().UnixNano(), jobSeq.Add(1))
+}
+
+type QueueJob struct {
+	JobID        string  `json:"jobId"`
+	InputPath    string  `json:"inputPath"`
+	OutputFormat string  `json:"outputFormat"`
+	Status       string  `json:"status"`
+	Progress     float64 `json:"progress"`
+	Message      string  `json:"message,omitempty"`
+	OutputPath   string  `json:"outputPath,omitempty"`
+	EnqueuedAt   string  `json:"enqueuedAt"`
+	Lane         string  `json:"lane"`
+
+	// inFastLane is set while the job holds a fast lane worker.
+	inFastLane bool
+}
+
+// EnqueueBook adds a book to the conversion queue and starts it as soon as a
+// worker slot is free.
+func (a *App) EnqueueBook(inputPath string, outputFormat string) (QueueJob, error) {
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return QueueJob{}, fmt.Errorf("文件不可访问: %w", err)
+	}
+	if info.IsDir() || !strings.HasSuffix(strings.ToLower(inputPath), ".epub") {
+		return QueueJob{}, fmt.Errorf("仅支持 EPUB 文件")
+	}
+	if outputFormat == "" {
+		outputFormat = a.currentConfig().OutputFormat
+	}
+
+	job := &QueueJob{
+		JobID:        newJobID(),
+		InputPath:    inputPath,
+		OutputFormat: outputFormat,
+		Status:       QueueStatusQueued,
+		EnqueuedAt:   time.Now().Format(time.RFC3339),
+		Lane:         queueLane(info.Size(), outputFormat),
+	}
+
+	a.queueMu.Lock()
+	a.queue = append(a.queue, job)
+	snapshot := *job
+	a.queueMu.Unlock()
+
+	a.log(fmt.Sprintf("Queued: %s (%s, %s lane)", filepath.Base(inputPath), snapshot.JobID, snapshot.Lane))
+	a.emitQueue()
+	a.pumpQueue()
+	return snapshot, nil
+}
+
+func (a *App) ListQueue() []QueueJob {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+
+	out := make([]QueueJob, 0, len(a.queue))
+	for _, job := range a.queue {
+		out = append(out, *job)
+	}
+	return out
+}
+
+// RemoveFromQueue drops a job that is waiting or already finished. Running
+// jobs cannot be removed.
+func (a *App) RemoveFromQueue(jobID string) error {
+	a.queueMu.Lock()
+	index := -1
+	for i, job := range a.queue {
+		if job.JobID == jobID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		a.queueMu.Unlock()
+		return fmt.Errorf("任务不存在: %s", jobID)
+	}
+	if a.queue[index].Status == QueueStatusRunning {
+		a.queueMu.Unlock()
+		return fmt.Errorf("任务正在运行，无法移除: %s", jobID)
+	}
+	a.queue = append(a.queue[:index], a.queue[index+1:]...)
+	a.queueMu.Unlock()
+
+	a.log(fmt.Sprintf("Removed from queue: %s", jobID))
+	a.emitQueue()
+	return nil
+}
+
+// SetQueueConcurrency sets how many queued books are converted in parallel.
+func (a *App) SetQueueConcurrency(n int) int {
+	if n < 1 {
+		n = 1
+	}
+	if n > maxQueueConcurrency {
+		n = maxQueueConcurrency
+	}
+
+	a.queueMu.Lock()
+	a.queueConcurrency = n
+	a.queueMu.Unlock()
+
+	a.pumpQueue()
+	return n
+}
+
+// queueLane puts small books that only need Markdown in the fast lane.
+func queueLane(size int64, outputFormat string) string {
+	if size > fastLaneMaxBytes {
+		return QueueLaneNormal
+	}
+	for _, name := range strings.Split(outputFormat, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "md", "rag-md", "markdown":
+		default:
+			return QueueLaneNormal
+		}
+	}
+	return QueueLaneFast
+}
+
+func (a *App) pumpQueue() {
+	for _, job := range a.claimQueueJobs() {
+		go a.runQueueJob(job.JobID, job.InputPath, job.OutputFormat)
+	}
+}
+
+// claimQueueJobs marks the jobs that can start now as running. Fast lane
+// jobs take a fast lane worker first and fall back to the shared workers,
+// which serve every job in queue order.
+func (a *App) claimQueueJobs() []QueueJob {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+
+	limit := a.queueConcurrency
+	if limit < 1 {
+		limit = defaultQueueConcurrency
+	}
+	var claimed []QueueJob
+	for _, job := range a.queue {
+		if job.Status != QueueStatusQueued {
+			continue
+		}
+		switch {
+		case job.Lane == QueueLaneFast && a.queueFastRunning < fastLaneWorkers:
+			job.inFastLane = true
+			a.queueFastRunning++
+		case a.queueRunning < limit:
+			a.queueRunning++
+		default:
+			continue
+		}
+		job.Status = QueueStatusRunning
+		claimed = append(claimed, *job)
+	}
+	return claimed
+}
+
+func (a *App) runQueueJob(jobID, inputPath, outputFormat string) {
+	a.emitQueue()
+	result := a.runConversion(jobID, inputPath, outputFormat)
+
+	a.queueMu.Lock()
+	for _, job := range a.queue {
+		if job.JobID != jobID {
+			continue
+		}
+		job.Progress = result.Progress
+		job.Message = result.Message
+		job.OutputPath = result.OutputPath
+		switch {
+		case result.IsError:
+			job.Status = QueueStatusError
+		case result.Stage == "cancelled":
+			job.Status = QueueStatusCancelled
+		case len(result.Warnings) > 0:
+			job.Status = QueueStatusWarning
+		default:
+			job.Status = QueueStatusComplete
+		}
+		if job.inFastLane {
+			job.inFastLane = false
+			a.queueFastRunning--
+		} else {
+			a.queueRunning--
+		}
+	}
+	a.queueMu.Unlock()
+
+	a.emitQueue()
+	a.pumpQueue()
+}
+
+func (a *App) cancelQueued(jobID string) bool {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+
+	for _, job := range a.queue {
+		if job.JobID == jobID && job.Status == QueueStatusQueued {
+			job.Status = QueueStatusCancelled
+			job.Message = "任务已取消"
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) updateQueueProgress(jobID string, pct float64, msg string) {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+
+	for _, job := range a.queue {
+		if job.JobID == jobID {
+			job.Progress = pct
+			job.Message = msg
+			return
+		}
+	}
+}
+
+func (a *App) emitQueue() {
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "queue:updated", a.ListQueue())
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueueConvertsEnqueuedBooks(t *testing.T) {
+	workDir := filepath.Join(".", ".tmp", "test-queue")
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatalf("mkdir work dir: %v", err)
+	}
+	first := filepath.Join(workDir, "first.epub")
+	second := filepath.Join(workDir, "second.epub")
+	createSampleEPUB(t, first)
+	createSampleEPUB(t, second)
+
+	app := NewApp()
+	if _, err := app.EnqueueBook(filepath.Join(workDir, "missing.epub"), "md"); err == nil {
+		t.Fatal("expected missing file to be rejected")
+	}
+	firstJob, err := app.EnqueueBook(first, "md")
+	if err != nil {
+		t.Fatalf("enqueue first: %v", err)
+	}
+	if _, err := app.EnqueueBook(second, "md"); err != nil {
+		t.Fatalf("enqueue second: %v", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		done := 0
+		for _, job := range app.ListQueue() {
+			if job.Status == QueueStatusError {
+				t.Fatalf("job failed: %+v", job)
+			}
+			if job.Status == QueueStatusComplete {
+				done++
+			}
+		}
+		if done == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("queue did not finish: %+v", app.ListQueue())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := app.RemoveFromQueue(firstJob.JobID); err != nil {
+		t.Fatalf("remove finished job: %v", err)
+	}
+	if jobs := app.ListQueue(); len(jobs) != 1 || jobs[0].OutputPath == "" {
+		t.Fatalf("unexpected queue after removal: %+v", jobs)
+	}
+	if err := app.RemoveFromQueue(firstJob.JobID); err == nil {
+		t.Fatal("expected removing an unknown job to fail")
+	}
+}
+
+func TestQueueChecksSeriesNumberedOutput(t *testing.T) {
+	workDir := filepath.Join(".", ".tmp", "test-queue-series")
+	_ = os.RemoveAll(workDir)
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatalf("mkdir work dir: %v", err)
+	}
+	input := filepath.Join(workDir, "volume.epub")
+	createSeriesEPUB(t, input)
+
+	app := NewApp()
+	app.applyConfig(normalizeConfig(Config{ConflictPolicy: ConflictSkip, SeriesNumbering: true}))
+	runJob := func() QueueJob {
+		job, err := app.EnqueueBook(input, "md")
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			for _, queued := range app.ListQueue() {
+				if queued.JobID == job.JobID && queued.Status != QueueStatusQueued && queued.Status != QueueStatusRunning {
+					return queued
+				}
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("queue did not finish: %+v", app.ListQueue())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	want := filepath.Join(workDir, "示例系列_02_volume_athanor.md")
+	first := runJob()
+	if first.Status == QueueStatusError || first.OutputPath != want {
+		t.Fatalf("expected series-numbered output %s, got %+v", want, first)
+	}
+	second := runJob()
+	if second.Message != "输出已存在，已跳过" || second.OutputPath != want {
+		t.Fatalf("expected the second run to skip the existing series output, got %+v", second)
+	}
+}
+
+// createSeriesEPUB writes the sample book as volume 2 of a calibre series.
+func createSeriesEPUB(t *testing.T, output string) {
+	t.Helper()
+
+	plain := output + ".plain"
+	createSampleEPUB(t, plain)
+	defer os.Remove(plain)
+	reader, err := zip.OpenReader(plain)
+	if err != nil {
+		t.Fatalf("open sample epub: %v", err)
+	}
+	defer reader.Close()
+
+	file, err := os.Create(output)
+	if err != nil {
+		t.Fatalf("create epub: %v", err)
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for _, entry := range reader.File {
+		rc, err := entry.Open()
+		if err != nil {
+			t.Fatalf("open entry %s: %v", entry.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read entry %s: %v", entry.Name, err)
+		}
+		if entry.Name == "OEBPS/content.opf" {
+			data = []byte(strings.Replace(string(data), "</metadata>",
+				`<meta name="calibre:series" content="示例系列"/><meta name="calibre:series_index" content="2"/></metadata>`, 1))
+		}
+		target, err := writer.CreateHeader(&zip.FileHeader{Name: entry.Name, Method: entry.Method})
+		if err != nil {
+			t.Fatalf("create entry %s: %v", entry.Name, err)
+		}
+		if _, err := target.Write(data); err != nil {
+			t.Fatalf("write entry %s: %v", entry.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close epub writer: %v", err)
+	}
+}
+
+func TestNewJobIDIsUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id := newJobID()
+		if seen[id] {
+			t.Fatalf("duplicate job ID %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestCancelJobDropsQueuedJob(t *testing.T) {
+	app := NewApp()
+	app.queue = append(app.queue, &QueueJob{JobID: "job_waiting", Status: QueueStatusQueued})
+
+	if err := app.CancelJob("job_waiting"); err != nil {
+		t.Fatalf("cancel queued job: %v", err)
+	}
+	if jobs := app.ListQueue(); jobs[0].Status != QueueStatusCancelled {
+		t.Fatalf("expected cancelled job, got %+v", jobs[0])
+	}
+	if err := app.CancelJob("job_waiting"); err == nil {
+		t.Fatal("expected cancelling a finished job to fail")
+	}
+}
+
+func TestQueueLane(t *testing.T) {
+	cases := []struct {
+		size   int64
+		format string
+		want   string
+	}{
+		{1 << 20, "md", QueueLaneFast},
+		{1 << 20, "", QueueLaneFast},
+		{1 << 20, "md,html", QueueLaneNormal},
+		{fastLaneMaxBytes + 1, "md", QueueLaneNormal},
+	}
+	for _, c := range cases {
+		if got := queueLane(c.size, c.format); got != c.want {
+			t.Errorf("queueLane(%d, %q) = %q, want %q", c.size, c.format, got, c.want)
+		}
+	}
+}
+
+func TestEnqueueBookUsesConfiguredFormat(t *testing.T) {
+	workDir := filepath.Join(".", ".tmp", "test-queue-format")
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatalf("mkdir work dir: %v", err)
+	}
+	input := filepath.Join(workDir, "format.epub")
+	createSampleEPUB(t, input)
+
+	app := NewApp()
+	app.applyConfig(normalizeConfig(Config{OutputFormat: "md,html"}))
+	app.queueRunning = app.queueConcurrency
+	job, err := app.EnqueueBook(input, "")
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if job.OutputFormat != "md,html" || job.Lane != QueueLaneNormal {
+		t.Fatalf("expected the configured format and the normal lane, got %+v", job)
+	}
+}
+
+func TestFastLaneJobSkipsBusyWorkers(t *testing.T) {
+	app := NewApp()
+	app.queueRunning = app.queueConcurrency
+	app.queue = append(app.queue,
+		&QueueJob{JobID: "job_big", Status: QueueStatusQueued, Lane: QueueLaneNormal},
+		&QueueJob{JobID: "job_small", Status: QueueStatusQueued, Lane: QueueLaneFast},
+		&QueueJob{JobID: "job_small_2", Status: QueueStatusQueued, Lane: QueueLaneFast},
+	)
+
+	claimed := app.claimQueueJobs()
+	if len(claimed) != 1 || claimed[0].JobID != "job_small" {
+		t.Fatalf("expected only the first fast job to start, got %+v", claimed)
+	}
+	if app.queueFastRunning != 1 || !app.queue[1].inFastLane {
+		t.Fatalf("fast job did not take the fast lane worker: %+v", app.queue[1])
+	}
+
+	app.queueRunning = 0
+	claimed = app.claimQueueJobs()
+	if len(claimed) != 1 || claimed[0].JobID != "job_big" {
+		t.Fatalf("expected the waiting normal job to start next, got %+v", claimed)
+	}
+}
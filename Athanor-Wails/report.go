@@ -0,0 +1,124 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"Athanor-Wails/internal/rag"
+)
+
+// CreateFailureReport writes a zip for a failed job that can be attached to
+// a bug report: system info, the effective config, the error and the job's
+// log lines. It never includes the book or any output. File paths, the
+// book's file name and output name are replaced with placeholders, and the
+// chapter titles and resource paths in log lines with short hashes.
+func (a *App) CreateFailureReport(jobID string) (string, error) {
+	a.jobsMu.Lock()
+	record, ok := a.jobs[jobID]
+	a.jobsMu.Unlock()
+	if !ok || record.Error == "" {
+		return "", fmt.Errorf("没有失败的任务: %s", jobID)
+	}
+
+	cfg := a.currentConfig()
+	redact := reportRedactor(record.InputPath, a.outputDir(record.InputPath), record.BaseName)
+	if cfg.OutputDir != "" {
+		cfg.OutputDir = "<output-dir>"
+	}
+	// The frontend's settings are opaque to the backend and may hold
+	// recent paths, so they stay out of the report.
+	cfg.Settings = nil
+	var inputSize int64
+	if info, err := os.Stat(record.InputPath); err == nil {
+		inputSize = info.Size()
+	}
+	report := map[string]any{
+		"jobId":           jobID,
+		"error":           redact.Replace(rag.RedactLogDetails(record.Error)),
+		"pipelineVersion": rag.PipelineVersion,
+		"go":              runtime.Version(),
+		"os":              runtime.GOOS,
+		"arch":            runtime.GOARCH,
+		"cpus":            runtime.NumCPU(),
+		"outputFormat":    record.OutputFormat,
+		"inputSize":       inputSize,
+		"config":          cfg,
+	}
+
+	logs := a.logLines(record.logStart, record.logEnd)
+	for i, line := range logs {
+		logs[i] = redact.Replace(rag.RedactLogDetails(line))
+	}
+
+	reportPath := filepath.Join(os.TempDir(), "athanor-report-"+jobID+".zip")
+	if err := writeFailureReport(reportPath, report, logs); err != nil {
+		return "", err
+	}
+	a.log(fmt.Sprintf("Failure report: %s", reportPath))
+	return reportPath, nil
+}
+
+// reportRedactor replaces the paths, the book's file name and the output
+// name that show up in errors and log lines. Longer strings go first so a
+// full path is not left half replaced.
+func reportRedactor(inputPath, outputDir, baseName string) *strings.Replacer {
+	placeholders := map[string]string{
+		inputPath:                      "<input>",
+		filepath.Dir(inputPath):        "<input-dir>",
+		outputDir:                      "<output-dir>",
+		filepath.Base(inputPath):       "<book>.epub",
+		rag.DefaultBaseName(inputPath): "<book>_athanor",
+		baseName:                       "<book>_athanor",
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		placeholders[home] = "~"
+	}
+	olds := make([]string, 0, len(placeholders))
+	for old := range placeholders {
+		if old != "" && old != "." {
+			olds = append(olds, old)
+		}
+	}
+	sort.Slice(olds, func(i, j int) bool { return len(olds[i]) > len(olds[j]) })
+	pairs := make([]string, 0, 2*len(olds))
+	for _, old := range olds {
+		pairs = append(pairs, old, placeholders[old])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func writeFailureReport(reportPath string, report map[string]any, logs []string) error {
+	file, err := os.Create(reportPath)
+	if err != nil {
+		return fmt.Errorf("创建问题报告失败: %w", err)
+	}
+	defer file.Close()
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化问题报告失败: %w", err)
+	}
+	writer := zip.NewWriter(file)
+	for _, entry := range []struct{ name, content string }{
+		{"report.json", string(data) + "\n"},
+		{"logs.txt", strings.Join(logs, "\n") + "\n"},
+	} {
+		w, err := writer.Create(entry.name)
+		if err != nil {
+			return fmt.Errorf("写入问题报告失败: %w", err)
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			return fmt.Errorf("写入问题报告失败: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("写入问题报告失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateFailureReportRedactsPaths(t *testing.T) {
+	workDir := filepath.Join(".", ".tmp", "test-report")
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatalf("mkdir work dir: %v", err)
+	}
+	input := filepath.Join(workDir, "Secret Title.epub")
+	if err := os.WriteFile(input, []byte("not a zip"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	app := NewApp()
+	result := app.ConvertBook(input, "md")
+	if !result.IsError {
+		t.Fatal("expected a broken EPUB to fail")
+	}
+	if _, err := app.ExportJobBundle(result.JobID); err == nil {
+		t.Fatal("a failed job should have no output bundle")
+	}
+
+	reportPath, err := app.CreateFailureReport(result.JobID)
+	if err != nil {
+		t.Fatalf("create report: %v", err)
+	}
+	defer os.Remove(reportPath)
+	reader, err := zip.OpenReader(reportPath)
+	if err != nil {
+		t.Fatalf("open report: %v", err)
+	}
+	defer reader.Close()
+
+	names := map[string]bool{}
+	for _, file := range reader.File {
+		names[file.Name] = true
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if strings.Contains(string(data), "Secret Title") || strings.Contains(string(data), workDir) {
+			t.Fatalf("%s leaks the book name or path:\n%s", file.Name, data)
+		}
+	}
+	if !names["report.json"] || !names["logs.txt"] {
+		t.Fatalf("unexpected report entries: %v", names)
+	}
+}
+
+func TestCreateFailureReportRedactsOutputNameAndDetails(t *testing.T) {
+	input := filepath.Join(".", ".tmp", "test-report", "Secret Title.epub")
+	baseName := "Secret Saga_02_Secret Title_athanor_2"
+
+	app := NewApp()
+	logStart := app.currentLogSeq()
+	app.log("Output exists, writing to: " + baseName)
+	app.log("🗑️ 已移除重复章节 chapter-003 («Secret Chapter»)，与 chapter-001 相同")
+	app.recordJob("job_failed", jobRecord{
+		InputPath: input,
+		BaseName:  baseName,
+		Error:     "转换存在警告",
+		logStart:  logStart,
+		logEnd:    app.currentLogSeq(),
+	})
+
+	reportPath, err := app.CreateFailureReport("job_failed")
+	if err != nil {
+		t.Fatalf("create report: %v", err)
+	}
+	defer os.Remove(reportPath)
+	reader, err := zip.OpenReader(reportPath)
+	if err != nil {
+		t.Fatalf("open report: %v", err)
+	}
+	defer reader.Close()
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if strings.Contains(string(data), "Secret") {
+			t.Fatalf("%s leaks the output name or a chapter title:\n%s", file.Name, data)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+// Settings are frontend-owned preferences (selected formats, panel state,
+// and so on) that the backend only stores. They live under the "settings"
+// key of the config file, next to the conversion defaults the backend acts
+// on.
+type Settings map[string]any
+
+func (a *App) GetSettings() (Settings, error) {
+	path, err := a.configFile()
+	if err != nil {
+		return Settings{}, err
+	}
+	cfg, err := readConfig(path)
+	if err != nil {
+		return Settings{}, err
+	}
+	if cfg.Settings == nil {
+		return Settings{}, nil
+	}
+	return cfg.Settings, nil
+}
+
+// SetSettings replaces the stored settings and leaves the rest of the
+// config file as it is. A config file that cannot be read is not
+// overwritten.
+func (a *App) SetSettings(settings Settings) error {
+	a.saveMu.Lock()
+	defer a.saveMu.Unlock()
+
+	path, err := a.configFile()
+	if err != nil {
+		return err
+	}
+	cfg, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	if settings == nil {
+		settings = Settings{}
+	}
+	cfg.Settings = settings
+	if err := writeConfig(path, cfg); err != nil {
+		return err
+	}
+	a.applyConfig(cfg)
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSettingsPersist(t *testing.T) {
+	dir := filepath.Join(".", ".tmp", "test-settings")
+	_ = os.RemoveAll(dir)
+
+	app := NewApp()
+	app.configPath = filepath.Join(dir, "config.json")
+
+	settings, err := app.GetSettings()
+	if err != nil || len(settings) != 0 {
+		t.Fatalf("expected empty settings, got %v (%v)", settings, err)
+	}
+	if err := app.SetSettings(Settings{"formats": []any{"md", "txt"}, "showLog": true}); err != nil {
+		t.Fatalf("set settings: %v", err)
+	}
+
+	restarted := NewApp()
+	restarted.configPath = app.configPath
+	settings, err = restarted.GetSettings()
+	if err != nil {
+		t.Fatalf("get settings: %v", err)
+	}
+	formats, ok := settings["formats"].([]any)
+	if !ok || len(formats) != 2 || settings["showLog"] != true {
+		t.Fatalf("settings did not survive restart: %v", settings)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "settings.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("settings should live in config.json, not a file of their own: %v", err)
+	}
+}
+
+func TestSettingsShareTheConfigFile(t *testing.T) {
+	dir := filepath.Join(".", ".tmp", "test-settings-config")
+	_ = os.RemoveAll(dir)
+
+	app := NewApp()
+	app.configPath = filepath.Join(dir, "config.json")
+	if _, err := app.SaveConfig(Config{OutputDir: filepath.Join(dir, "out")}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	if err := app.SetSettings(Settings{"showLog": true}); err != nil {
+		t.Fatalf("set settings: %v", err)
+	}
+	if _, err := app.SaveConfig(Config{OutputDir: filepath.Join(dir, "other")}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	cfg, err := readConfig(app.configPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if cfg.OutputDir != filepath.Join(dir, "other") || cfg.Settings["showLog"] != true {
+		t.Fatalf("config and settings should not overwrite each other, got %+v", cfg)
+	}
+}